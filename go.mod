@@ -5,9 +5,11 @@ go 1.12
 require (
 	github.com/fatih/color v1.7.0
 	github.com/globalsign/mgo v0.0.0-20181015135952-eeefdecb41b8
+	github.com/go-sql-driver/mysql v1.5.0
 	github.com/jamillosantos/macchiato v0.0.0-20171220130318-3be045cc5033
 	github.com/lab259/rlog/v2 v2.1.0
 	github.com/lib/pq v1.1.1
+	github.com/mattn/go-sqlite3 v1.11.0
 	github.com/onsi/ginkgo v1.8.0
 	github.com/onsi/gomega v1.5.0
 )
@@ -0,0 +1,86 @@
+package migration
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+)
+
+// ToolVersion is the version of the tool running the migrations. It is stored
+// on each migration.HistoryRecord.
+//
+// It can be set at build time:
+//
+//	go build -ldflags "-X github.com/lab259/go-migration.ToolVersion=v1.2.3"
+var ToolVersion = "dev"
+
+// HistoryRecord is the record persisted by the targets for each migration
+// applied or reverted and for each failed attempt of running a migration.
+type HistoryRecord struct {
+	ID          time.Time
+	Description string
+	Direction   Direction
+
+	// AppliedAt is when the migration started running.
+	AppliedAt time.Time
+	Duration  time.Duration
+	Checksum  string
+
+	// Hostname and User identify who ran the migration.
+	Hostname    string
+	User        string
+	ToolVersion string
+
+	// Failed is set for failed attempts, that have the error message stored
+	// in Failure.
+	Failed  bool
+	Failure string
+
+	// Baselined is set for the migrations marked as executed by
+	// migration.ManagerDefault.Baseline, that were never ran by the tool.
+	Baselined bool
+}
+
+// NewHistoryRecord creates a migration.HistoryRecord from the summary of a
+// migration, filling in the host, the user and the migration.ToolVersion.
+func NewHistoryRecord(summary *Summary) *HistoryRecord {
+	record := &HistoryRecord{
+		ID:          summary.Migration.GetID(),
+		Description: summary.Migration.GetDescription(),
+		Direction:   summary.Direction(),
+		AppliedAt:   summary.StartedAt(),
+		Duration:    summary.Duration(),
+		Checksum:    summary.Checksum(),
+		Hostname:    currentHostname(),
+		User:        currentUser(),
+		ToolVersion: ToolVersion,
+		Baselined:   summary.Baselined(),
+	}
+	if record.AppliedAt.IsZero() {
+		record.AppliedAt = time.Now()
+	}
+	if summary.Panicked() {
+		record.Failed = true
+		record.Failure = fmt.Sprintf("panic: %v", summary.PanicData())
+	} else if summary.Failed() {
+		record.Failed = true
+		record.Failure = summary.Failure().Error()
+	}
+	return record
+}
+
+func currentHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return hostname
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
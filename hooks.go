@@ -0,0 +1,102 @@
+package migration
+
+import (
+	"context"
+)
+
+// Hooks are functions called by the migration.ManagerDefault while running
+// the migrations of a command (eg. migrate, rewind or do). They are
+// registered with migration.WithHooks and any of them can be nil.
+//
+// A hook returning an error aborts the run: no further migrations are
+// executed and the error is returned by the manager.
+type Hooks struct {
+	// BeforeAll is called once, before the migrations of the command are
+	// listed and run.
+	BeforeAll func(ctx context.Context, executionContext interface{}) error
+
+	// AfterAll is called once, after all the migrations of the command
+	// succeeded, with their summaries.
+	AfterAll func(ctx context.Context, summaries []*Summary, executionContext interface{}) error
+
+	// BeforeEach is called before each migration. The execution context is
+	// the one the migration receives, which is the transaction when the
+	// migration runs inside of one.
+	BeforeEach func(ctx context.Context, summary *Summary, executionContext interface{}) error
+
+	// AfterEach is called after each migration succeeds, before it is
+	// recorded on the target. When the migration runs inside of a
+	// transaction, an error rolls it back.
+	AfterEach func(ctx context.Context, summary *Summary, executionContext interface{}) error
+
+	// OnError is called once for each failure aborting the run: a migration,
+	// its bookkeeping, a hook, the lock or the target. The summary is nil when
+	// the failure is not related to a migration (eg. when BeforeAll fails or
+	// the lock cannot be acquired).
+	OnError func(ctx context.Context, summary *Summary, err error, executionContext interface{})
+}
+
+// hookList is the list of hooks registered on a migration.ManagerDefault.
+// They are called in the order they were registered, stopping at the first
+// error.
+type hookList []Hooks
+
+func (hooks hookList) beforeAll(ctx context.Context, executionContext interface{}) error {
+	for _, h := range hooks {
+		if h.BeforeAll == nil {
+			continue
+		}
+		if err := h.BeforeAll(ctx, executionContext); err != nil {
+			hooks.onError(ctx, nil, err, executionContext)
+			return err
+		}
+	}
+	return nil
+}
+
+func (hooks hookList) afterAll(ctx context.Context, summaries []*Summary, executionContext interface{}) error {
+	for _, h := range hooks {
+		if h.AfterAll == nil {
+			continue
+		}
+		if err := h.AfterAll(ctx, summaries, executionContext); err != nil {
+			hooks.onError(ctx, nil, err, executionContext)
+			return err
+		}
+	}
+	return nil
+}
+
+func (hooks hookList) beforeEach(ctx context.Context, summary *Summary, executionContext interface{}) error {
+	for _, h := range hooks {
+		if h.BeforeEach == nil {
+			continue
+		}
+		if err := h.BeforeEach(ctx, summary, executionContext); err != nil {
+			hooks.onError(ctx, summary, err, executionContext)
+			return err
+		}
+	}
+	return nil
+}
+
+func (hooks hookList) afterEach(ctx context.Context, summary *Summary, executionContext interface{}) error {
+	for _, h := range hooks {
+		if h.AfterEach == nil {
+			continue
+		}
+		if err := h.AfterEach(ctx, summary, executionContext); err != nil {
+			hooks.onError(ctx, summary, err, executionContext)
+			return err
+		}
+	}
+	return nil
+}
+
+func (hooks hookList) onError(ctx context.Context, summary *Summary, err error, executionContext interface{}) {
+	for _, h := range hooks {
+		if h.OnError != nil {
+			h.OnError(ctx, summary, err, executionContext)
+		}
+	}
+}
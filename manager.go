@@ -1,5 +1,11 @@
 package migration
 
+import (
+	"context"
+	"io"
+	"time"
+)
+
 // Manager is an interface that describe the common behavior of a migration
 // manager.
 //
@@ -10,6 +16,11 @@ package migration
 // Integrating the `Source` and the `Target`, the `Manager` is responsible for
 // running migrations with its methods `Migrate`, `Rewind`, `Reset`, `Up` and
 // `Down`.
+//
+// The other commands are described by optional interfaces (eg.
+// migration.ManagerTo), implemented by the migration.ManagerDefault. The
+// migration.ArgsRunner checks the manager implements them before running the
+// commands.
 type Manager interface {
 	Source() Source
 	Target() Target
@@ -21,3 +32,126 @@ type Manager interface {
 	Do(listener Reporter, executionContext interface{}) (*Summary, error)
 	Undo(listener Reporter, executionContext interface{}) (*Summary, error)
 }
+
+// ContextManager is implemented by managers that have a `Context` variant
+// of the migration.Manager commands (eg. `MigrateContext`), which stops
+// running migrations as soon as the given `context.Context` is done.
+type ContextManager interface {
+	MigrateContext(ctx context.Context, listener Reporter, executionContext interface{}) ([]*Summary, error)
+	RewindContext(ctx context.Context, listener Reporter, executionContext interface{}) ([]*Summary, error)
+	ResetContext(ctx context.Context, listener Reporter, executionContext interface{}) ([]*Summary, []*Summary, error)
+	DoContext(ctx context.Context, listener Reporter, executionContext interface{}) (*Summary, error)
+	UndoContext(ctx context.Context, listener Reporter, executionContext interface{}) (*Summary, error)
+}
+
+// ManagerRedo is implemented by managers able to undo and do again the last
+// migration executed.
+type ManagerRedo interface {
+	Redo(listener Reporter, executionContext interface{}) (*Summary, *Summary, error)
+	RedoContext(ctx context.Context, listener Reporter, executionContext interface{}) (*Summary, *Summary, error)
+}
+
+// ManagerSteps is implemented by managers able to do, or undo, a number of
+// migrations in a single run.
+type ManagerSteps interface {
+	DoN(n int, listener Reporter, executionContext interface{}) ([]*Summary, error)
+	UndoN(n int, listener Reporter, executionContext interface{}) ([]*Summary, error)
+	DoNContext(ctx context.Context, n int, listener Reporter, executionContext interface{}) ([]*Summary, error)
+	UndoNContext(ctx context.Context, n int, listener Reporter, executionContext interface{}) ([]*Summary, error)
+}
+
+// ManagerTo is implemented by managers able to move the database to a
+// specific version.
+type ManagerTo interface {
+	MigrateTo(version time.Time, listener Reporter, executionContext interface{}) ([]*Summary, error)
+	RewindTo(version time.Time, listener Reporter, executionContext interface{}) ([]*Summary, error)
+	MigrateToContext(ctx context.Context, version time.Time, listener Reporter, executionContext interface{}) ([]*Summary, error)
+	RewindToContext(ctx context.Context, version time.Time, listener Reporter, executionContext interface{}) ([]*Summary, error)
+}
+
+// ManagerVerifier is implemented by managers able to check the migrations
+// executed against the checksums recorded, and the undo of the migrations.
+type ManagerVerifier interface {
+	Verify(listener Reporter) ([]*ChecksumMismatch, error)
+	VerifyReversible(snapshotter Snapshotter, listener Reporter, executionContext interface{}) ([]*Residue, error)
+	VerifyContext(ctx context.Context, listener Reporter) ([]*ChecksumMismatch, error)
+	VerifyReversibleContext(ctx context.Context, snapshotter Snapshotter, listener Reporter, executionContext interface{}) ([]*Residue, error)
+}
+
+// ManagerHistory is implemented by managers able to list the history and the
+// status of the migrations.
+type ManagerHistory interface {
+	History() ([]*HistoryRecord, error)
+	Status() ([]*StatusRow, error)
+	HistoryContext(ctx context.Context) ([]*HistoryRecord, error)
+	StatusContext(ctx context.Context) ([]*StatusRow, error)
+}
+
+// ManagerMarker is implemented by managers able to change the migrations
+// executed without running them.
+type ManagerMarker interface {
+	Baseline(version time.Time) ([]*Summary, error)
+	MarkApplied(version time.Time) (*Summary, error)
+	MarkReverted(version time.Time) (*Summary, error)
+	BaselineContext(ctx context.Context, version time.Time) ([]*Summary, error)
+	MarkAppliedContext(ctx context.Context, version time.Time) (*Summary, error)
+	MarkRevertedContext(ctx context.Context, version time.Time) (*Summary, error)
+}
+
+// ManagerPlanner is implemented by managers able to plan the commands, and
+// to write the plans as SQL scripts, without executing anything.
+type ManagerPlanner interface {
+	PlanMigrate() (*Plan, error)
+	PlanMigrateTo(version time.Time) (*Plan, error)
+	PlanRewind() (*Plan, error)
+	PlanRewindTo(version time.Time) (*Plan, error)
+	PlanReset() (*Plan, error)
+	WriteSQL(plan *Plan, w io.Writer) error
+}
+
+// ChecksumMismatch describes an executed migration whose checksum differs
+// from the one recorded when it was applied.
+type ChecksumMismatch struct {
+	Migration Migration
+
+	// Expected is the checksum recorded on the target.
+	Expected string
+
+	// Actual is the current checksum of the migration.
+	Actual string
+}
+
+func managerMigrate(ctx context.Context, manager Manager, listener Reporter, executionContext interface{}) ([]*Summary, error) {
+	if m, ok := manager.(ContextManager); ok {
+		return m.MigrateContext(ctx, listener, executionContext)
+	}
+	return manager.Migrate(listener, executionContext)
+}
+
+func managerRewind(ctx context.Context, manager Manager, listener Reporter, executionContext interface{}) ([]*Summary, error) {
+	if m, ok := manager.(ContextManager); ok {
+		return m.RewindContext(ctx, listener, executionContext)
+	}
+	return manager.Rewind(listener, executionContext)
+}
+
+func managerReset(ctx context.Context, manager Manager, listener Reporter, executionContext interface{}) ([]*Summary, []*Summary, error) {
+	if m, ok := manager.(ContextManager); ok {
+		return m.ResetContext(ctx, listener, executionContext)
+	}
+	return manager.Reset(listener, executionContext)
+}
+
+func managerDo(ctx context.Context, manager Manager, listener Reporter, executionContext interface{}) (*Summary, error) {
+	if m, ok := manager.(ContextManager); ok {
+		return m.DoContext(ctx, listener, executionContext)
+	}
+	return manager.Do(listener, executionContext)
+}
+
+func managerUndo(ctx context.Context, manager Manager, listener Reporter, executionContext interface{}) (*Summary, error) {
+	if m, ok := manager.(ContextManager); ok {
+		return m.UndoContext(ctx, listener, executionContext)
+	}
+	return manager.Undo(listener, executionContext)
+}
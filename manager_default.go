@@ -1,7 +1,11 @@
 package migration
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"sort"
 	"time"
 )
 
@@ -11,21 +15,158 @@ var ErrMigrationPanicked = errors.New("migration panicked")
 // ErrMigrationStarved is when late migrations are detected.
 var ErrMigrationStarved = errors.New("migration starvation")
 
+// ErrChecksumNotSupported is returned by migration.ManagerDefault.Verify when
+// the target does not implement the migration.ChecksumTarget.
+var ErrChecksumNotSupported = errors.New("the target does not store checksums")
+
+// ErrHistoryNotSupported is returned by migration.ManagerDefault.History when
+// the target does not implement the migration.HistoryTarget.
+var ErrHistoryNotSupported = errors.New("the target does not store the history")
+
+// ErrRepeatableNotSupported is returned when the source has repeatable
+// migrations, but the target does not implement the
+// migration.RepeatableTarget.
+var ErrRepeatableNotSupported = errors.New("the target does not track repeatable migrations")
+
+// ErrMigrationNotFound is returned when the version informed does not match
+// any migration of the source.
+var ErrMigrationNotFound = errors.New("migration not found")
+
+// ErrMigrationExecuted is returned by migration.ManagerDefault.MarkApplied
+// when the migration is already recorded as executed.
+var ErrMigrationExecuted = errors.New("migration already executed")
+
+// ErrMigrationNotExecuted is returned by migration.ManagerDefault.MarkReverted
+// when the migration is not recorded as executed.
+var ErrMigrationNotExecuted = errors.New("migration not executed")
+
 // ManagerDefault is a default implementation of a Manager. It provides, via
 // migration.NewManager, a way to define what is the source and target of a
 // manager.
 type ManagerDefault struct {
-	source Source
-	target Target
+	source           Source
+	target           Target
+	lockTimeout      time.Duration
+	outOfOrder       OutOfOrderPolicy
+	allowed          map[int64]bool
+	migrationTimeout time.Duration
+	transactionMode  TransactionMode
+	hooks            hookList
+}
+
+// NewManager creates and returns a migration.ManagerDefault based on a
+// target and source, configured by the given options.
+func NewManager(target Target, source Source, options ...Option) *ManagerDefault {
+	manager := &ManagerDefault{
+		target:      target,
+		source:      source,
+		lockTimeout: DefaultLockTimeout,
+	}
+	for _, option := range options {
+		option(manager)
+	}
+	return manager
 }
 
 // NewDefaultManager creates and returns a migration.Manager implementation
-// (`migration.ManagerDefault`) based on a target and source.
+// (`migration.ManagerDefault`) based on a target and source, with the default
+// options.
 func NewDefaultManager(target Target, source Source) Manager {
-	return &ManagerDefault{
-		target: target,
-		source: source,
+	return NewManager(target, source)
+}
+
+// allowsOutOfOrder checks if the starved migration `m` can be applied,
+// according to the migration.OutOfOrderPolicy of the manager.
+func (manager *ManagerDefault) allowsOutOfOrder(m Migration) bool {
+	switch manager.outOfOrder {
+	case OutOfOrderWarn:
+		return true
+	case OutOfOrderExplicit:
+		return manager.allowed[m.GetID().UnixNano()]
+	default:
+		return false
+	}
+}
+
+// withLock holds the migration lock while `h` runs, if the target is a
+// migration.Locker.
+func (manager *ManagerDefault) withLock(ctx context.Context, h func() error) (err error) {
+	locker, ok := manager.target.(Locker)
+	if !ok {
+		return h()
+	}
+
+	if err = locker.Lock(ctx, manager.lockTimeout); err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := locker.Unlock(); unlockErr != nil && err == nil {
+			err = unlockErr
+		}
+	}()
+
+	return h()
+}
+
+// runAll runs `h` holding the migration lock, between the BeforeAll and the
+// AfterAll hooks. The AfterAll receives the summaries returned by `h`, and it
+// is not called if `h` fails.
+//
+// Failures not related to a migration (eg. acquiring the lock or reading the
+// version of the target) are passed to the OnError hooks with a nil summary.
+// The `ctx` given to `h` keeps track of the errors already passed to them, so
+// they are not reported twice.
+func (manager *ManagerDefault) runAll(ctx context.Context, executionContext interface{}, h func(ctx context.Context) ([]*Summary, error)) error {
+	var reported error
+	ctx = context.WithValue(ctx, reportedErrorKey{}, &reported)
+	err := manager.withLock(ctx, func() error {
+		if err := manager.hooks.beforeAll(ctx, executionContext); err != nil {
+			reported = err
+			return err
+		}
+		summaries, err := h(ctx)
+		if err != nil {
+			return err
+		}
+		if err := manager.hooks.afterAll(ctx, summaries, executionContext); err != nil {
+			reported = err
+			return err
+		}
+		return nil
+	})
+	if err != nil && err != reported {
+		manager.hooks.onError(ctx, nil, err, executionContext)
 	}
+	return err
+}
+
+// reportedErrorKey is the key of the context value, set by runAll, holding the
+// last error passed to the OnError hooks.
+type reportedErrorKey struct{}
+
+// markReported flags the error as passed to the OnError hooks, so the runAll
+// of the `ctx` does not report it again.
+func markReported(ctx context.Context, err error) {
+	if reported, ok := ctx.Value(reportedErrorKey{}).(*error); ok {
+		*reported = err
+	}
+}
+
+// onError calls the OnError hooks, flagging the error as reported.
+func (manager *ManagerDefault) onError(ctx context.Context, summary *Summary, err error, executionContext interface{}) {
+	markReported(ctx, err)
+	manager.hooks.onError(ctx, summary, err, executionContext)
+}
+
+// collectSummaries lists the non nil summaries.
+func collectSummaries(list ...*Summary) []*Summary {
+	result := make([]*Summary, 0, len(list))
+	for _, summary := range list {
+		if summary != nil {
+			result = append(result, summary)
+		}
+	}
+	return result
 }
 
 // Source returns the migration source used for this manager.
@@ -75,12 +216,16 @@ func (manager *ManagerDefault) migrationsAfter(version time.Time) ([]Migration,
 // uses the migration.Manager.MigrationsBefore passing on the current version
 // from migration.Manager.Target.Version.
 func (manager *ManagerDefault) MigrationsPending() ([]Migration, error) {
+	return manager.migrationsPending(context.Background())
+}
+
+func (manager *ManagerDefault) migrationsPending(ctx context.Context) ([]Migration, error) {
 	migrations, err := manager.source.List()
 	if err != nil {
 		return nil, err
 	}
 
-	executed, err := manager.target.MigrationsExecuted()
+	executed, err := targetMigrationsExecuted(ctx, manager.target)
 	if err != nil {
 		return nil, err
 	}
@@ -103,12 +248,16 @@ func (manager *ManagerDefault) MigrationsPending() ([]Migration, error) {
 // the migration.Manager.MigrationsAfter passing on the current version from
 // migration.Manager.Target.Version.
 func (manager *ManagerDefault) MigrationsExecuted() ([]Migration, error) {
+	return manager.migrationsExecuted(context.Background())
+}
+
+func (manager *ManagerDefault) migrationsExecuted(ctx context.Context) ([]Migration, error) {
 	migrations, err := manager.source.List()
 	if err != nil {
 		return nil, err
 	}
 
-	executed, err := manager.target.MigrationsExecuted()
+	executed, err := targetMigrationsExecuted(ctx, manager.target)
 	if err != nil {
 		return nil, err
 	}
@@ -134,11 +283,41 @@ func (manager *ManagerDefault) MigrationsExecuted() ([]Migration, error) {
 // After the migration is executed, if it returns no error, it calls the
 // reporter.After method.
 func (manager *ManagerDefault) Do(reporter Reporter, executionContext interface{}) (*Summary, error) {
-	version, err := manager.target.Version()
+	return manager.DoContext(context.Background(), reporter, executionContext)
+}
+
+// DoContext is the migration.ManagerDefault.Do with a `context.Context`.
+func (manager *ManagerDefault) DoContext(ctx context.Context, reporter Reporter, executionContext interface{}) (summary *Summary, err error) {
+	err = manager.runAll(ctx, executionContext, func(ctx context.Context) ([]*Summary, error) {
+		summary, err = manager.doNext(ctx, reporter, executionContext)
+		return collectSummaries(summary), err
+	})
+	return
+}
+
+// DoN does up to `n` pending migrations, one by one, stopping at the first
+// error or when there is nothing else to be done. All the steps run holding
+// the migration lock, between a single BeforeAll and AfterAll.
+//
+// Each step is reported by the reporter.MigrationSummary method, as the `do`
+// command does.
+func (manager *ManagerDefault) DoN(n int, reporter Reporter, executionContext interface{}) ([]*Summary, error) {
+	return manager.DoNContext(context.Background(), n, reporter, executionContext)
+}
+
+// DoNContext is the migration.ManagerDefault.DoN with a `context.Context`.
+func (manager *ManagerDefault) DoNContext(ctx context.Context, n int, reporter Reporter, executionContext interface{}) ([]*Summary, error) {
+	return manager.runSteps(ctx, n, reporter, executionContext, func() (*Summary, error) {
+		return manager.doNext(ctx, reporter, executionContext)
+	})
+}
+
+func (manager *ManagerDefault) doNext(ctx context.Context, reporter Reporter, executionContext interface{}) (*Summary, error) {
+	version, err := targetVersion(ctx, manager.target)
 	if err != nil {
 		return nil, err
 	}
-	migrations, err := manager.MigrationsPending()
+	migrations, err := manager.migrationsPending(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -152,47 +331,11 @@ func (manager *ManagerDefault) Do(reporter Reporter, executionContext interface{
 	if len(migrations) == 0 {
 		return nil, nil
 	}
-	return manager.do(migrations[0], reporter, executionContext)
+	return manager.do(ctx, migrations[0], reporter, executionContext)
 }
 
-func (manager *ManagerDefault) do(m Migration, reporter Reporter, executionContext interface{}) (summary *Summary, err error) {
-	summary = &Summary{
-		Migration: m,
-		direction: DirectionDo,
-	}
-	reporter.BeforeMigration(*summary, nil)
-
-	startedAt := time.Now()
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				summary.panicked = true
-				if err, ok := r.(error); ok {
-					summary.setFailed(err)
-				}
-				summary.panicData = r
-				err = ErrMigrationPanicked
-			}
-		}()
-		err = m.Do(executionContext)
-	}()
-	summary.duration = time.Since(startedAt)
-
-	if !summary.panicked && err != nil {
-		summary.setFailed(err)
-		reporter.AfterMigration(*summary, err)
-		return summary, err
-	}
-	reporter.AfterMigration(*summary, err)
-
-	if summary.panicked {
-		return summary, ErrMigrationPanicked
-	}
-	if err = manager.target.AddMigration(summary); err != nil {
-		return summary, err
-	}
-
-	return summary, nil
+func (manager *ManagerDefault) do(ctx context.Context, m Migration, reporter Reporter, executionContext interface{}) (*Summary, error) {
+	return manager.run(ctx, m, DirectionDo, reporter, executionContext)
 }
 
 // Undo takes a step up on the migrations, bringing the database one step closer
@@ -202,7 +345,68 @@ func (manager *ManagerDefault) do(m Migration, reporter Reporter, executionConte
 // After the migration is executed, if it returns no error, it calls the
 // reporter.After method.
 func (manager *ManagerDefault) Undo(reporter Reporter, executionContext interface{}) (*Summary, error) {
-	migrations, err := manager.MigrationsExecuted()
+	return manager.UndoContext(context.Background(), reporter, executionContext)
+}
+
+// UndoContext is the migration.ManagerDefault.Undo with a `context.Context`.
+func (manager *ManagerDefault) UndoContext(ctx context.Context, reporter Reporter, executionContext interface{}) (summary *Summary, err error) {
+	err = manager.runAll(ctx, executionContext, func(ctx context.Context) ([]*Summary, error) {
+		summary, err = manager.undoLast(ctx, reporter, executionContext)
+		return collectSummaries(summary), err
+	})
+	return
+}
+
+// UndoN undoes up to `n` migrations executed, one by one, stopping at the
+// first error or when there is nothing else to be undone. All the steps run
+// holding the migration lock, between a single BeforeAll and AfterAll.
+//
+// Each step is reported by the reporter.MigrationSummary method, as the
+// `undo` command does.
+func (manager *ManagerDefault) UndoN(n int, reporter Reporter, executionContext interface{}) ([]*Summary, error) {
+	return manager.UndoNContext(context.Background(), n, reporter, executionContext)
+}
+
+// UndoNContext is the migration.ManagerDefault.UndoN with a
+// `context.Context`.
+func (manager *ManagerDefault) UndoNContext(ctx context.Context, n int, reporter Reporter, executionContext interface{}) ([]*Summary, error) {
+	return manager.runSteps(ctx, n, reporter, executionContext, func() (*Summary, error) {
+		return manager.undoLast(ctx, reporter, executionContext)
+	})
+}
+
+// runSteps calls `step` up to `n` times inside of a single runAll, reporting
+// each summary. It stops at the first error, or when `step` has nothing else
+// to be done. Errors aborting the run before any step (eg. failing to acquire
+// the lock) are reported as well.
+func (manager *ManagerDefault) runSteps(ctx context.Context, n int, reporter Reporter, executionContext interface{}, step func() (*Summary, error)) (summaries []*Summary, err error) {
+	reported := false
+	err = manager.runAll(ctx, executionContext, func(ctx context.Context) ([]*Summary, error) {
+		summaries = make([]*Summary, 0, n)
+		for i := 0; i < n; i++ {
+			summary, err := step()
+			reporter.MigrationSummary(summary, err)
+			if summary != nil {
+				summaries = append(summaries, summary)
+			}
+			if err != nil {
+				reported = true
+				return summaries, err
+			}
+			if summary == nil {
+				break
+			}
+		}
+		return summaries, nil
+	})
+	if err != nil && !reported {
+		reporter.MigrationSummary(nil, err)
+	}
+	return
+}
+
+func (manager *ManagerDefault) undoLast(ctx context.Context, reporter Reporter, executionContext interface{}) (*Summary, error) {
+	migrations, err := manager.migrationsExecuted(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -210,75 +414,345 @@ func (manager *ManagerDefault) Undo(reporter Reporter, executionContext interfac
 	if len(migrations) == 0 {
 		return nil, nil
 	}
-	summary, err := manager.undo(migrations[len(migrations)-1], reporter, executionContext)
+	summary, err := manager.undo(ctx, migrations[len(migrations)-1], reporter, executionContext)
 	return summary, err
 }
 
-func (manager *ManagerDefault) undo(m Migration, reporter Reporter, executionContext interface{}) (*Summary, error) {
+func (manager *ManagerDefault) undo(ctx context.Context, m Migration, reporter Reporter, executionContext interface{}) (*Summary, error) {
+	return manager.run(ctx, m, DirectionUndo, reporter, executionContext)
+}
+
+// run executes the migration in the given direction and records it on the
+// target.
+//
+// When the target is a migration.TransactionalTarget (and the migration did
+// not opt out through migration.NonTransactional), the migration receives the
+// execution context of the transaction, which is committed only after the
+// migration is recorded.
+//
+// If the `ctx` is done, the migration is not executed and the error of the
+// context is returned.
+func (manager *ManagerDefault) run(ctx context.Context, m Migration, direction Direction, reporter Reporter, executionContext interface{}) (*Summary, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.SetManager(manager)
 	summary := &Summary{
 		Migration: m,
-		direction: DirectionUndo,
+		direction: direction,
+	}
+	if direction == DirectionDo {
+		checksum, err := migrationChecksum(m)
+		if err != nil {
+			return nil, err
+		}
+		summary.checksum = checksum
+	}
+
+	tx, err := manager.begin(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+	if tx != nil {
+		executionContext = tx.ExecutionContext()
 	}
+
+	if err = manager.hooks.beforeEach(ctx, summary, executionContext); err != nil {
+		markReported(ctx, err)
+		if tx != nil {
+			tx.Rollback()
+		}
+		return nil, err
+	}
+
 	reporter.BeforeMigration(*summary, nil)
 
-	startedAt := time.Now()
-	var err error
-	func() {
-		defer func() {
-			if r := recover(); r != nil {
-				summary.panicked = true
-				// TODO Capture the panic info
-				if err, ok := r.(error); ok {
-					summary.setFailed(err)
-				}
-				summary.panicData = r
-				err = ErrMigrationPanicked
-			}
-		}()
-		err = m.Undo(executionContext)
-	}()
-	summary.duration = time.Since(startedAt)
+	summary.startedAt = time.Now()
+	err = manager.callWithTimeout(ctx, summary, executionContext)
+	summary.duration = time.Since(summary.startedAt)
 
 	if !summary.panicked && err != nil {
 		summary.setFailed(err)
-		reporter.AfterMigration(*summary, err)
-		return summary, err
 	}
 	reporter.AfterMigration(*summary, err)
 
-	if summary.panicked {
-		return summary, ErrMigrationPanicked
+	if err != nil {
+		if tx != nil {
+			tx.Rollback()
+		}
+		if target, ok := manager.target.(HistoryTarget); ok {
+			// The failure of the migration is what matters to the caller, so
+			// an error storing the attempt is not reported.
+			target.AddFailure(ctx, NewHistoryRecord(summary))
+		}
+		manager.onError(ctx, summary, err, executionContext)
+		return summary, err
+	}
+
+	if err = manager.hooks.afterEach(ctx, summary, executionContext); err != nil {
+		markReported(ctx, err)
+		if tx != nil {
+			tx.Rollback()
+		}
+		return summary, err
 	}
 
-	if err = manager.target.RemoveMigration(summary); err != nil {
+	if err = manager.record(ctx, tx, summary); err != nil {
+		if tx != nil {
+			tx.Rollback()
+		}
+		manager.onError(ctx, summary, err, executionContext)
 		return summary, err
 	}
+
+	if tx != nil {
+		if err = tx.Commit(); err != nil {
+			manager.onError(ctx, summary, err, executionContext)
+			return summary, err
+		}
+	}
 	return summary, nil
 }
 
+// callWithTimeout calls the handler of the migration limited by the migration
+// timeout of the manager, if any.
+func (manager *ManagerDefault) callWithTimeout(ctx context.Context, summary *Summary, executionContext interface{}) error {
+	if manager.migrationTimeout <= 0 {
+		return manager.call(ctx, summary, executionContext)
+	}
+	ctx, cancel := context.WithTimeout(ctx, manager.migrationTimeout)
+	defer cancel()
+	return manager.call(ctx, summary, executionContext)
+}
+
+// call calls the handler of the migration for the direction of the summary,
+// recovering from any panic. The `ctx` is passed along to migrations
+// implementing the migration.ContextMigration.
+func (manager *ManagerDefault) call(ctx context.Context, summary *Summary, executionContext interface{}) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			summary.panicked = true
+			if err, ok := r.(error); ok {
+				summary.setFailed(err)
+			}
+			summary.panicData = r
+			err = ErrMigrationPanicked
+		}
+	}()
+	if m, ok := summary.Migration.(ContextMigration); ok {
+		if summary.direction == DirectionDo {
+			return m.DoContext(ctx, executionContext)
+		}
+		return m.UndoContext(ctx, executionContext)
+	}
+	if summary.direction == DirectionDo {
+		return summary.Migration.Do(executionContext)
+	}
+	return summary.Migration.Undo(executionContext)
+}
+
+// begin starts a transaction for the migration, if the target supports it. It
+// returns nil when the migration should not run inside of a transaction.
+func (manager *ManagerDefault) begin(ctx context.Context, m Migration) (Transaction, error) {
+	if !manager.transactional(m) {
+		return nil, nil
+	}
+	return manager.target.(TransactionalTarget).Begin(ctx)
+}
+
+// transactional checks if the migration should run inside of a transaction:
+// the target must be a migration.TransactionalTarget, transactions must not
+// be disabled on the manager and the migration must not opt out of them.
+func (manager *ManagerDefault) transactional(m Migration) bool {
+	if _, ok := manager.target.(TransactionalTarget); !ok {
+		return false
+	}
+	if manager.transactionMode == TransactionNone {
+		return false
+	}
+	if nt, ok := m.(NonTransactional); ok && nt.NonTransactional() {
+		return false
+	}
+	return true
+}
+
+// record adds (or removes, depending on the direction) the migration of the
+// summary to the target. If a transaction is given, it is used instead.
+func (manager *ManagerDefault) record(ctx context.Context, tx Transaction, summary *Summary) error {
+	if tx != nil {
+		if summary.direction == DirectionDo {
+			return tx.AddMigration(summary)
+		}
+		return tx.RemoveMigration(summary)
+	}
+	if summary.direction == DirectionDo {
+		return targetAddMigration(ctx, manager.target, summary)
+	}
+	return targetRemoveMigration(ctx, manager.target, summary)
+}
+
+// detectStarvation reports the migrations of the `list` older than the
+// `version`. It fails with migration.ErrMigrationStarved if any of them is not
+// allowed to be applied out of order.
 func (manager *ManagerDefault) detectStarvation(reporter Reporter, list []Migration, version time.Time) error {
 	migrationsStarved := make([]Migration, 0)
+	blocked := false
 
 	for _, m := range list {
 		if m.GetID().Before(version) {
 			migrationsStarved = append(migrationsStarved, m)
+			if !manager.allowsOutOfOrder(m) {
+				blocked = true
+			}
 		}
 	}
 
 	if len(migrationsStarved) > 0 {
 		reporter.MigrationsStarved(migrationsStarved)
-		return ErrMigrationStarved
+		if blocked {
+			return ErrMigrationStarved
+		}
 	}
 	return nil
 }
 
-// Migrate brings the database to the latest migration.
+// Migrate brings the database to the latest migration. Then, it applies the
+// repeatable migrations that changed since they were last applied.
 func (manager *ManagerDefault) Migrate(reporter Reporter, executionContext interface{}) ([]*Summary, error) {
-	version, err := manager.target.Version()
+	return manager.MigrateContext(context.Background(), reporter, executionContext)
+}
+
+// MigrateContext is the migration.ManagerDefault.Migrate with a
+// `context.Context`.
+//
+// When the `ctx` is done, no further migrations are executed and the error of
+// the context is returned.
+func (manager *ManagerDefault) MigrateContext(ctx context.Context, reporter Reporter, executionContext interface{}) (summaries []*Summary, err error) {
+	err = manager.runAll(ctx, executionContext, func(ctx context.Context) ([]*Summary, error) {
+		summaries, err = manager.migrate(ctx, reporter, executionContext, false)
+		return summaries, err
+	})
+	return
+}
+
+// migrate applies the pending migrations and, then, the repeatable ones that
+// changed. If `reapply` is set, all the repeatable migrations are applied.
+func (manager *ManagerDefault) migrate(ctx context.Context, reporter Reporter, executionContext interface{}, reapply bool) ([]*Summary, error) {
+	list, err := manager.pendingNotStarved(ctx, reporter)
 	if err != nil {
 		return nil, err
 	}
-	list, err := manager.MigrationsPending()
+	repeatables, err := manager.pendingRepeatables(ctx, reapply)
+	if err != nil {
+		return nil, err
+	}
+	return manager.doAll(ctx, append(list, repeatables...), reporter, executionContext)
+}
+
+// pendingRepeatables lists the repeatable migrations whose checksums differ
+// from the ones recorded on the target. Repeatable migrations without a
+// checksum are always listed, as well as all of them if `all` is set.
+func (manager *ManagerDefault) pendingRepeatables(ctx context.Context, all bool) ([]Migration, error) {
+	source, ok := manager.source.(RepeatableSource)
+	if !ok {
+		return nil, nil
+	}
+	list, err := source.ListRepeatable()
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	target, ok := manager.target.(RepeatableTarget)
+	if !ok {
+		return nil, ErrRepeatableNotSupported
+	}
+	checksums, err := target.RepeatableChecksums(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Migration, 0, len(list))
+	for _, m := range list {
+		checksum, err := migrationChecksum(m)
+		if err != nil {
+			return nil, err
+		}
+		recorded, ok := checksums[m.GetDescription()]
+		if all || !ok || checksum == "" || checksum != recorded {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// MigrateTo applies all the pending migrations up to the given `version`
+// (inclusive).
+//
+// If the `version` does not match any migration of the source, it returns
+// migration.ErrMigrationNotFound and no migrations are executed.
+func (manager *ManagerDefault) MigrateTo(version time.Time, reporter Reporter, executionContext interface{}) ([]*Summary, error) {
+	return manager.MigrateToContext(context.Background(), version, reporter, executionContext)
+}
+
+// MigrateToContext is the migration.ManagerDefault.MigrateTo with a
+// `context.Context`.
+func (manager *ManagerDefault) MigrateToContext(ctx context.Context, version time.Time, reporter Reporter, executionContext interface{}) (summaries []*Summary, err error) {
+	err = manager.runAll(ctx, executionContext, func(ctx context.Context) ([]*Summary, error) {
+		summaries, err = manager.migrateTo(ctx, version, reporter, executionContext)
+		return summaries, err
+	})
+	return
+}
+
+func (manager *ManagerDefault) migrateTo(ctx context.Context, version time.Time, reporter Reporter, executionContext interface{}) ([]*Summary, error) {
+	if err := manager.ensureMigration(version); err != nil {
+		return nil, err
+	}
+	list, err := manager.pendingNotStarved(ctx, reporter)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range list {
+		if m.GetID().After(version) {
+			list = list[:i]
+			break
+		}
+	}
+	return manager.doAll(ctx, list, reporter, executionContext)
+}
+
+// ensureMigration checks if there is a migration with the given `version` on
+// the source.
+func (manager *ManagerDefault) ensureMigration(version time.Time) error {
+	_, err := manager.findMigration(version)
+	return err
+}
+
+// findMigration returns the migration of the source with the given `version`,
+// or migration.ErrMigrationNotFound.
+func (manager *ManagerDefault) findMigration(version time.Time) (Migration, error) {
+	migrations, err := manager.source.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range migrations {
+		if m.GetID().Equal(version) {
+			return m, nil
+		}
+	}
+	return nil, ErrMigrationNotFound
+}
+
+// pendingNotStarved lists the pending migrations, failing with
+// migration.ErrMigrationStarved if any of them is older than the current
+// version of the target and the migration.OutOfOrderPolicy does not allow it
+// to be applied.
+func (manager *ManagerDefault) pendingNotStarved(ctx context.Context, reporter Reporter) ([]Migration, error) {
+	version, err := targetVersion(ctx, manager.target)
+	if err != nil {
+		return nil, err
+	}
+	list, err := manager.migrationsPending(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -288,10 +762,16 @@ func (manager *ManagerDefault) Migrate(reporter Reporter, executionContext inter
 			return nil, err
 		}
 	}
+	return list, nil
+}
+
+// doAll applies the `list` of migrations in order, stopping at the first
+// failure.
+func (manager *ManagerDefault) doAll(ctx context.Context, list []Migration, reporter Reporter, executionContext interface{}) ([]*Summary, error) {
 	reporter.BeforeMigrate(list)
 	result := make([]*Summary, 0, len(list))
 	for i := 0; i < len(list); i++ {
-		summary, err := manager.do(list[i], reporter, executionContext)
+		summary, err := manager.do(ctx, list[i], reporter, executionContext)
 		if summary != nil {
 			result = append(result, summary)
 		}
@@ -308,14 +788,72 @@ func (manager *ManagerDefault) Migrate(reporter Reporter, executionContext inter
 // migration.Migrate.Down in a inverted order, virtually bringing the database
 // to its original form.
 func (manager *ManagerDefault) Rewind(reporter Reporter, executionContext interface{}) ([]*Summary, error) {
-	list, err := manager.MigrationsExecuted()
+	return manager.RewindContext(context.Background(), reporter, executionContext)
+}
+
+// RewindContext is the migration.ManagerDefault.Rewind with a
+// `context.Context`.
+//
+// When the `ctx` is done, no further migrations are undone and the error of
+// the context is returned.
+func (manager *ManagerDefault) RewindContext(ctx context.Context, reporter Reporter, executionContext interface{}) (summaries []*Summary, err error) {
+	err = manager.runAll(ctx, executionContext, func(ctx context.Context) ([]*Summary, error) {
+		summaries, err = manager.rewind(ctx, reporter, executionContext)
+		return summaries, err
+	})
+	return
+}
+
+func (manager *ManagerDefault) rewind(ctx context.Context, reporter Reporter, executionContext interface{}) ([]*Summary, error) {
+	list, err := manager.migrationsExecuted(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return manager.undoAll(ctx, list, reporter, executionContext)
+}
+
+// RewindTo undoes all the executed migrations newer than the given `version`.
+// The migration with the `version` itself is kept.
+//
+// If the `version` does not match any migration of the source, it returns
+// migration.ErrMigrationNotFound and no migrations are undone.
+func (manager *ManagerDefault) RewindTo(version time.Time, reporter Reporter, executionContext interface{}) ([]*Summary, error) {
+	return manager.RewindToContext(context.Background(), version, reporter, executionContext)
+}
+
+// RewindToContext is the migration.ManagerDefault.RewindTo with a
+// `context.Context`.
+func (manager *ManagerDefault) RewindToContext(ctx context.Context, version time.Time, reporter Reporter, executionContext interface{}) (summaries []*Summary, err error) {
+	err = manager.runAll(ctx, executionContext, func(ctx context.Context) ([]*Summary, error) {
+		summaries, err = manager.rewindTo(ctx, version, reporter, executionContext)
+		return summaries, err
+	})
+	return
+}
+
+func (manager *ManagerDefault) rewindTo(ctx context.Context, version time.Time, reporter Reporter, executionContext interface{}) ([]*Summary, error) {
+	if err := manager.ensureMigration(version); err != nil {
+		return nil, err
+	}
+	list, err := manager.migrationsExecuted(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kept := 0
+	for kept < len(list) && !list[kept].GetID().After(version) {
+		kept++
+	}
+	list = list[kept:]
+	return manager.undoAll(ctx, list, reporter, executionContext)
+}
+
+// undoAll undoes the `list` of migrations in the inverted order, stopping at
+// the first failure.
+func (manager *ManagerDefault) undoAll(ctx context.Context, list []Migration, reporter Reporter, executionContext interface{}) ([]*Summary, error) {
 	reporter.BeforeRewind(list)
 	result := make([]*Summary, 0, len(list))
 	for i := len(list) - 1; i > -1; i-- {
-		summary, err := manager.undo(list[i], reporter, executionContext)
+		summary, err := manager.undo(ctx, list[i], reporter, executionContext)
 		if summary != nil {
 			result = append(result, summary)
 		}
@@ -326,13 +864,586 @@ func (manager *ManagerDefault) Rewind(reporter Reporter, executionContext interf
 	return result, nil
 }
 
-// Reset rewind all the migrations, then migrates to the latest.
-func (manager *ManagerDefault) Reset(reporter Reporter, executionContext interface{}) ([]*Summary, []*Summary, error) {
-	reporter.BeforeReset()
-	migrationsBack, err := manager.Rewind(reporter, executionContext)
+// Redo undoes the last executed migration and, then, does it again. It returns
+// the summaries of both steps.
+//
+// If there is no executed migration, nothing is done and both summaries are
+// nil. If the undo step fails, the migration is not done again.
+func (manager *ManagerDefault) Redo(reporter Reporter, executionContext interface{}) (*Summary, *Summary, error) {
+	return manager.RedoContext(context.Background(), reporter, executionContext)
+}
+
+// RedoContext is the migration.ManagerDefault.Redo with a `context.Context`.
+func (manager *ManagerDefault) RedoContext(ctx context.Context, reporter Reporter, executionContext interface{}) (undone *Summary, done *Summary, err error) {
+	err = manager.runAll(ctx, executionContext, func(ctx context.Context) ([]*Summary, error) {
+		undone, err = manager.undoLast(ctx, reporter, executionContext)
+		if undone == nil || err != nil {
+			return collectSummaries(undone), err
+		}
+		done, err = manager.do(ctx, undone.Migration, reporter, executionContext)
+		return collectSummaries(undone, done), err
+	})
+	return
+}
+
+// Verify compares the checksum of each executed migration with the checksum
+// recorded when it was applied. The migrations that differ are returned and,
+// if the reporter is a migration.VerifyReporter, reported through its
+// ChecksumMismatches.
+//
+// Migrations without a checksum, on either side, are not verified.
+func (manager *ManagerDefault) Verify(reporter Reporter) ([]*ChecksumMismatch, error) {
+	return manager.VerifyContext(context.Background(), reporter)
+}
+
+// VerifyContext is the migration.ManagerDefault.Verify with a
+// `context.Context`.
+func (manager *ManagerDefault) VerifyContext(ctx context.Context, reporter Reporter) ([]*ChecksumMismatch, error) {
+	target, ok := manager.target.(ChecksumTarget)
+	if !ok {
+		return nil, ErrChecksumNotSupported
+	}
+	recorded, err := target.Checksums(ctx)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := manager.migrationsExecuted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := make([]*ChecksumMismatch, 0)
+	for _, m := range migrations {
+		expected := recorded[m.GetID().UnixNano()]
+		if expected == "" {
+			continue
+		}
+		actual, err := migrationChecksum(m)
+		if err != nil {
+			return nil, err
+		}
+		if actual != "" && actual != expected {
+			mismatches = append(mismatches, &ChecksumMismatch{
+				Migration: m,
+				Expected:  expected,
+				Actual:    actual,
+			})
+		}
+	}
+	if r, ok := reporter.(VerifyReporter); ok && len(mismatches) > 0 {
+		r.ChecksumMismatches(mismatches)
+	}
+	return mismatches, nil
+}
+
+// VerifyReversible checks if the undo of each pending migration reverses its
+// do. Each migration is done, undone and done again, in order. The
+// `snapshotter` captures the database before the do and after the undo; the
+// migrations whose snapshots differ are returned and, if the reporter is a
+// migration.ResidueReporter, reported through its Residues.
+//
+// It stops at the first migration that fails, in either direction. As the
+// migrations are done for real, it is meant to run against a disposable
+// database.
+func (manager *ManagerDefault) VerifyReversible(snapshotter Snapshotter, reporter Reporter, executionContext interface{}) ([]*Residue, error) {
+	return manager.VerifyReversibleContext(context.Background(), snapshotter, reporter, executionContext)
+}
+
+// VerifyReversibleContext is the migration.ManagerDefault.VerifyReversible
+// with a `context.Context`.
+func (manager *ManagerDefault) VerifyReversibleContext(ctx context.Context, snapshotter Snapshotter, reporter Reporter, executionContext interface{}) (residues []*Residue, err error) {
+	err = manager.withLock(ctx, func() error {
+		residues, err = manager.verifyReversible(ctx, snapshotter, reporter, executionContext)
+		return err
+	})
+	return
+}
+
+func (manager *ManagerDefault) verifyReversible(ctx context.Context, snapshotter Snapshotter, reporter Reporter, executionContext interface{}) ([]*Residue, error) {
+	list, err := manager.pendingNotStarved(ctx, reporter)
+	if err != nil {
+		return nil, err
+	}
+
+	reporter.BeforeMigrate(list)
+	residues := make([]*Residue, 0)
+	for _, m := range list {
+		before, err := snapshotter.Snapshot(ctx)
+		if err != nil {
+			return residues, err
+		}
+		if _, err = manager.do(ctx, m, reporter, executionContext); err != nil {
+			return residues, err
+		}
+		if _, err = manager.undo(ctx, m, reporter, executionContext); err != nil {
+			return residues, err
+		}
+		after, err := snapshotter.Snapshot(ctx)
+		if err != nil {
+			return residues, err
+		}
+		if added, removed := after.Diff(before); len(added) > 0 || len(removed) > 0 {
+			residues = append(residues, &Residue{
+				Migration: m,
+				Added:     added,
+				Removed:   removed,
+			})
+		}
+		if _, err = manager.do(ctx, m, reporter, executionContext); err != nil {
+			return residues, err
+		}
+	}
+	if r, ok := reporter.(ResidueReporter); ok && len(residues) > 0 {
+		r.Residues(residues)
+	}
+	return residues, nil
+}
+
+// History returns the records of the applied and reverted migrations and of
+// the failed attempts, oldest first.
+func (manager *ManagerDefault) History() ([]*HistoryRecord, error) {
+	return manager.HistoryContext(context.Background())
+}
+
+// HistoryContext is the migration.ManagerDefault.History with a
+// `context.Context`.
+func (manager *ManagerDefault) HistoryContext(ctx context.Context) ([]*HistoryRecord, error) {
+	target, ok := manager.target.(HistoryTarget)
+	if !ok {
+		return nil, ErrHistoryNotSupported
+	}
+	return target.History(ctx)
+}
+
+// Status reconciles the source with the target, returning one row per
+// migration ID, sorted: the migrations applied (or with a checksum mismatch,
+// if the target implements the migration.ChecksumTarget), pending and
+// starved, along with the IDs recorded on the target that are not on the
+// source anymore. Repeatable migrations are not listed.
+func (manager *ManagerDefault) Status() ([]*StatusRow, error) {
+	return manager.StatusContext(context.Background())
+}
+
+// StatusContext is the migration.ManagerDefault.Status with a
+// `context.Context`.
+func (manager *ManagerDefault) StatusContext(ctx context.Context) ([]*StatusRow, error) {
+	migrations, err := manager.source.List()
+	if err != nil {
+		return nil, err
+	}
+	executed, err := targetMigrationsExecuted(ctx, manager.target)
+	if err != nil {
+		return nil, err
+	}
+	var recorded map[int64]string
+	if target, ok := manager.target.(ChecksumTarget); ok {
+		recorded, err = target.Checksums(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	version := NoVersion
+	executedMap := make(map[int64]bool, len(executed))
+	for _, id := range executed {
+		executedMap[id.UnixNano()] = true
+		if id.After(version) {
+			version = id
+		}
+	}
+
+	rows := make([]*StatusRow, 0, len(migrations)+len(executed))
+	listed := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		listed[m.GetID().UnixNano()] = true
+		row := &StatusRow{
+			ID:        m.GetID(),
+			Migration: m,
+		}
+		switch {
+		case executedMap[m.GetID().UnixNano()]:
+			row.State = StateApplied
+			if expected := recorded[m.GetID().UnixNano()]; expected != "" {
+				actual, err := migrationChecksum(m)
+				if err != nil {
+					return nil, err
+				}
+				if actual != "" && actual != expected {
+					row.State = StateChecksumMismatch
+					row.Expected = expected
+					row.Actual = actual
+				}
+			}
+		case m.GetID().Before(version):
+			row.State = StateStarved
+		default:
+			row.State = StatePending
+		}
+		rows = append(rows, row)
+	}
+	for _, id := range executed {
+		if !listed[id.UnixNano()] {
+			rows = append(rows, &StatusRow{
+				ID:    id.UTC(),
+				State: StateOrphaned,
+			})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].ID.Before(rows[j].ID)
+	})
+	return rows, nil
+}
+
+// Baseline marks all the pending migrations up to the given `version`
+// (inclusive) as executed, without running them. It is meant to adopt the
+// tool on databases whose schema predates it. The migrations are recorded as
+// baselined (see migration.HistoryRecord.Baselined).
+func (manager *ManagerDefault) Baseline(version time.Time) ([]*Summary, error) {
+	return manager.BaselineContext(context.Background(), version)
+}
+
+// BaselineContext is the migration.ManagerDefault.Baseline with a
+// `context.Context`.
+func (manager *ManagerDefault) BaselineContext(ctx context.Context, version time.Time) (summaries []*Summary, err error) {
+	err = manager.withLock(ctx, func() error {
+		summaries, err = manager.baseline(ctx, version)
+		return err
+	})
+	return
+}
+
+func (manager *ManagerDefault) baseline(ctx context.Context, version time.Time) ([]*Summary, error) {
+	if err := manager.ensureMigration(version); err != nil {
+		return nil, err
+	}
+	list, err := manager.migrationsPending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]*Summary, 0, len(list))
+	for _, m := range list {
+		if m.GetID().After(version) {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return summaries, err
+		}
+		checksum, err := migrationChecksum(m)
+		if err != nil {
+			return summaries, err
+		}
+		summary := NewSummary(m)
+		summary.direction = DirectionDo
+		summary.startedAt = time.Now()
+		summary.checksum = checksum
+		summary.baselined = true
+		if err := targetAddMigration(ctx, manager.target, summary); err != nil {
+			return summaries, err
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// MarkApplied records the migration with the given `version` as executed,
+// without running it. It is meant to repair the bookkeeping after the schema
+// was fixed by hand.
+func (manager *ManagerDefault) MarkApplied(version time.Time) (*Summary, error) {
+	return manager.MarkAppliedContext(context.Background(), version)
+}
+
+// MarkAppliedContext is the migration.ManagerDefault.MarkApplied with a
+// `context.Context`.
+func (manager *ManagerDefault) MarkAppliedContext(ctx context.Context, version time.Time) (summary *Summary, err error) {
+	err = manager.withLock(ctx, func() error {
+		m, err := manager.findMigration(version)
+		if err != nil {
+			return err
+		}
+		executed, err := manager.isExecuted(ctx, version)
+		if err != nil {
+			return err
+		}
+		if executed {
+			return ErrMigrationExecuted
+		}
+		checksum, err := migrationChecksum(m)
+		if err != nil {
+			return err
+		}
+		summary = NewSummary(m)
+		summary.direction = DirectionDo
+		summary.startedAt = time.Now()
+		summary.checksum = checksum
+		return targetAddMigration(ctx, manager.target, summary)
+	})
+	return
+}
+
+// MarkReverted removes the migration with the given `version` from the
+// migrations executed, without undoing it. The migration does not need to
+// exist on the source, so IDs of deleted migrations can be removed as well.
+func (manager *ManagerDefault) MarkReverted(version time.Time) (*Summary, error) {
+	return manager.MarkRevertedContext(context.Background(), version)
+}
+
+// MarkRevertedContext is the migration.ManagerDefault.MarkReverted with a
+// `context.Context`.
+func (manager *ManagerDefault) MarkRevertedContext(ctx context.Context, version time.Time) (summary *Summary, err error) {
+	err = manager.withLock(ctx, func() error {
+		executed, err := manager.isExecuted(ctx, version)
+		if err != nil {
+			return err
+		}
+		if !executed {
+			return ErrMigrationNotExecuted
+		}
+		m, err := manager.findMigration(version)
+		if err == ErrMigrationNotFound {
+			m = NewMigration(version, "")
+		} else if err != nil {
+			return err
+		}
+		summary = NewSummary(m)
+		summary.direction = DirectionUndo
+		summary.startedAt = time.Now()
+		return targetRemoveMigration(ctx, manager.target, summary)
+	})
+	return
+}
+
+// isExecuted checks if the target recorded the migration with the given
+// `version` as executed.
+func (manager *ManagerDefault) isExecuted(ctx context.Context, version time.Time) (bool, error) {
+	executed, err := targetMigrationsExecuted(ctx, manager.target)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range executed {
+		if id.Equal(version) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// PlanMigrate returns the steps migration.ManagerDefault.Migrate would take,
+// without executing anything.
+func (manager *ManagerDefault) PlanMigrate() (*Plan, error) {
+	plan, err := manager.planMigrate("migrate", nil)
+	if err != nil {
+		return nil, err
+	}
+	repeatables, err := manager.pendingRepeatables(context.Background(), false)
+	if err != nil {
+		return nil, err
+	}
+	plan.add(DirectionDo, repeatables...)
+	return plan, nil
+}
+
+// PlanMigrateTo returns the steps migration.ManagerDefault.MigrateTo would
+// take, without executing anything.
+func (manager *ManagerDefault) PlanMigrateTo(version time.Time) (*Plan, error) {
+	if err := manager.ensureMigration(version); err != nil {
+		return nil, err
+	}
+	return manager.planMigrate("migrate-to", func(m Migration) bool {
+		return !m.GetID().After(version)
+	})
+}
+
+// planMigrate plans the pending migrations accepted by the `filter` (all of
+// them, if nil), flagging the starved ones. The starved migrations allowed by
+// the migration.OutOfOrderPolicy are flagged as out of order instead.
+func (manager *ManagerDefault) planMigrate(command string, filter func(m Migration) bool) (*Plan, error) {
+	ctx := context.Background()
+	version, err := targetVersion(ctx, manager.target)
+	if err != nil {
+		return nil, err
+	}
+	list, err := manager.migrationsPending(ctx)
 	if err != nil {
-		return migrationsBack, nil, err
+		return nil, err
+	}
+
+	plan := &Plan{Command: command}
+	for _, m := range list {
+		if m.GetID().Before(version) {
+			if manager.allowsOutOfOrder(m) {
+				plan.OutOfOrder = append(plan.OutOfOrder, m)
+			} else {
+				plan.Starved = append(plan.Starved, m)
+			}
+		}
+		if filter == nil || filter(m) {
+			plan.add(DirectionDo, m)
+		}
+	}
+	return plan, nil
+}
+
+// PlanRewind returns the steps migration.ManagerDefault.Rewind would take,
+// without executing anything.
+func (manager *ManagerDefault) PlanRewind() (*Plan, error) {
+	return manager.planRewind("rewind", nil)
+}
+
+// PlanRewindTo returns the steps migration.ManagerDefault.RewindTo would
+// take, without executing anything.
+func (manager *ManagerDefault) PlanRewindTo(version time.Time) (*Plan, error) {
+	if err := manager.ensureMigration(version); err != nil {
+		return nil, err
 	}
-	migrationsForward, err := manager.Migrate(reporter, executionContext)
-	return migrationsBack, migrationsForward, err
+	return manager.planRewind("rewind-to", func(m Migration) bool {
+		return m.GetID().After(version)
+	})
+}
+
+// planRewind plans the executed migrations accepted by the `filter` (all of
+// them, if nil), newest first.
+func (manager *ManagerDefault) planRewind(command string, filter func(m Migration) bool) (*Plan, error) {
+	list, err := manager.migrationsExecuted(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Command: command}
+	for i := len(list) - 1; i > -1; i-- {
+		if filter == nil || filter(list[i]) {
+			plan.add(DirectionUndo, list[i])
+		}
+	}
+	return plan, nil
+}
+
+// PlanReset returns the steps migration.ManagerDefault.Reset would take,
+// without executing anything.
+func (manager *ManagerDefault) PlanReset() (*Plan, error) {
+	plan, err := manager.planRewind("reset", nil)
+	if err != nil {
+		return nil, err
+	}
+	// After the rewind, all the migrations of the source are pending.
+	list, err := manager.source.List()
+	if err != nil {
+		return nil, err
+	}
+	plan.add(DirectionDo, list...)
+	repeatables, err := manager.pendingRepeatables(context.Background(), true)
+	if err != nil {
+		return nil, err
+	}
+	plan.add(DirectionDo, repeatables...)
+	return plan, nil
+}
+
+// WriteSQL writes to `w` the SQL script of the steps of the `plan`, instead of
+// executing them. The migrations run with a migration.SQLWriter as execution
+// context, so the statements of the migration.FileMigration are written
+// along with the statements recording them on the target. The migrations are
+// wrapped in transactions as they would be when executed.
+//
+// The target must implement the migration.ScriptTarget. Otherwise,
+// migration.ErrScriptNotSupported is returned. If the plan has starved
+// migrations, migration.ErrMigrationStarved is returned, and if it has
+// migrations other than migration.FileMigration, a
+// `*migration.UnscriptableMigrationError` is returned. Either way, nothing is
+// written.
+func (manager *ManagerDefault) WriteSQL(plan *Plan, w io.Writer) error {
+	target, ok := manager.target.(ScriptTarget)
+	if !ok {
+		return ErrScriptNotSupported
+	}
+	if len(plan.Starved) > 0 {
+		return ErrMigrationStarved
+	}
+	for _, step := range plan.Steps {
+		if _, ok := step.Migration.(*FileMigration); !ok {
+			return &UnscriptableMigrationError{
+				Migration: step.Migration,
+			}
+		}
+	}
+
+	ctx := context.Background()
+	writer := NewSQLWriter(w, target.Dialect())
+	if err := target.ScriptSetup(ctx, writer); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+	for _, step := range plan.Steps {
+		m := step.Migration
+		m.SetManager(manager)
+		summary := &Summary{
+			Migration: m,
+			direction: step.Direction,
+			startedAt: time.Now(),
+		}
+		if step.Direction == DirectionDo {
+			checksum, err := migrationChecksum(m)
+			if err != nil {
+				return err
+			}
+			summary.checksum = checksum
+		}
+
+		if err := writer.comment("%s [%s] %s", step.Direction, MigrationLabel(m), m.GetDescription()); err != nil {
+			return err
+		}
+		transactional := manager.transactional(m)
+		if transactional {
+			if _, err := writer.ExecContext(ctx, "BEGIN"); err != nil {
+				return err
+			}
+		}
+
+		if err := manager.call(ctx, summary, writer); err != nil {
+			return err
+		}
+
+		var err error
+		if step.Direction == DirectionDo {
+			err = target.ScriptAddMigration(ctx, writer, summary)
+		} else {
+			err = target.ScriptRemoveMigration(ctx, writer, summary)
+		}
+		if err != nil {
+			return err
+		}
+
+		if transactional {
+			if _, err := writer.ExecContext(ctx, "COMMIT"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset rewind all the migrations, then migrates to the latest. All the
+// repeatable migrations are applied again.
+func (manager *ManagerDefault) Reset(reporter Reporter, executionContext interface{}) ([]*Summary, []*Summary, error) {
+	return manager.ResetContext(context.Background(), reporter, executionContext)
+}
+
+// ResetContext is the migration.ManagerDefault.Reset with a `context.Context`.
+func (manager *ManagerDefault) ResetContext(ctx context.Context, reporter Reporter, executionContext interface{}) (migrationsBack []*Summary, migrationsForward []*Summary, err error) {
+	err = manager.runAll(ctx, executionContext, func(ctx context.Context) ([]*Summary, error) {
+		reporter.BeforeReset()
+		migrationsBack, err = manager.rewind(ctx, reporter, executionContext)
+		if err != nil {
+			return migrationsBack, err
+		}
+		migrationsForward, err = manager.migrate(ctx, reporter, executionContext, true)
+		return append(collectSummaries(migrationsBack...), migrationsForward...), err
+	})
+	return
 }
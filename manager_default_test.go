@@ -1,9 +1,14 @@
 package migration_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/lab259/go-migration"
+	"github.com/lab259/go-migration/migrationtest"
 
 	"errors"
 
@@ -13,6 +18,8 @@ import (
 
 type nopTarget struct {
 	executed []*migration.Summary
+	failures []*migration.HistoryRecord
+	history  []*migration.HistoryRecord
 }
 
 // Version returns the current version of the database.
@@ -33,6 +40,7 @@ func (target *nopTarget) AddMigration(summary *migration.Summary) error {
 		target.executed = make([]*migration.Summary, 0)
 	}
 	target.executed = append(target.executed, summary)
+	target.history = append(target.history, migration.NewHistoryRecord(summary))
 	return nil
 }
 
@@ -47,6 +55,7 @@ func (target *nopTarget) RemoveMigration(summary *migration.Summary) error {
 			target.executed = append(target.executed[:i], target.executed[i+1:]...)
 		}
 	}
+	target.history = append(target.history, migration.NewHistoryRecord(summary))
 	return nil
 }
 
@@ -59,6 +68,35 @@ func (target *nopTarget) MigrationsExecuted() ([]time.Time, error) {
 	return executed, nil
 }
 
+// AddFailure stores the failed attempt
+func (target *nopTarget) AddFailure(ctx context.Context, record *migration.HistoryRecord) error {
+	target.failures = append(target.failures, record)
+	target.history = append(target.history, record)
+	return nil
+}
+
+// History returns the records of the migrations executed, reverted and the
+// failures
+func (target *nopTarget) History(ctx context.Context) ([]*migration.HistoryRecord, error) {
+	records := make([]*migration.HistoryRecord, len(target.history))
+	copy(records, target.history)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].AppliedAt.Before(records[j].AppliedAt)
+	})
+	return records, nil
+}
+
+// Checksums returns the checksums of the migrations executed
+func (target *nopTarget) Checksums(ctx context.Context) (map[int64]string, error) {
+	checksums := make(map[int64]string)
+	for _, summary := range target.executed {
+		if summary.Checksum() != "" {
+			checksums[summary.Migration.GetID().UnixNano()] = summary.Checksum()
+		}
+	}
+	return checksums, nil
+}
+
 type ErroredTarget struct {
 }
 
@@ -109,6 +147,138 @@ func (target *BeforeRunTarget) BeforeRun(executionContext interface{}) {
 	target.BeforeRuns += 1
 }
 
+type txMock struct {
+	target     *TransactionalTarget
+	added      []*migration.Summary
+	removed    []*migration.Summary
+	committed  bool
+	rolledBack bool
+}
+
+func (tx *txMock) ExecutionContext() interface{} {
+	return tx
+}
+
+func (tx *txMock) AddMigration(summary *migration.Summary) error {
+	if tx.target.addMigrationErr != nil {
+		return tx.target.addMigrationErr
+	}
+	tx.added = append(tx.added, summary)
+	return nil
+}
+
+func (tx *txMock) RemoveMigration(summary *migration.Summary) error {
+	tx.removed = append(tx.removed, summary)
+	return nil
+}
+
+func (tx *txMock) Commit() error {
+	tx.committed = true
+	for _, summary := range tx.added {
+		tx.target.nopTarget.AddMigration(summary)
+	}
+	for _, summary := range tx.removed {
+		tx.target.nopTarget.RemoveMigration(summary)
+	}
+	return nil
+}
+
+func (tx *txMock) Rollback() error {
+	tx.rolledBack = true
+	return nil
+}
+
+type TransactionalTarget struct {
+	nopTarget
+	transactions    []*txMock
+	addMigrationErr error
+}
+
+func (target *TransactionalTarget) Begin(ctx context.Context) (migration.Transaction, error) {
+	tx := &txMock{
+		target: target,
+	}
+	target.transactions = append(target.transactions, tx)
+	return tx, nil
+}
+
+type LockerTarget struct {
+	nopTarget
+	locked   bool
+	locks    int
+	unlocks  int
+	timeout  time.Duration
+	lockErr  error
+	lockedOn func()
+}
+
+func (target *LockerTarget) Lock(ctx context.Context, timeout time.Duration) error {
+	target.timeout = timeout
+	if target.lockErr != nil {
+		return target.lockErr
+	}
+	Expect(target.locked).To(BeFalse(), "the lock should not be acquired twice")
+	target.locked = true
+	target.locks++
+	return nil
+}
+
+func (target *LockerTarget) Unlock() error {
+	target.locked = false
+	target.unlocks++
+	return nil
+}
+
+func (target *LockerTarget) AddMigration(summary *migration.Summary) error {
+	Expect(target.locked).To(BeTrue(), "migrations should run while holding the lock")
+	return target.nopTarget.AddMigration(summary)
+}
+
+func (target *LockerTarget) RemoveMigration(summary *migration.Summary) error {
+	Expect(target.locked).To(BeTrue(), "migrations should run while holding the lock")
+	return target.nopTarget.RemoveMigration(summary)
+}
+
+type nonTransactionalMigrationMock struct {
+	migrationMock
+}
+
+func (m *nonTransactionalMigrationMock) NonTransactional() bool {
+	return true
+}
+
+// schemaSnapshotter is a migration.Snapshotter of a fake schema, changed by
+// the migrations through apply.
+type schemaSnapshotter struct {
+	entries map[string]bool
+	err     error
+}
+
+// apply adds ("+entry") or removes ("-entry") entries of the schema.
+func (snapshotter *schemaSnapshotter) apply(changes ...string) {
+	if snapshotter.entries == nil {
+		snapshotter.entries = make(map[string]bool)
+	}
+	for _, change := range changes {
+		if change[0] == '+' {
+			snapshotter.entries[change[1:]] = true
+		} else {
+			delete(snapshotter.entries, change[1:])
+		}
+	}
+}
+
+func (snapshotter *schemaSnapshotter) Snapshot(ctx context.Context) (migration.Snapshot, error) {
+	if snapshotter.err != nil {
+		return nil, snapshotter.err
+	}
+	snapshot := make(migration.Snapshot, 0, len(snapshotter.entries))
+	for entry := range snapshotter.entries {
+		snapshot = append(snapshot, entry)
+	}
+	return snapshot, nil
+}
+
 type nopReporter struct {
 	beforeMigration func(summary *migration.Summary, err error)
 }
@@ -143,6 +313,12 @@ func (reporter *nopReporter) BeforeReset() {
 func (reporter *nopReporter) AfterReset(rewindSummary []*migration.Summary, migrateSummary []*migration.Summary, err error) {
 }
 
+func (reporter *nopReporter) AfterBaseline(migrations []*migration.Summary, err error) {
+}
+
+func (reporter *nopReporter) MigrationMarked(migration *migration.Summary, err error) {
+}
+
 func (reporter *nopReporter) ListPending(migrations []migration.Migration, err error) {
 }
 
@@ -152,6 +328,27 @@ func (reporter *nopReporter) ListExecuted(migrations []migration.Migration, err
 func (reporter *nopReporter) MigrationsStarved(migrations []migration.Migration) {
 }
 
+func (reporter *nopReporter) ListHistory(records []*migration.HistoryRecord, err error) {
+}
+
+func (reporter *nopReporter) ListPlan(plan *migration.Plan, err error) {
+}
+
+func (reporter *nopReporter) ListStatus(rows []*migration.StatusRow, err error) {
+}
+
+func (reporter *nopReporter) SQLScript(script string, err error) {
+}
+
+func (reporter *nopReporter) ChecksumMismatches(mismatches []*migration.ChecksumMismatch) {
+}
+
+func (reporter *nopReporter) AfterVerify(mismatches []*migration.ChecksumMismatch, err error) {
+}
+
+func (reporter *nopReporter) Residues(residues []*migration.Residue) {
+}
+
 func (reporter *nopReporter) Failure(err error) {
 }
 
@@ -172,7 +369,7 @@ var _ = Describe("ManagerDefault", func() {
 	var (
 		target     migration.Target
 		codeSource *migration.CodeSource
-		manager    migration.Manager
+		manager    *migration.ManagerDefault
 		m1, m2, m3,
 		/*m4UndoneErr, */ m5DoneErr *migrationMock
 		/*m4UndonePanic,  m5DonePanic*/
@@ -229,18 +426,266 @@ var _ = Describe("ManagerDefault", func() {
 			}
 		*/
 
-		manager = migration.NewDefaultManager(target, codeSource)
+		manager = migration.NewManager(target, codeSource)
 	})
 
 	It("should create a new instance of the DefaultManager", func() {
 		t := &nopTarget{}
 		cs := migration.NewCodeSource()
-		manager := migration.NewDefaultManager(t, cs)
+		manager := migration.NewManager(t, cs)
 		Expect(manager).NotTo(BeNil())
 		Expect(manager.Target()).To(Equal(t))
 		Expect(manager.Source()).To(Equal(cs))
 	})
 
+	Describe("Options", func() {
+		It("should configure the manager with the options", func() {
+			lockerTarget := &LockerTarget{}
+			lockerTarget.nopTarget.AddMigration(migration.NewSummary(m2))
+			manager := migration.NewManager(lockerTarget, codeSource,
+				migration.WithLockTimeout(time.Second*3),
+				migration.WithOutOfOrderPolicy(migration.OutOfOrderExplicit),
+				migration.WithAllowOutOfOrder(m1.GetID()),
+			)
+
+			ms, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(HaveLen(2))
+			Expect(lockerTarget.timeout).To(Equal(time.Second * 3))
+		})
+
+		It("should not open transactions when they are disabled", func() {
+			txTarget := &TransactionalTarget{}
+			manager := migration.NewManager(txTarget, codeSource, migration.WithTransactionMode(migration.TransactionNone))
+
+			ms, err := manager.Migrate(&nopReporter{}, "execution context")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(HaveLen(3))
+			Expect(txTarget.transactions).To(BeEmpty())
+			Expect(m1.executionContext).To(Equal("execution context"))
+			Expect(txTarget.Version()).To(Equal(m3.GetID()))
+		})
+
+		It("should cancel the context of the migrations that take too long", func() {
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(migration.NewMigrationContext(m1.GetID(), m1.GetDescription(), func(ctx context.Context, executionContext interface{}) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}))
+			manager := migration.NewManager(target, codeSource, migration.WithMigrationTimeout(time.Millisecond*10))
+
+			summary, err := manager.Do(&nopReporter{}, nil)
+			Expect(err).To(Equal(context.DeadlineExceeded))
+			Expect(summary.Failed()).To(BeTrue())
+			Expect(target.Version()).To(Equal(migration.NoVersion))
+		})
+	})
+
+	Describe("Hooks", func() {
+		var calls []string
+
+		recordingHooks := func(name string) migration.Hooks {
+			return migration.Hooks{
+				BeforeAll: func(ctx context.Context, executionContext interface{}) error {
+					calls = append(calls, name+" before all")
+					return nil
+				},
+				AfterAll: func(ctx context.Context, summaries []*migration.Summary, executionContext interface{}) error {
+					calls = append(calls, fmt.Sprintf("%s after all %d", name, len(summaries)))
+					return nil
+				},
+				BeforeEach: func(ctx context.Context, summary *migration.Summary, executionContext interface{}) error {
+					calls = append(calls, name+" before "+summary.Migration.GetDescription())
+					return nil
+				},
+				AfterEach: func(ctx context.Context, summary *migration.Summary, executionContext interface{}) error {
+					calls = append(calls, name+" after "+summary.Migration.GetDescription())
+					return nil
+				},
+				OnError: func(ctx context.Context, summary *migration.Summary, err error, executionContext interface{}) {
+					description := "<nil>"
+					if summary != nil {
+						description = summary.Migration.GetDescription()
+					}
+					calls = append(calls, fmt.Sprintf("%s error %s: %s", name, description, err))
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			calls = nil
+		})
+
+		It("should call the hooks around the run and each migration", func() {
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(m1)
+			codeSource.Register(m2)
+			manager := migration.NewManager(target, codeSource, migration.WithHooks(recordingHooks("a")), migration.WithHooks(recordingHooks("b")))
+
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal([]string{
+				"a before all",
+				"b before all",
+				"a before GetDescription 1",
+				"b before GetDescription 1",
+				"a after GetDescription 1",
+				"b after GetDescription 1",
+				"a before GetDescription 2",
+				"b before GetDescription 2",
+				"a after GetDescription 2",
+				"b after GetDescription 2",
+				"a after all 2",
+				"b after all 2",
+			}))
+
+			calls = nil
+			_, _, err = manager.Redo(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(HaveLen(12))
+			Expect(calls[11]).To(Equal("b after all 2"))
+		})
+
+		It("should call the hooks once for all the steps of DoN and UndoN", func() {
+			manager := migration.NewManager(target, codeSource, migration.WithHooks(recordingHooks("a")))
+			reporter := migrationtest.NewRecordingReporter()
+
+			summaries, err := manager.DoN(2, reporter, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summaries).To(HaveLen(2))
+			Expect(calls).To(HaveLen(6))
+			Expect(calls[0]).To(Equal("a before all"))
+			Expect(calls[5]).To(Equal("a after all 2"))
+			Expect(reporter.Kinds()).To(ContainElement(migrationtest.MigrationSummary))
+
+			calls = nil
+			summaries, err = manager.UndoN(5, &nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summaries).To(HaveLen(2))
+			Expect(summaries[0].Migration).To(Equal(m2))
+			Expect(summaries[1].Migration).To(Equal(m1))
+			Expect(calls).To(HaveLen(6))
+			Expect(calls[0]).To(Equal("a before all"))
+			Expect(calls[5]).To(Equal("a after all 2"))
+		})
+
+		It("should pass the execution context of the migration to the hooks", func() {
+			txTarget := &TransactionalTarget{}
+			var received []interface{}
+			manager := migration.NewManager(txTarget, codeSource, migration.WithHooks(migration.Hooks{
+				BeforeAll: func(ctx context.Context, executionContext interface{}) error {
+					received = append(received, executionContext)
+					return nil
+				},
+				BeforeEach: func(ctx context.Context, summary *migration.Summary, executionContext interface{}) error {
+					received = append(received, executionContext)
+					return nil
+				},
+			}))
+
+			_, err := manager.Do(&nopReporter{}, "execution context")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(received).To(HaveLen(2))
+			Expect(received[0]).To(Equal("execution context"))
+			Expect(received[1]).To(BeIdenticalTo(txTarget.transactions[0]))
+		})
+
+		It("should abort the run when a hook fails", func() {
+			hooks := recordingHooks("a")
+			hooks.BeforeEach = func(ctx context.Context, summary *migration.Summary, executionContext interface{}) error {
+				if summary.Migration == m2 {
+					return errors.New("forced error")
+				}
+				return nil
+			}
+			manager := migration.NewManager(target, codeSource, migration.WithHooks(hooks))
+
+			ms, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).To(MatchError("forced error"))
+			Expect(ms).To(HaveLen(1))
+			Expect(m2.done).To(BeFalse())
+			Expect(m3.done).To(BeFalse())
+			Expect(target.Version()).To(Equal(m1.GetID()))
+			Expect(calls).To(Equal([]string{
+				"a before all",
+				"a after GetDescription 1",
+				"a error GetDescription 2: forced error",
+			}))
+		})
+
+		It("should rollback the migration when the after each hook fails", func() {
+			txTarget := &TransactionalTarget{}
+			hooks := recordingHooks("a")
+			hooks.AfterEach = func(ctx context.Context, summary *migration.Summary, executionContext interface{}) error {
+				return errors.New("forced error")
+			}
+			manager := migration.NewManager(txTarget, codeSource, migration.WithHooks(hooks))
+
+			_, err := manager.Do(&nopReporter{}, nil)
+			Expect(err).To(MatchError("forced error"))
+			Expect(txTarget.transactions[0].rolledBack).To(BeTrue())
+			Expect(txTarget.Version()).To(Equal(migration.NoVersion))
+			Expect(calls).To(ContainElement("a error GetDescription 1: forced error"))
+			Expect(calls).ToNot(ContainElement(HavePrefix("a after all")))
+		})
+
+		It("should not run any migration when the before all hook fails", func() {
+			hooks := recordingHooks("a")
+			hooks.BeforeAll = func(ctx context.Context, executionContext interface{}) error {
+				return errors.New("forced error")
+			}
+			manager := migration.NewManager(target, codeSource, migration.WithHooks(hooks))
+
+			ms, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).To(MatchError("forced error"))
+			Expect(ms).To(BeEmpty())
+			Expect(m1.done).To(BeFalse())
+			Expect(calls).To(Equal([]string{"a error <nil>: forced error"}))
+		})
+
+		It("should call the on error hook when a migration fails", func() {
+			codeSource.Register(m5DoneErr)
+			manager := migration.NewManager(target, codeSource, migration.WithHooks(recordingHooks("a")))
+
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).To(Equal(m5DoneErr.doneErr))
+			Expect(calls[len(calls)-1]).To(Equal("a error GetDescription 5: Done Err: m5 done forced error"))
+			Expect(calls).ToNot(ContainElement(HavePrefix("a after all")))
+		})
+
+		It("should call the on error hook once when the migration fails", func() {
+			codeSource.Register(m5DoneErr)
+			manager := migration.NewManager(target, codeSource, migration.WithHooks(recordingHooks("a")))
+
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(calls).To(HaveLen(7))
+			Expect(calls[6]).To(HavePrefix("a error GetDescription 5"))
+		})
+
+		It("should call the on error hook when the lock cannot be acquired", func() {
+			lockerTarget := &LockerTarget{}
+			lockerTarget.lockErr = migration.ErrLockTimeout
+			manager := migration.NewManager(lockerTarget, codeSource, migration.WithHooks(recordingHooks("a")))
+
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).To(Equal(migration.ErrLockTimeout))
+			Expect(calls).To(Equal([]string{"a error <nil>: " + migration.ErrLockTimeout.Error()}))
+		})
+
+		It("should call the on error hook when the target fails", func() {
+			memory := migrationtest.NewMemoryTarget().FailVersion(errors.New("forced error"))
+			manager := migration.NewManager(memory, codeSource, migration.WithHooks(recordingHooks("a")))
+
+			_, err := manager.Do(&nopReporter{}, nil)
+			Expect(err).To(MatchError("forced error"))
+			Expect(calls).To(Equal([]string{
+				"a before all",
+				"a error <nil>: forced error",
+			}))
+		})
+	})
+
 	Describe("MigrationsPending", func() {
 		It("should list pendent migrations when all migrations are new", func() {
 			migrations, err := manager.MigrationsPending()
@@ -281,7 +726,7 @@ var _ = Describe("ManagerDefault", func() {
 
 	Describe("MigrationsExecuted", func() {
 		It("should fail listing the migrations to be executed", func() {
-			manager := migration.NewDefaultManager(&ErroredTarget{}, codeSource)
+			manager := migration.NewManager(&ErroredTarget{}, codeSource)
 			migrations, err := manager.MigrationsExecuted()
 			Expect(err).To(HaveOccurred())
 			Expect(migrations).To(BeNil())
@@ -344,7 +789,7 @@ var _ = Describe("ManagerDefault", func() {
 
 	Describe("Migrate", func() {
 		It("should fail adding the migration as executed", func() {
-			manager := migration.NewDefaultManager(&AddMigrationErroredTarget{}, codeSource)
+			manager := migration.NewManager(&AddMigrationErroredTarget{}, codeSource)
 
 			migrations := make([]migration.Migration, 0)
 			ms, err := manager.Migrate(&nopReporter{
@@ -468,7 +913,7 @@ var _ = Describe("ManagerDefault", func() {
 				donePanicData: "this is the panic data",
 			}
 			codeSource.Register(m)
-			manager := migration.NewDefaultManager(target, codeSource)
+			manager := migration.NewManager(target, codeSource)
 
 			migrations := make([]migration.Migration, 0)
 			ms, err := manager.Migrate(&nopReporter{
@@ -504,7 +949,7 @@ var _ = Describe("ManagerDefault", func() {
 			}
 			codeSource.Register(migrationErrored)
 			// target.AddMigration(migration.NewSummary(migrationErrored))
-			manager := migration.NewDefaultManager(target, codeSource)
+			manager := migration.NewManager(target, codeSource)
 
 			migrations := make([]migration.Migration, 0)
 			ms, err := manager.Migrate(&nopReporter{
@@ -542,12 +987,68 @@ var _ = Describe("ManagerDefault", func() {
 
 			Expect(manager.Target().Version()).To(Equal(m2.GetID()))
 		})
+
+		It("should report and apply the starved migrations with the warn policy", func() {
+			target.AddMigration(migration.NewSummary(m2))
+			manager = migration.NewManager(target, codeSource, migration.WithOutOfOrderPolicy(migration.OutOfOrderWarn))
+
+			var starved []migration.Migration
+			ms, err := manager.Migrate(&customReporter{
+				migrationStarved: func(migrations []migration.Migration) {
+					starved = migrations
+				},
+			}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(starved).To(Equal([]migration.Migration{m1}))
+
+			Expect(ms).To(HaveLen(2))
+			Expect(ms[0].Migration).To(Equal(m1))
+			Expect(ms[1].Migration).To(Equal(m3))
+			Expect(m1.done).To(BeTrue())
+			Expect(m3.done).To(BeTrue())
+		})
+
+		It("should apply only the starved migrations explicitly allowed", func() {
+			m0 := &migrationMock{
+				id:          m1.GetID().Add(-time.Hour),
+				description: "GetDescription 0",
+			}
+			codeSource.Register(m0)
+			target.AddMigration(migration.NewSummary(m2))
+			manager = migration.NewManager(target, codeSource,
+				migration.WithOutOfOrderPolicy(migration.OutOfOrderExplicit),
+				migration.WithAllowOutOfOrder(m1.GetID()),
+			)
+
+			var starved []migration.Migration
+			reporter := &customReporter{
+				migrationStarved: func(migrations []migration.Migration) {
+					starved = migrations
+				},
+			}
+			ms, err := manager.Migrate(reporter, nil)
+			Expect(err).To(Equal(migration.ErrMigrationStarved))
+			Expect(ms).To(BeEmpty())
+			Expect(starved).To(Equal([]migration.Migration{m0, m1}))
+
+			manager = migration.NewManager(target, codeSource,
+				migration.WithOutOfOrderPolicy(migration.OutOfOrderExplicit),
+				migration.WithAllowOutOfOrder(m1.GetID()),
+				migration.WithAllowOutOfOrder(m0.GetID()),
+			)
+			ms, err = manager.Migrate(reporter, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(HaveLen(3))
+			Expect(ms[0].Migration).To(Equal(m0))
+			Expect(ms[1].Migration).To(Equal(m1))
+			Expect(ms[2].Migration).To(Equal(m3))
+		})
 	})
 
 	Describe("Rewind", func() {
 		It("should fail removing a migration from the list", func() {
 			target := &RemoveMigrationErroredTarget{}
-			manager := migration.NewDefaultManager(target, codeSource)
+			manager := migration.NewManager(target, codeSource)
 			target.AddMigration(migration.NewSummary(m1))
 			target.AddMigration(migration.NewSummary(m2))
 			target.AddMigration(migration.NewSummary(m3))
@@ -743,7 +1244,7 @@ var _ = Describe("ManagerDefault", func() {
 			}
 			codeSource.Register(migrationErrored)
 			target.AddMigration(migration.NewSummary(migrationErrored))
-			manager := migration.NewDefaultManager(target, codeSource)
+			manager := migration.NewManager(target, codeSource)
 
 			migrations := make([]migration.Migration, 0)
 			ms, err := manager.Rewind(&nopReporter{
@@ -779,7 +1280,7 @@ var _ = Describe("ManagerDefault", func() {
 			}
 			codeSource.Register(migrationErrored)
 			target.AddMigration(migration.NewSummary(migrationErrored))
-			manager := migration.NewDefaultManager(target, codeSource)
+			manager := migration.NewManager(target, codeSource)
 
 			migrations := make([]migration.Migration, 0)
 			ms, err := manager.Rewind(&nopReporter{
@@ -903,7 +1404,7 @@ var _ = Describe("ManagerDefault", func() {
 			codeSource = migration.NewCodeSource()
 			codeSource.Register(m5DoneErr)
 
-			manager := migration.NewDefaultManager(target, codeSource)
+			manager := migration.NewManager(target, codeSource)
 
 			beforeMigrationCalled := false
 			summary, err := manager.Do(&nopReporter{
@@ -936,7 +1437,7 @@ var _ = Describe("ManagerDefault", func() {
 			}
 			codeSource.Register(m)
 
-			manager := migration.NewDefaultManager(target, codeSource)
+			manager := migration.NewManager(target, codeSource)
 
 			beforeMigrationCalled := false
 			summary, err := manager.Do(&nopReporter{
@@ -970,7 +1471,7 @@ var _ = Describe("ManagerDefault", func() {
 			}
 			codeSource.Register(m)
 
-			manager := migration.NewDefaultManager(target, codeSource)
+			manager := migration.NewManager(target, codeSource)
 
 			beforeMigrationCalled := false
 			summary, err := manager.Do(&nopReporter{
@@ -1109,7 +1610,7 @@ var _ = Describe("ManagerDefault", func() {
 			codeSource.Register(m1)
 			codeSource.Register(migrationErrored)
 
-			manager := migration.NewDefaultManager(target, codeSource)
+			manager := migration.NewManager(target, codeSource)
 			manager.Target().AddMigration(migration.NewSummary(m1))
 			manager.Target().AddMigration(migration.NewSummary(migrationErrored))
 
@@ -1150,7 +1651,7 @@ var _ = Describe("ManagerDefault", func() {
 			codeSource.Register(m1)
 			codeSource.Register(migrationErrored)
 
-			manager := migration.NewDefaultManager(target, codeSource)
+			manager := migration.NewManager(target, codeSource)
 			target.AddMigration(migration.NewSummary(m1))
 			target.AddMigration(migration.NewSummary(migrationErrored))
 
@@ -1192,7 +1693,7 @@ var _ = Describe("ManagerDefault", func() {
 			}
 			codeSource.Register(m)
 
-			manager := migration.NewDefaultManager(target, codeSource)
+			manager := migration.NewManager(target, codeSource)
 			manager.Target().AddMigration(migration.NewSummary(m))
 
 			beforeMigrationCalled := false
@@ -1218,16 +1719,1007 @@ var _ = Describe("ManagerDefault", func() {
 		})
 	})
 
-	Describe("Reset", func() {
-		It("should reset a migration", func() {
+	Describe("MigrateTo", func() {
+		It("should migrate up to the given version", func() {
+			ms, err := manager.MigrateTo(m2.GetID(), &nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ms).To(HaveLen(2))
+			Expect(ms[0].Migration).To(Equal(m1))
+			Expect(ms[1].Migration).To(Equal(m2))
+
+			Expect(m1.done).To(BeTrue())
+			Expect(m2.done).To(BeTrue())
+			Expect(m3.done).To(BeFalse())
+			Expect(target.Version()).To(Equal(m2.GetID()))
+		})
+
+		It("should migrate only the pending migrations", func() {
+			target.AddMigration(migration.NewSummary(m1))
+
+			ms, err := manager.MigrateTo(m3.GetID(), &nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(HaveLen(2))
+			Expect(ms[0].Migration).To(Equal(m2))
+			Expect(ms[1].Migration).To(Equal(m3))
+			Expect(m1.done).To(BeFalse())
+		})
+
+		It("should do nothing when the version is already applied", func() {
+			target.AddMigration(migration.NewSummary(m1))
+			target.AddMigration(migration.NewSummary(m2))
+
+			ms, err := manager.MigrateTo(m1.GetID(), &nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(BeEmpty())
+			Expect(target.Version()).To(Equal(m2.GetID()))
+		})
+
+		It("should fail when the version does not exist in the source", func() {
+			ms, err := manager.MigrateTo(m2.GetID().Add(time.Second), &nopReporter{}, nil)
+			Expect(err).To(Equal(migration.ErrMigrationNotFound))
+			Expect(ms).To(BeEmpty())
+			Expect(m1.done).To(BeFalse())
+		})
+
+		It("should detect starvation", func() {
+			target.AddMigration(migration.NewSummary(m2))
+
+			_, err := manager.MigrateTo(m3.GetID(), &nopReporter{}, nil)
+			Expect(err).To(Equal(migration.ErrMigrationStarved))
+			Expect(m3.done).To(BeFalse())
+		})
+	})
+
+	Describe("RewindTo", func() {
+		BeforeEach(func() {
 			target.AddMigration(migration.NewSummary(m1))
 			target.AddMigration(migration.NewSummary(m2))
 			target.AddMigration(migration.NewSummary(m3))
+		})
 
-			migrationUndone, migrationsDone, err := manager.Reset(&nopReporter{}, nil)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(migrationUndone).To(HaveLen(3))
-			Expect(migrationsDone).To(HaveLen(3))
+		It("should rewind the migrations newer than the given version", func() {
+			ms, err := manager.RewindTo(m1.GetID(), &nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ms).To(HaveLen(2))
+			Expect(ms[0].Migration).To(Equal(m3))
+			Expect(ms[1].Migration).To(Equal(m2))
+
+			Expect(m1.undone).To(BeFalse())
+			Expect(m2.undone).To(BeTrue())
+			Expect(m3.undone).To(BeTrue())
+			Expect(target.Version()).To(Equal(m1.GetID()))
+		})
+
+		It("should do nothing when the version is the current one", func() {
+			ms, err := manager.RewindTo(m3.GetID(), &nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(BeEmpty())
+			Expect(target.Version()).To(Equal(m3.GetID()))
+		})
+
+		It("should fail when the version does not exist in the source", func() {
+			ms, err := manager.RewindTo(m1.GetID().Add(time.Second), &nopReporter{}, nil)
+			Expect(err).To(Equal(migration.ErrMigrationNotFound))
+			Expect(ms).To(BeEmpty())
+			Expect(m3.undone).To(BeFalse())
+		})
+
+		It("should stop at the first failure", func() {
+			m2.undoneErr = errors.New("m2 undone forced error")
+
+			ms, err := manager.RewindTo(m1.GetID(), &nopReporter{}, nil)
+			Expect(err).To(Equal(m2.undoneErr))
+			Expect(ms).To(HaveLen(2))
+			Expect(ms[1].Failed()).To(BeTrue())
+			Expect(target.Version()).To(Equal(m2.GetID()))
+		})
+	})
+
+	Describe("Redo", func() {
+		It("should undo and do the last migration again", func() {
+			target.AddMigration(migration.NewSummary(m1))
+			target.AddMigration(migration.NewSummary(m2))
+
+			directions := make([]migration.Direction, 0)
+			undone, done, err := manager.Redo(&nopReporter{
+				beforeMigration: func(summary *migration.Summary, err error) {
+					Expect(summary.Migration).To(Equal(m2))
+					directions = append(directions, summary.Direction())
+				},
+			}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(undone.Migration).To(Equal(m2))
+			Expect(undone.Direction()).To(Equal(migration.DirectionUndo))
+			Expect(done.Migration).To(Equal(m2))
+			Expect(done.Direction()).To(Equal(migration.DirectionDo))
+			Expect(directions).To(Equal([]migration.Direction{migration.DirectionUndo, migration.DirectionDo}))
+			Expect(m2.undone).To(BeTrue())
+			Expect(m2.done).To(BeTrue())
+			Expect(target.Version()).To(Equal(m2.GetID()))
+		})
+
+		It("should do nothing when there is no executed migration", func() {
+			undone, done, err := manager.Redo(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(undone).To(BeNil())
+			Expect(done).To(BeNil())
+		})
+
+		It("should not do the migration again when the undo fails", func() {
+			m1.undoneErr = errors.New("m1 undone forced error")
+			target.AddMigration(migration.NewSummary(m1))
+
+			undone, done, err := manager.Redo(&nopReporter{}, nil)
+			Expect(err).To(Equal(m1.undoneErr))
+			Expect(undone.Failed()).To(BeTrue())
+			Expect(done).To(BeNil())
+			Expect(m1.done).To(BeFalse())
+		})
+	})
+
+	Describe("Verify", func() {
+		var (
+			c1, c2, c3 *migration.DefaultMigration
+		)
+
+		BeforeEach(func() {
+			c1 = migration.NewMigration(m1.GetID(), m1.GetDescription(), nopHandler).WithChecksum("checksum 1")
+			c2 = migration.NewMigration(m2.GetID(), m2.GetDescription(), nopHandler)
+			c3 = migration.NewMigration(m3.GetID(), m3.GetDescription(), nopHandler).WithChecksum("checksum 3")
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(c1)
+			codeSource.Register(c2)
+			codeSource.Register(c3)
+			manager = migration.NewManager(target, codeSource)
+		})
+
+		It("should record the checksum of the migrations", func() {
+			ms, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(HaveLen(3))
+			Expect(ms[0].Checksum()).To(Equal("checksum 1"))
+			Expect(ms[1].Checksum()).To(BeEmpty())
+			Expect(ms[2].Checksum()).To(Equal("checksum 3"))
+		})
+
+		It("should report no mismatches when nothing changed", func() {
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			mismatches, err := manager.Verify(&customReporter{
+				mismatches: func(mismatches []*migration.ChecksumMismatch) {
+					Fail("no mismatches expected")
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mismatches).To(BeEmpty())
+		})
+
+		It("should report the migrations changed after being applied", func() {
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			c3.WithChecksum("checksum 3 changed")
+			c2.WithChecksum("checksum 2")
+
+			var reported []*migration.ChecksumMismatch
+			mismatches, err := manager.Verify(&customReporter{
+				mismatches: func(mismatches []*migration.ChecksumMismatch) {
+					reported = mismatches
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mismatches).To(HaveLen(1))
+			Expect(mismatches[0].Migration).To(Equal(c3))
+			Expect(mismatches[0].Expected).To(Equal("checksum 3"))
+			Expect(mismatches[0].Actual).To(Equal("checksum 3 changed"))
+			Expect(reported).To(Equal(mismatches))
+		})
+
+		It("should not verify pending migrations", func() {
+			_, err := manager.Do(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			c3.WithChecksum("checksum 3 changed")
+
+			mismatches, err := manager.Verify(&nopReporter{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mismatches).To(BeEmpty())
+		})
+
+		It("should fail when the target does not store checksums", func() {
+			manager = migration.NewManager(&ErroredTarget{}, codeSource)
+
+			_, err := manager.Verify(&nopReporter{})
+			Expect(err).To(Equal(migration.ErrChecksumNotSupported))
+		})
+	})
+
+	Describe("VerifyReversible", func() {
+		var (
+			snapshotter *schemaSnapshotter
+		)
+
+		BeforeEach(func() {
+			snapshotter = &schemaSnapshotter{}
+		})
+
+		newMigration := func(m *migrationMock, do []string, undo []string) *migration.DefaultMigration {
+			return migration.NewMigration(m.GetID(), m.GetDescription(), func(executionContext interface{}) error {
+				snapshotter.apply(do...)
+				return nil
+			}, func(executionContext interface{}) error {
+				snapshotter.apply(undo...)
+				return nil
+			})
+		}
+
+		It("should do, undo and do again each pending migration", func() {
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(newMigration(m1, []string{"+table a"}, []string{"-table a"}))
+			codeSource.Register(newMigration(m2, []string{"+table b"}, []string{"-table b"}))
+			manager = migration.NewManager(target, codeSource)
+
+			directions := make([]migration.Direction, 0)
+			residues, err := manager.VerifyReversible(snapshotter, &nopReporter{
+				beforeMigration: func(summary *migration.Summary, err error) {
+					directions = append(directions, summary.Direction())
+				},
+			}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(residues).To(BeEmpty())
+			Expect(directions).To(Equal([]migration.Direction{
+				migration.DirectionDo, migration.DirectionUndo, migration.DirectionDo,
+				migration.DirectionDo, migration.DirectionUndo, migration.DirectionDo,
+			}))
+			Expect(snapshotter.entries).To(Equal(map[string]bool{"table a": true, "table b": true}))
+			Expect(manager.MigrationsExecuted()).To(HaveLen(2))
+		})
+
+		It("should report the migrations whose undo leaves residue", func() {
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(newMigration(m1, []string{"+table a"}, []string{"-table a"}))
+			codeSource.Register(newMigration(m2, []string{"+table b", "+index b", "-table a"}, []string{"-table b"}))
+			manager = migration.NewManager(target, codeSource)
+
+			var reported []*migration.Residue
+			residues, err := manager.VerifyReversible(snapshotter, &customReporter{
+				residues: func(residues []*migration.Residue) {
+					reported = residues
+				},
+			}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(residues).To(HaveLen(1))
+			Expect(residues[0].Migration.GetID()).To(Equal(m2.GetID()))
+			Expect(residues[0].Added).To(Equal([]string{"index b"}))
+			Expect(residues[0].Removed).To(Equal([]string{"table a"}))
+			Expect(reported).To(Equal(residues))
+		})
+
+		It("should stop when a migration fails", func() {
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(migration.NewMigration(m1.GetID(), m1.GetDescription(), nopHandler, func(executionContext interface{}) error {
+				return errors.New("undo: forced error")
+			}))
+			codeSource.Register(newMigration(m2, []string{"+table b"}, []string{"-table b"}))
+			manager = migration.NewManager(target, codeSource)
+
+			residues, err := manager.VerifyReversible(snapshotter, &nopReporter{}, nil)
+			Expect(err).To(MatchError("undo: forced error"))
+			Expect(residues).To(BeEmpty())
+			Expect(manager.MigrationsExecuted()).To(HaveLen(1))
+		})
+
+		It("should fail when the snapshot fails", func() {
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(newMigration(m1, []string{"+table a"}, []string{"-table a"}))
+			manager = migration.NewManager(target, codeSource)
+			snapshotter.err = errors.New("snapshot: forced error")
+
+			_, err := manager.VerifyReversible(snapshotter, &nopReporter{}, nil)
+			Expect(err).To(MatchError("snapshot: forced error"))
+			Expect(manager.MigrationsExecuted()).To(BeEmpty())
+		})
+	})
+
+	Describe("Plan", func() {
+		var (
+			p1, p2, p3 *migration.DefaultMigration
+		)
+
+		BeforeEach(func() {
+			p1 = migration.NewMigration(m1.GetID(), m1.GetDescription(), nopHandler, nopHandler)
+			p2 = migration.NewMigration(m2.GetID(), m2.GetDescription(), nopHandler, nopHandler)
+			p3 = migration.NewMigration(m3.GetID(), m3.GetDescription(), nopHandler, nopHandler)
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(p1)
+			codeSource.Register(p2)
+			codeSource.Register(p3)
+			manager = migration.NewManager(target, codeSource)
+		})
+
+		steps := func(plan *migration.Plan) []string {
+			result := make([]string, len(plan.Steps))
+			for i, step := range plan.Steps {
+				result[i] = step.Direction.String() + " " + step.Migration.GetDescription()
+			}
+			return result
+		}
+
+		It("should plan the migrate without executing anything", func() {
+			Expect(target.AddMigration(migration.NewSummary(p1))).To(Succeed())
+
+			plan, err := manager.PlanMigrate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Command).To(Equal("migrate"))
+			Expect(steps(plan)).To(Equal([]string{"do " + p2.GetDescription(), "do " + p3.GetDescription()}))
+			Expect(plan.Starved).To(BeEmpty())
+			Expect(manager.MigrationsExecuted()).To(HaveLen(1))
+		})
+
+		It("should plan the migrate-to", func() {
+			plan, err := manager.PlanMigrateTo(p2.GetID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Command).To(Equal("migrate-to"))
+			Expect(steps(plan)).To(Equal([]string{"do " + p1.GetDescription(), "do " + p2.GetDescription()}))
+
+			_, err = manager.PlanMigrateTo(p3.GetID().Add(time.Second))
+			Expect(err).To(Equal(migration.ErrMigrationNotFound))
+		})
+
+		It("should warn about starved migrations", func() {
+			Expect(target.AddMigration(migration.NewSummary(p1))).To(Succeed())
+			Expect(target.AddMigration(migration.NewSummary(p3))).To(Succeed())
+
+			plan, err := manager.PlanMigrate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(steps(plan)).To(Equal([]string{"do " + p2.GetDescription()}))
+			Expect(plan.Starved).To(Equal([]migration.Migration{p2}))
+		})
+
+		It("should flag the starved migrations allowed by the policy as out of order", func() {
+			Expect(target.AddMigration(migration.NewSummary(p3))).To(Succeed())
+			manager = migration.NewManager(target, codeSource,
+				migration.WithOutOfOrderPolicy(migration.OutOfOrderExplicit),
+				migration.WithAllowOutOfOrder(p2.GetID()),
+			)
+
+			plan, err := manager.PlanMigrate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(steps(plan)).To(Equal([]string{"do " + p1.GetDescription(), "do " + p2.GetDescription()}))
+			Expect(plan.Starved).To(Equal([]migration.Migration{p1}))
+			Expect(plan.OutOfOrder).To(Equal([]migration.Migration{p2}))
+		})
+
+		It("should plan the rewind and the rewind-to, newest first", func() {
+			Expect(target.AddMigration(migration.NewSummary(p1))).To(Succeed())
+			Expect(target.AddMigration(migration.NewSummary(p2))).To(Succeed())
+			Expect(target.AddMigration(migration.NewSummary(p3))).To(Succeed())
+
+			plan, err := manager.PlanRewind()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Command).To(Equal("rewind"))
+			Expect(steps(plan)).To(Equal([]string{"undo " + p3.GetDescription(), "undo " + p2.GetDescription(), "undo " + p1.GetDescription()}))
+
+			plan, err = manager.PlanRewindTo(p1.GetID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Command).To(Equal("rewind-to"))
+			Expect(steps(plan)).To(Equal([]string{"undo " + p3.GetDescription(), "undo " + p2.GetDescription()}))
+			Expect(manager.MigrationsExecuted()).To(HaveLen(3))
+		})
+
+		It("should plan the reset", func() {
+			Expect(target.AddMigration(migration.NewSummary(p1))).To(Succeed())
+			Expect(target.AddMigration(migration.NewSummary(p2))).To(Succeed())
+
+			plan, err := manager.PlanReset()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Command).To(Equal("reset"))
+			Expect(steps(plan)).To(Equal([]string{
+				"undo " + p2.GetDescription(),
+				"undo " + p1.GetDescription(),
+				"do " + p1.GetDescription(),
+				"do " + p2.GetDescription(),
+				"do " + p3.GetDescription(),
+			}))
+		})
+
+		It("should not write the SQL script when the target does not support it", func() {
+			plan, err := manager.PlanMigrate()
+			Expect(err).ToNot(HaveOccurred())
+
+			var script bytes.Buffer
+			Expect(manager.WriteSQL(plan, &script)).To(Equal(migration.ErrScriptNotSupported))
+			Expect(script.Len()).To(BeZero())
+		})
+	})
+
+	Describe("History", func() {
+		It("should list the executed migrations and the failed attempts", func() {
+			codeSource.Register(m5DoneErr)
+
+			startedAt := time.Now()
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).To(Equal(m5DoneErr.doneErr))
+
+			records, err := manager.History()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(HaveLen(3))
+			Expect(records[0].ID).To(Equal(m1.GetID()))
+			Expect(records[0].Description).To(Equal(m1.GetDescription()))
+			Expect(records[0].Direction).To(Equal(migration.DirectionDo))
+			Expect(records[0].AppliedAt).To(BeTemporally(">=", startedAt))
+			Expect(records[0].ToolVersion).To(Equal(migration.ToolVersion))
+			Expect(records[0].Failed).To(BeFalse())
+			Expect(records[1].ID).To(Equal(m2.GetID()))
+			Expect(records[2].ID).To(Equal(m5DoneErr.GetID()))
+			Expect(records[2].Failed).To(BeTrue())
+			Expect(records[2].Failure).To(Equal("m5 done forced error"))
+		})
+
+		It("should record migrations that panic as failures", func() {
+			m := &migrationMock{
+				id:            m1.GetID(),
+				description:   "Panic",
+				donePanicData: "panic data",
+			}
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(m)
+			nop := &nopTarget{}
+			manager = migration.NewManager(nop, codeSource)
+
+			_, err := manager.Do(&nopReporter{}, nil)
+			Expect(err).To(Equal(migration.ErrMigrationPanicked))
+			Expect(nop.failures).To(HaveLen(1))
+			Expect(nop.failures[0].Failed).To(BeTrue())
+			Expect(nop.failures[0].Failure).To(Equal("panic: panic data"))
+		})
+
+		It("should record failed undo attempts", func() {
+			m1.undoneErr = errors.New("m1 undone forced error")
+			nop := &nopTarget{}
+			nop.AddMigration(migration.NewSummary(m1))
+			manager = migration.NewManager(nop, codeSource)
+
+			_, err := manager.Undo(&nopReporter{}, nil)
+			Expect(err).To(Equal(m1.undoneErr))
+			Expect(nop.failures).To(HaveLen(1))
+			Expect(nop.failures[0].Direction).To(Equal(migration.DirectionUndo))
+			Expect(nop.failures[0].Failure).To(Equal("m1 undone forced error"))
+		})
+
+		It("should record the migrations reverted", func() {
+			memory := migrationtest.NewMemoryTarget()
+			manager = migration.NewManager(memory, codeSource)
+
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manager.Undo(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manager.MarkReverted(m1.GetID())
+			Expect(err).ToNot(HaveOccurred())
+
+			records, err := manager.History()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(HaveLen(5))
+			Expect(records[3].ID).To(Equal(m3.GetID()))
+			Expect(records[3].Direction).To(Equal(migration.DirectionUndo))
+			Expect(records[3].Failed).To(BeFalse())
+			Expect(records[4].ID).To(Equal(m1.GetID()))
+			Expect(records[4].Direction).To(Equal(migration.DirectionUndo))
+		})
+
+		It("should fail when the target does not store the history", func() {
+			manager = migration.NewManager(&ErroredTarget{}, codeSource)
+
+			_, err := manager.History()
+			Expect(err).To(Equal(migration.ErrHistoryNotSupported))
+		})
+	})
+
+	Describe("Status", func() {
+		It("should reconcile the source with the target", func() {
+			baseTime := time.Date(2000, 0, 0, 0, 0, 0, 0, time.UTC)
+			applied := migration.NewMigration(baseTime, "Applied", nopHandler).WithChecksum("v1")
+			changed := migration.NewMigration(baseTime.Add(time.Hour), "Changed", nopHandler).WithChecksum("v1")
+			starved := migration.NewMigration(baseTime.Add(2*time.Hour), "Starved", nopHandler)
+			latest := migration.NewMigration(baseTime.Add(3*time.Hour), "Latest", nopHandler)
+			orphaned := migration.NewMigration(baseTime.Add(4*time.Hour), "Orphaned", nopHandler)
+			pending := migration.NewMigration(baseTime.Add(5*time.Hour), "Pending", nopHandler)
+
+			source := migration.NewCodeSource()
+			for _, m := range []migration.Migration{applied, changed, latest, orphaned} {
+				source.Register(m)
+			}
+			memory := migrationtest.NewMemoryTarget()
+			manager = migration.NewManager(memory, source)
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			source = migration.NewCodeSource()
+			for _, m := range []migration.Migration{applied, changed.WithChecksum("v2"), starved, latest, pending} {
+				source.Register(m)
+			}
+			manager = migration.NewManager(memory, source)
+
+			rows, err := manager.Status()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rows).To(HaveLen(6))
+			states := make([]migration.MigrationState, len(rows))
+			for i, row := range rows {
+				states[i] = row.State
+			}
+			Expect(states).To(Equal([]migration.MigrationState{
+				migration.StateApplied,
+				migration.StateChecksumMismatch,
+				migration.StateStarved,
+				migration.StateApplied,
+				migration.StateOrphaned,
+				migration.StatePending,
+			}))
+			Expect(rows[1].Expected).To(Equal("v1"))
+			Expect(rows[1].Actual).To(Equal("v2"))
+			Expect(rows[1].Drifted()).To(BeTrue())
+			Expect(rows[4].ID).To(Equal(orphaned.GetID()))
+			Expect(rows[4].Migration).To(BeNil())
+			Expect(rows[4].Description()).To(BeEmpty())
+			Expect(rows[5].Description()).To(Equal("Pending"))
+			Expect(rows[5].Drifted()).To(BeFalse())
+		})
+	})
+
+	Describe("Baseline", func() {
+		It("should mark the migrations up to the version as executed without running them", func() {
+			memory := migrationtest.NewMemoryTarget()
+			manager = migration.NewManager(memory, codeSource)
+
+			summaries, err := manager.Baseline(m2.GetID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summaries).To(HaveLen(2))
+			Expect(summaries[0].Migration).To(Equal(m1))
+			Expect(summaries[1].Migration).To(Equal(m2))
+			Expect(summaries[1].Baselined()).To(BeTrue())
+			Expect(summaries[1].Direction()).To(Equal(migration.DirectionDo))
+			Expect(m1.done).To(BeFalse())
+			Expect(m2.done).To(BeFalse())
+			Expect(memory.AppliedIDs()).To(Equal([]time.Time{m1.GetID(), m2.GetID()}))
+
+			records, err := manager.History()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(HaveLen(2))
+			Expect(records[0].Baselined).To(BeTrue())
+			Expect(records[0].Failed).To(BeFalse())
+
+			ms, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(HaveLen(1))
+			Expect(ms[0].Migration).To(Equal(m3))
+			Expect(ms[0].Baselined()).To(BeFalse())
+		})
+
+		It("should skip the migrations already executed", func() {
+			target.AddMigration(migration.NewSummary(m1))
+
+			summaries, err := manager.Baseline(m3.GetID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summaries).To(HaveLen(2))
+			Expect(summaries[0].Migration).To(Equal(m2))
+			Expect(summaries[1].Migration).To(Equal(m3))
+		})
+
+		It("should fail when the version does not exist", func() {
+			summaries, err := manager.Baseline(m5DoneErr.GetID())
+			Expect(err).To(Equal(migration.ErrMigrationNotFound))
+			Expect(summaries).To(BeEmpty())
+			Expect(target.MigrationsExecuted()).To(BeEmpty())
+		})
+	})
+
+	Describe("MarkApplied", func() {
+		It("should record the migration as executed without running it", func() {
+			summary, err := manager.MarkApplied(m2.GetID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary.Migration).To(Equal(m2))
+			Expect(summary.Direction()).To(Equal(migration.DirectionDo))
+			Expect(m2.done).To(BeFalse())
+			Expect(target.MigrationsExecuted()).To(Equal([]time.Time{m2.GetID()}))
+		})
+
+		It("should fail when the migration was already executed", func() {
+			target.AddMigration(migration.NewSummary(m2))
+
+			_, err := manager.MarkApplied(m2.GetID())
+			Expect(err).To(Equal(migration.ErrMigrationExecuted))
+		})
+
+		It("should fail when the migration does not exist", func() {
+			_, err := manager.MarkApplied(m5DoneErr.GetID())
+			Expect(err).To(Equal(migration.ErrMigrationNotFound))
+			Expect(target.MigrationsExecuted()).To(BeEmpty())
+		})
+	})
+
+	Describe("MarkReverted", func() {
+		It("should remove the migration from the executed without undoing it", func() {
+			target.AddMigration(migration.NewSummary(m1))
+			target.AddMigration(migration.NewSummary(m2))
+
+			summary, err := manager.MarkReverted(m2.GetID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary.Migration).To(Equal(m2))
+			Expect(summary.Direction()).To(Equal(migration.DirectionUndo))
+			Expect(m2.undone).To(BeFalse())
+			Expect(target.MigrationsExecuted()).To(Equal([]time.Time{m1.GetID()}))
+		})
+
+		It("should remove migrations that no longer exist on the source", func() {
+			target.AddMigration(migration.NewSummary(m5DoneErr))
+
+			summary, err := manager.MarkReverted(m5DoneErr.GetID())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary.Migration.GetID()).To(Equal(m5DoneErr.GetID()))
+			Expect(target.MigrationsExecuted()).To(BeEmpty())
+		})
+
+		It("should fail when the migration was not executed", func() {
+			_, err := manager.MarkReverted(m1.GetID())
+			Expect(err).To(Equal(migration.ErrMigrationNotExecuted))
+		})
+	})
+
+	Describe("Reset", func() {
+		It("should reset a migration", func() {
+			target.AddMigration(migration.NewSummary(m1))
+			target.AddMigration(migration.NewSummary(m2))
+			target.AddMigration(migration.NewSummary(m3))
+
+			migrationUndone, migrationsDone, err := manager.Reset(&nopReporter{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(migrationUndone).To(HaveLen(3))
+			Expect(migrationsDone).To(HaveLen(3))
+		})
+	})
+
+	Describe("Repeatable", func() {
+		var (
+			memory  *migrationtest.MemoryTarget
+			applied []string
+		)
+
+		repeatable := func(description, checksum string) *migration.DefaultMigration {
+			return migration.NewRepeatableMigration(description, func(executionContext interface{}) error {
+				applied = append(applied, description)
+				return nil
+			}).WithChecksum(checksum)
+		}
+
+		BeforeEach(func() {
+			applied = nil
+			memory = migrationtest.NewMemoryTarget()
+		})
+
+		It("should apply the repeatable migrations after the versioned ones", func() {
+			codeSource.Register(repeatable("View", "v1"))
+			manager = migration.NewManager(memory, codeSource)
+
+			ms, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(HaveLen(4))
+			Expect(ms[3].Migration.GetDescription()).To(Equal("View"))
+			Expect(applied).To(Equal([]string{"View"}))
+			Expect(memory.AppliedIDs()).To(Equal([]time.Time{m1.GetID(), m2.GetID(), m3.GetID()}))
+
+			checksums, err := memory.RepeatableChecksums(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(checksums).To(Equal(map[string]string{"View": "v1"}))
+		})
+
+		It("should apply the repeatable migrations only when their checksums change", func() {
+			codeSource.Register(repeatable("View 1", "v1"))
+			codeSource.Register(repeatable("View 2", "v1"))
+			manager = migration.NewManager(memory, codeSource)
+
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(applied).To(Equal([]string{"View 1", "View 2"}))
+
+			applied = nil
+			ms, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(BeEmpty())
+			Expect(applied).To(BeEmpty())
+
+			source := migration.NewCodeSource()
+			source.Register(m1)
+			source.Register(m2)
+			source.Register(m3)
+			source.Register(repeatable("View 1", "v1"))
+			source.Register(repeatable("View 2", "v2"))
+			manager = migration.NewManager(memory, source)
+
+			plan, err := manager.PlanMigrate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Steps).To(HaveLen(1))
+			Expect(plan.Steps[0].Migration.GetDescription()).To(Equal("View 2"))
+
+			_, err = manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(applied).To(Equal([]string{"View 2"}))
+		})
+
+		It("should always apply the repeatable migrations without checksum", func() {
+			codeSource.Register(repeatable("View", ""))
+			manager = migration.NewManager(memory, codeSource)
+
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(applied).To(Equal([]string{"View", "View"}))
+		})
+
+		It("should apply all the repeatable migrations again on reset", func() {
+			codeSource.Register(repeatable("View", "v1"))
+			manager = migration.NewManager(memory, codeSource)
+
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			plan, err := manager.PlanReset()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Steps).To(HaveLen(7))
+			Expect(migration.IsRepeatable(plan.Steps[6].Migration)).To(BeTrue())
+
+			_, _, err = manager.Reset(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(applied).To(Equal([]string{"View", "View"}))
+		})
+
+		It("should fail when the target does not track repeatable migrations", func() {
+			codeSource.Register(repeatable("View", "v1"))
+
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).To(Equal(migration.ErrRepeatableNotSupported))
+			Expect(m1.done).To(BeFalse())
+		})
+	})
+
+	Describe("Transactions", func() {
+		var txTarget *TransactionalTarget
+
+		BeforeEach(func() {
+			txTarget = &TransactionalTarget{}
+			manager = migration.NewManager(txTarget, codeSource)
+		})
+
+		It("should run each migration and its bookkeeping in a transaction", func() {
+			ms, err := manager.Migrate(&nopReporter{}, "execution context")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(HaveLen(3))
+
+			Expect(txTarget.transactions).To(HaveLen(3))
+			for i, m := range []*migrationMock{m1, m2, m3} {
+				tx := txTarget.transactions[i]
+				Expect(m.executionContext).To(BeIdenticalTo(tx))
+				Expect(tx.added).To(HaveLen(1))
+				Expect(tx.added[0].Migration).To(Equal(m))
+				Expect(tx.committed).To(BeTrue())
+				Expect(tx.rolledBack).To(BeFalse())
+			}
+
+			executed, err := txTarget.MigrationsExecuted()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(executed).To(Equal([]time.Time{m1.GetID(), m2.GetID(), m3.GetID()}))
+		})
+
+		It("should rollback the transaction when the migration fails", func() {
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(m5DoneErr)
+			manager = migration.NewManager(txTarget, codeSource)
+
+			summary, err := manager.Do(&nopReporter{}, nil)
+			Expect(err).To(Equal(m5DoneErr.doneErr))
+			Expect(summary.Failed()).To(BeTrue())
+
+			Expect(txTarget.transactions).To(HaveLen(1))
+			Expect(txTarget.transactions[0].added).To(BeEmpty())
+			Expect(txTarget.transactions[0].committed).To(BeFalse())
+			Expect(txTarget.transactions[0].rolledBack).To(BeTrue())
+			Expect(txTarget.Version()).To(Equal(migration.NoVersion))
+		})
+
+		It("should rollback the transaction when the migration cannot be recorded", func() {
+			txTarget.addMigrationErr = errors.New("AddMigration: forced error")
+
+			summary, err := manager.Do(&nopReporter{}, nil)
+			Expect(err).To(Equal(txTarget.addMigrationErr))
+			Expect(summary.Migration).To(Equal(m1))
+			Expect(m1.done).To(BeTrue())
+
+			Expect(txTarget.transactions).To(HaveLen(1))
+			Expect(txTarget.transactions[0].committed).To(BeFalse())
+			Expect(txTarget.transactions[0].rolledBack).To(BeTrue())
+			Expect(txTarget.Version()).To(Equal(migration.NoVersion))
+		})
+
+		It("should remove the migration inside of the transaction when undoing", func() {
+			txTarget.AddMigration(migration.NewSummary(m1))
+
+			summary, err := manager.Undo(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary.Migration).To(Equal(m1))
+
+			Expect(txTarget.transactions).To(HaveLen(1))
+			Expect(m1.executionContext).To(BeIdenticalTo(txTarget.transactions[0]))
+			Expect(txTarget.transactions[0].removed).To(HaveLen(1))
+			Expect(txTarget.transactions[0].committed).To(BeTrue())
+			Expect(txTarget.Version()).To(Equal(migration.NoVersion))
+		})
+
+		It("should not open a transaction for non transactional migrations", func() {
+			m := &nonTransactionalMigrationMock{
+				migrationMock: migrationMock{
+					id:          m3.GetID().Add(time.Second),
+					description: "Create index concurrently",
+				},
+			}
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(m)
+			manager = migration.NewManager(txTarget, codeSource)
+
+			_, err := manager.Do(&nopReporter{}, "execution context")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(m.executionContext).To(Equal("execution context"))
+			Expect(txTarget.transactions).To(BeEmpty())
+			Expect(txTarget.Version()).To(Equal(m.GetID()))
+		})
+
+		It("should opt a DefaultMigration out of transactions", func() {
+			m := migration.NewMigration(time.Now(), "Create index concurrently").WithoutTransaction()
+			Expect(m.NonTransactional()).To(BeTrue())
+			Expect(migration.NewMigration(time.Now(), "Regular migration").NonTransactional()).To(BeFalse())
+		})
+	})
+
+	Describe("Lock", func() {
+		var lockerTarget *LockerTarget
+
+		BeforeEach(func() {
+			lockerTarget = &LockerTarget{}
+			manager = migration.NewManager(lockerTarget, codeSource)
+		})
+
+		It("should hold the lock while migrating", func() {
+			ms, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(HaveLen(3))
+			Expect(lockerTarget.locks).To(Equal(1))
+			Expect(lockerTarget.unlocks).To(Equal(1))
+			Expect(lockerTarget.timeout).To(Equal(migration.DefaultLockTimeout))
+		})
+
+		It("should hold the lock while rewinding", func() {
+			lockerTarget.nopTarget.AddMigration(migration.NewSummary(m1))
+			lockerTarget.nopTarget.AddMigration(migration.NewSummary(m2))
+
+			ms, err := manager.Rewind(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ms).To(HaveLen(2))
+			Expect(lockerTarget.locks).To(Equal(1))
+			Expect(lockerTarget.unlocks).To(Equal(1))
+		})
+
+		It("should hold the lock only once while resetting", func() {
+			lockerTarget.nopTarget.AddMigration(migration.NewSummary(m1))
+
+			back, forward, err := manager.Reset(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(back).To(HaveLen(1))
+			Expect(forward).To(HaveLen(3))
+			Expect(lockerTarget.locks).To(Equal(1))
+			Expect(lockerTarget.unlocks).To(Equal(1))
+		})
+
+		It("should hold the lock while doing and undoing", func() {
+			_, err := manager.Do(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = manager.Undo(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lockerTarget.locks).To(Equal(2))
+			Expect(lockerTarget.unlocks).To(Equal(2))
+		})
+
+		It("should release the lock when the migration fails", func() {
+			codeSource.Register(m5DoneErr)
+
+			_, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).To(Equal(m5DoneErr.doneErr))
+			Expect(lockerTarget.locked).To(BeFalse())
+			Expect(lockerTarget.unlocks).To(Equal(1))
+		})
+
+		It("should use the configured timeout", func() {
+			manager = migration.NewManager(lockerTarget, codeSource, migration.WithLockTimeout(time.Second*5))
+
+			_, err := manager.Do(&nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lockerTarget.timeout).To(Equal(time.Second * 5))
+		})
+
+		It("should not run any migration when the lock cannot be acquired", func() {
+			lockerTarget.lockErr = migration.ErrLockTimeout
+
+			ms, err := manager.Migrate(&nopReporter{}, nil)
+			Expect(err).To(Equal(migration.ErrLockTimeout))
+			Expect(ms).To(BeEmpty())
+			Expect(m1.done).To(BeFalse())
+			Expect(lockerTarget.unlocks).To(Equal(0))
+		})
+	})
+
+	Describe("Context", func() {
+		It("should pass the context to the migrations", func() {
+			type ctxKey struct{}
+			ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+			var received context.Context
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(migration.NewMigrationContext(m1.GetID(), m1.GetDescription(), func(ctx context.Context, executionContext interface{}) error {
+				received = ctx
+				return nil
+			}))
+			manager = migration.NewManager(target, codeSource)
+
+			_, err := manager.DoContext(ctx, &nopReporter{}, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(received.Value(ctxKey{})).To(Equal("value"))
+		})
+
+		It("should stop migrating between migrations when the context is canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(migration.NewMigrationContext(m1.GetID(), m1.GetDescription(), func(ctx context.Context, executionContext interface{}) error {
+				cancel()
+				return nil
+			}))
+			codeSource.Register(m2)
+			manager = migration.NewManager(target, codeSource)
+
+			ms, err := manager.MigrateContext(ctx, &nopReporter{}, nil)
+			Expect(err).To(Equal(context.Canceled))
+			Expect(ms).To(HaveLen(1))
+			Expect(m2.done).To(BeFalse())
+			Expect(target.Version()).To(Equal(m1.GetID()))
+		})
+
+		It("should not run any migration when the context is already canceled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			ms, err := manager.MigrateContext(ctx, &nopReporter{}, nil)
+			Expect(err).To(Equal(context.Canceled))
+			Expect(ms).To(BeEmpty())
+			Expect(m1.done).To(BeFalse())
+		})
+
+		It("should fail the migration when it honors the canceled context", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			codeSource = migration.NewCodeSource()
+			codeSource.Register(migration.NewMigrationContext(m1.GetID(), m1.GetDescription(), func(ctx context.Context, executionContext interface{}) error {
+				cancel()
+				<-ctx.Done()
+				return ctx.Err()
+			}))
+			manager = migration.NewManager(target, codeSource)
+
+			summary, err := manager.DoContext(ctx, &nopReporter{}, nil)
+			Expect(err).To(Equal(context.Canceled))
+			Expect(summary.Failed()).To(BeTrue())
+			Expect(target.Version()).To(Equal(migration.NoVersion))
 		})
 	})
 })
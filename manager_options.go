@@ -0,0 +1,81 @@
+package migration
+
+import (
+	"time"
+)
+
+// Option configures the behaviour of a migration.ManagerDefault created by
+// migration.NewManager.
+type Option func(manager *ManagerDefault)
+
+// TransactionMode defines whether the migration.ManagerDefault wraps the
+// migrations in transactions when the target is a
+// migration.TransactionalTarget.
+type TransactionMode int
+
+const (
+	// TransactionPerMigration runs each migration, and its bookkeeping, in its
+	// own transaction. Migrations implementing the
+	// migration.NonTransactional can still opt out. This is the default mode.
+	TransactionPerMigration TransactionMode = iota
+
+	// TransactionNone never opens transactions, even if the target supports
+	// them.
+	TransactionNone
+)
+
+// WithLockTimeout sets how long the manager waits for the migration lock when
+// the target is a migration.Locker. A zero timeout waits indefinitely.
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(manager *ManagerDefault) {
+		manager.lockTimeout = timeout
+	}
+}
+
+// WithOutOfOrderPolicy sets how the manager handles starved migrations. The
+// default is migration.OutOfOrderFail.
+func WithOutOfOrderPolicy(policy OutOfOrderPolicy) Option {
+	return func(manager *ManagerDefault) {
+		manager.outOfOrder = policy
+	}
+}
+
+// WithAllowOutOfOrder allows the migrations with the given IDs to be applied
+// even if they are starved. It only has effect with the
+// migration.OutOfOrderExplicit policy.
+func WithAllowOutOfOrder(ids ...time.Time) Option {
+	return func(manager *ManagerDefault) {
+		if manager.allowed == nil {
+			manager.allowed = make(map[int64]bool, len(ids))
+		}
+		for _, id := range ids {
+			manager.allowed[id.UnixNano()] = true
+		}
+	}
+}
+
+// WithMigrationTimeout limits how long each migration can run. The context
+// passed to the migrations is canceled when the timeout is reached, so it is
+// only enforced on migrations honoring it (eg. migration.ContextMigration and
+// migration.FileMigration). A zero timeout disables it, which is the default.
+func WithMigrationTimeout(timeout time.Duration) Option {
+	return func(manager *ManagerDefault) {
+		manager.migrationTimeout = timeout
+	}
+}
+
+// WithTransactionMode sets whether the migrations are wrapped in
+// transactions.
+func WithTransactionMode(mode TransactionMode) Option {
+	return func(manager *ManagerDefault) {
+		manager.transactionMode = mode
+	}
+}
+
+// WithHooks registers hooks called while the migrations run. It can be used
+// more than once; the hooks are called in the order they were registered.
+func WithHooks(hooks Hooks) Option {
+	return func(manager *ManagerDefault) {
+		manager.hooks = append(manager.hooks, hooks)
+	}
+}
@@ -1,6 +1,7 @@
 package migration
 
 import (
+	"context"
 	"fmt"
 	"time"
 )
@@ -17,6 +18,26 @@ const (
 	DirectionUndo Direction = iota
 )
 
+// String returns the name of the direction ("do" or "undo").
+func (direction Direction) String() string {
+	if direction == DirectionUndo {
+		return "undo"
+	}
+	return "do"
+}
+
+// ParseDirection returns the direction with the given name (see
+// migration.Direction.String).
+func ParseDirection(name string) (Direction, error) {
+	switch name {
+	case "do":
+		return DirectionDo, nil
+	case "undo":
+		return DirectionUndo, nil
+	}
+	return DirectionDo, fmt.Errorf("%s is not a valid direction", name)
+}
+
 const migrationIDFormat = "20060102150405"
 
 // DefaultMigrationTable is the default name of the migrations table.
@@ -24,11 +45,20 @@ const DefaultMigrationTable = "_migrations"
 
 // NewMigrationID creates a new ID from a string.
 func NewMigrationID(str string) time.Time {
-	result, err := time.Parse(migrationIDFormat, str)
+	result, err := ParseMigrationID(str)
 	if err == nil {
 		return result
 	}
-	panic(fmt.Sprintf("%s is not an valid ID. (%s)", str, err))
+	panic(err.Error())
+}
+
+// ParseMigrationID parses an ID (eg. 20171025191747) from a string.
+func ParseMigrationID(str string) (time.Time, error) {
+	result, err := time.Parse(migrationIDFormat, str)
+	if err != nil {
+		return NoVersion, fmt.Errorf("%s is not an valid ID. (%s)", str, err)
+	}
+	return result, nil
 }
 
 // Migration is the interface that describes the common behavior that a
@@ -41,3 +71,69 @@ type Migration interface {
 	GetManager() Manager
 	SetManager(manager Manager) Migration
 }
+
+// ContextMigration is implemented by migrations that accept a
+// `context.Context`. The manager prefers `DoContext` and `UndoContext` over
+// `Do` and `Undo`, so the migration can stop when the context is done.
+type ContextMigration interface {
+	Migration
+	DoContext(ctx context.Context, executionContext interface{}) error
+	UndoContext(ctx context.Context, executionContext interface{}) error
+}
+
+// NonTransactional is implemented by migrations that must not run inside of a
+// transaction (eg. `CREATE INDEX CONCURRENTLY` on PostgreSQL).
+//
+// When `NonTransactional` returns true, the manager runs the migration with
+// the execution context provided by the caller, even if the target is a
+// migration.TransactionalTarget.
+type NonTransactional interface {
+	NonTransactional() bool
+}
+
+// Repeatable is implemented by migrations that are not versioned (eg. views
+// and stored functions). Instead of being applied once, a repeatable migration
+// is applied by migration.Manager.Migrate, after the versioned migrations,
+// whenever its checksum differs from the one recorded when it was last
+// applied. Repeatable migrations without a checksum are applied every time.
+//
+// Repeatable migrations are identified by their descriptions, as they have
+// no ID. They are listed by a migration.RepeatableSource and tracked by a
+// migration.RepeatableTarget.
+type Repeatable interface {
+	Repeatable() bool
+}
+
+// IsRepeatable checks if the migration is a migration.Repeatable.
+func IsRepeatable(m Migration) bool {
+	r, ok := m.(Repeatable)
+	return ok && r.Repeatable()
+}
+
+// MigrationLabel returns the ID of the migration formatted as in the file
+// names (eg. 20171025191747), or "repeatable" for repeatable migrations, which
+// have no ID.
+func MigrationLabel(m Migration) string {
+	if IsRepeatable(m) {
+		return "repeatable"
+	}
+	return m.GetID().Format(migrationIDFormat)
+}
+
+// Checksummer is implemented by migrations that can fingerprint their
+// content. The checksum is stored alongside the migration when it is applied,
+// so migration.ManagerDefault.Verify can detect migrations changed afterwards.
+//
+// An empty checksum means the migration has no checksum.
+type Checksummer interface {
+	Checksum() (string, error)
+}
+
+// migrationChecksum returns the checksum of the migration, or an empty string
+// if it does not implement the migration.Checksummer.
+func migrationChecksum(m Migration) (string, error) {
+	if c, ok := m.(Checksummer); ok {
+		return c.Checksum()
+	}
+	return "", nil
+}
@@ -1,6 +1,7 @@
 package migration
 
 import (
+	"context"
 	"fmt"
 	"path"
 	"regexp"
@@ -33,22 +34,49 @@ func (m *BaseMigration) GetDescription() string {
 // migration.Source.
 type DefaultMigration struct {
 	BaseMigration
-	do      Handler
-	undo    Handler
-	manager Manager
+	do               ContextHandler
+	undo             ContextHandler
+	manager          Manager
+	nonTransactional bool
+	checksum         string
+	repeatable       bool
 }
 
 // Handler is the signature of the up and down methods that a migration
 // will receive.
 type Handler func(executionContext interface{}) error
 
+// ContextHandler is the signature of the up and down methods, that honor a
+// `context.Context`, that a migration will receive.
+type ContextHandler func(ctx context.Context, executionContext interface{}) error
+
+// contextHandler adapts a migration.Handler to a migration.ContextHandler.
+func contextHandler(handler Handler) ContextHandler {
+	if handler == nil {
+		return nil
+	}
+	return func(ctx context.Context, executionContext interface{}) error {
+		return handler(executionContext)
+	}
+}
+
 // NewMigration returns a new instance of migration.Migration with all the
 // required properties initialized.
 //
 // If a handler is provided it will assigned to the Up method. If a second is
 // provided, it will be assigned to the Down method.
 func NewMigration(id time.Time, description string, handlers ...Handler) *DefaultMigration {
-	var do, undo Handler
+	contextHandlers := make([]ContextHandler, len(handlers))
+	for i, handler := range handlers {
+		contextHandlers[i] = contextHandler(handler)
+	}
+	return NewMigrationContext(id, description, contextHandlers...)
+}
+
+// NewMigrationContext is the migration.NewMigration with handlers that
+// receive a `context.Context`.
+func NewMigrationContext(id time.Time, description string, handlers ...ContextHandler) *DefaultMigration {
+	var do, undo ContextHandler
 	if len(handlers) > 0 {
 		do = handlers[0]
 	}
@@ -65,6 +93,24 @@ func NewMigration(id time.Time, description string, handlers ...Handler) *Defaul
 	}
 }
 
+// NewRepeatableMigration returns a new migration.Repeatable migration, which
+// is identified by its description. The handler is called whenever the
+// checksum of the migration (see migration.DefaultMigration.WithChecksum)
+// changes.
+//
+// Repeatable migrations cannot be undone.
+func NewRepeatableMigration(description string, handler Handler) *DefaultMigration {
+	return NewRepeatableMigrationContext(description, contextHandler(handler))
+}
+
+// NewRepeatableMigrationContext is the migration.NewRepeatableMigration with a
+// handler that receives a `context.Context`.
+func NewRepeatableMigrationContext(description string, handler ContextHandler) *DefaultMigration {
+	m := NewMigrationContext(NoVersion, description, handler)
+	m.repeatable = true
+	return m
+}
+
 var codeMigrationRegex = regexp.MustCompile("^([0-9]{4}[0-9]{2}[0-9]{2}[0-9]{2}[0-9]{2}[0-9]{2})_(.*).go$")
 
 // CodeMigrationDateFormat is the format understood by the CodeMigration
@@ -84,6 +130,22 @@ func NewCodeMigration(handlers ...Handler) *DefaultMigration {
 // If a handler is provided it will assigned to the Up method. If a second is
 // provided, it will be assigned to the Down method.
 func NewCodeMigrationCustom(skip int, handlers ...Handler) *DefaultMigration {
+	id, description := codeMigrationID(1 + skip)
+	return NewMigration(id, description, handlers...)
+}
+
+// NewCodeMigrationContext is the migration.NewCodeMigration with handlers that
+// receive a `context.Context`.
+func NewCodeMigrationContext(handlers ...ContextHandler) *DefaultMigration {
+	id, description := codeMigrationID(1)
+	m := NewMigrationContext(id, description, handlers...)
+	DefaultCodeSource().Register(m)
+	return m
+}
+
+// codeMigrationID extracts the ID and the description of the migration from
+// the name of the file of the caller, `skip` frames above.
+func codeMigrationID(skip int) (time.Time, string) {
 	_, file, _, ok := runtime.Caller(1 + skip)
 	if ok {
 		groups := codeMigrationRegex.FindStringSubmatch(path.Base(file))
@@ -92,8 +154,7 @@ func NewCodeMigrationCustom(skip int, handlers ...Handler) *DefaultMigration {
 			if err != nil {
 				panic(fmt.Sprintf("the file name '%s' has an invalid datetime", file))
 			}
-			m := NewMigration(id, groups[2], handlers...)
-			return m
+			return id, groups[2]
 		}
 		panic(fmt.Sprintf("the file name '%s' has an invalid format", file))
 	} else {
@@ -103,12 +164,24 @@ func NewCodeMigrationCustom(skip int, handlers ...Handler) *DefaultMigration {
 
 // Do calls the up action of the migration.
 func (m *DefaultMigration) Do(executionContext interface{}) error {
-	return m.do(executionContext)
+	return m.DoContext(context.Background(), executionContext)
 }
 
 // Undo calls the down action of the migration.
 func (m *DefaultMigration) Undo(executionContext interface{}) error {
-	return m.undo(executionContext)
+	return m.UndoContext(context.Background(), executionContext)
+}
+
+// DoContext implements the migration.ContextMigration by calling the up
+// action of the migration.
+func (m *DefaultMigration) DoContext(ctx context.Context, executionContext interface{}) error {
+	return m.do(ctx, executionContext)
+}
+
+// UndoContext implements the migration.ContextMigration by calling the down
+// action of the migration.
+func (m *DefaultMigration) UndoContext(ctx context.Context, executionContext interface{}) error {
+	return m.undo(ctx, executionContext)
 }
 
 // GetManager returns the reference of the manager that is executing the
@@ -124,3 +197,38 @@ func (m *DefaultMigration) SetManager(manager Manager) Migration {
 	m.manager = manager
 	return m
 }
+
+// WithoutTransaction opts the migration out of the transaction opened by a
+// migration.TransactionalTarget.
+//
+// It returns itself for sugar syntax.
+func (m *DefaultMigration) WithoutTransaction() *DefaultMigration {
+	m.nonTransactional = true
+	return m
+}
+
+// NonTransactional implements the migration.NonTransactional.
+func (m *DefaultMigration) NonTransactional() bool {
+	return m.nonTransactional
+}
+
+// Repeatable implements the migration.Repeatable.
+func (m *DefaultMigration) Repeatable() bool {
+	return m.repeatable
+}
+
+// WithChecksum sets the checksum of the migration. Code migrations have no
+// content to fingerprint, so it is up to the developer to change the checksum
+// (eg. a version string) whenever the migration changes.
+//
+// It returns itself for sugar syntax.
+func (m *DefaultMigration) WithChecksum(checksum string) *DefaultMigration {
+	m.checksum = checksum
+	return m
+}
+
+// Checksum implements the migration.Checksummer by returning the checksum set
+// by migration.DefaultMigration.WithChecksum.
+func (m *DefaultMigration) Checksum() (string, error) {
+	return m.checksum, nil
+}
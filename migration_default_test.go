@@ -1,6 +1,7 @@
 package migration_test
 
 import (
+	"context"
 	"errors"
 	"time"
 
@@ -53,12 +54,49 @@ var _ = Describe("MigrationDefault", func() {
 		Expect(err.Error()).To(Equal("this is undo"))
 	})
 
+	It("should set the checksum", func() {
+		m := migration.NewMigration(time.Now(), "description 1")
+		Expect(m.Checksum()).To(BeEmpty())
+		Expect(m.WithChecksum("v2")).To(Equal(m))
+		Expect(m.Checksum()).To(Equal("v2"))
+	})
+
 	It("should create a new instance with a manager", func() {
-		manager := migration.NewDefaultManager(&nopTarget{}, migration.NewCodeSource())
+		manager := migration.NewManager(&nopTarget{}, migration.NewCodeSource())
 
 		now := time.Now().UTC()
 		m := migration.NewMigration(now, "description 1")
 		m.SetManager(manager)
 		Expect(m.GetManager()).To(Equal(manager))
 	})
+
+	It("should create a new instance with context handlers", func() {
+		now := time.Now().UTC()
+
+		type ctxKey struct{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+		var doCtx, undoCtx context.Context
+		do := func(ctx context.Context, executionContext interface{}) error {
+			doCtx = ctx
+			return nil
+		}
+		undo := func(ctx context.Context, executionContext interface{}) error {
+			undoCtx = ctx
+			return errors.New("this is undo")
+		}
+
+		m := migration.NewMigrationContext(now, "description 1", do, undo)
+		Expect(m.GetID()).To(Equal(now))
+		Expect(m.GetDescription()).To(Equal("description 1"))
+		Expect(m.DoContext(ctx, nil)).To(Succeed())
+		Expect(doCtx.Value(ctxKey{})).To(Equal("value"))
+		err := m.UndoContext(ctx, nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("this is undo"))
+		Expect(undoCtx.Value(ctxKey{})).To(Equal("value"))
+
+		Expect(m.Do(nil)).To(Succeed())
+		Expect(doCtx).To(Equal(context.Background()))
+	})
 })
@@ -1,22 +1,80 @@
 package migration
 
-import "time"
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/lab259/go-migration/splitter"
+)
+
+// ErrInvalidExecutionContext is returned when a migration.FileMigration
+// receives an execution context that cannot run SQL statements.
+var ErrInvalidExecutionContext = errors.New("invalid execution context: expected *sql.DB, *sql.Conn or *sql.Tx")
+
+// SQLExecutor describes the execution contexts accepted by the
+// migration.FileMigration. It is implemented by `*sql.DB`, `*sql.Conn` and
+// `*sql.Tx`.
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// IrreversibleMigrationError is returned when undoing a migration that cannot
+// be undone (eg. a migration.FileMigration without a .down file).
+type IrreversibleMigrationError struct {
+	Migration Migration
+}
+
+// Error implements the error interface.
+func (err *IrreversibleMigrationError) Error() string {
+	return fmt.Sprintf("migration [%s] %s is irreversible", err.Migration.GetID().Format(migrationIDFormat), err.Migration.GetDescription())
+}
+
+// StatementError is returned when a statement of a migration.FileMigration
+// fails. It points the file and the line where the statement starts.
+type StatementError struct {
+	File string
+	Line int
+	Err  error
+}
+
+// Error implements the error interface.
+func (err *StatementError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", err.File, err.Line, err.Err)
+}
+
+// DialectTarget is implemented by the targets that know which SQL dialect
+// their database speaks. It is used by the migration.FileMigration to split
+// its files into statements.
+type DialectTarget interface {
+	Dialect() splitter.Dialect
+}
 
 // FileMigration is the implementation of the migration.Migration that runs SQL
 // files.
 //
-// It is designed to provide a coded implementaiton of a migration. It receives
-// an up and down anonymous methods to be ran while executing the migration.
+// The SQL of the migration is read from the
+// [migration.FileMigration.baseFile].(up|down).[ext] files inside of the
+// directory the migration was listed from. Repeatable migrations have a single
+// R__[description].[ext] file.
 //
-// It is used by the migration.CodeSource implemenation of the
+// It is used by the migration.DirectorySource implemenation of the
 // migration.Source.
 type FileMigration struct {
 	id          time.Time
 	description string
+	directory   string
 	baseFile    string
 	ext         string
 	up          bool
 	down        bool
+	repeatable  bool
 	manager     Manager
 }
 
@@ -37,19 +95,112 @@ func (m *FileMigration) GetDescription() string {
 //
 // If the file does not exists, it returns an error.
 func (m *FileMigration) Do(executionContext interface{}) error {
-	// TODO
-	return nil
+	return m.DoContext(context.Background(), executionContext)
+}
+
+// DoContext implements the migration.ContextMigration. It is the
+// migration.FileMigration.Do with a `context.Context`.
+func (m *FileMigration) DoContext(ctx context.Context, executionContext interface{}) error {
+	return m.run(ctx, executionContext, "up")
 }
 
 // Undo implements the migration.Migration.Down by running all SQLs inside of
 // the [migration.FileMigration.baseFile].down.sql file.
 //
-// If the file does not exists, it returns an error.
+// If the file does not exists, it returns a
+// `*migration.IrreversibleMigrationError`.
 func (m *FileMigration) Undo(executionContext interface{}) error {
-	// TODO
+	return m.UndoContext(context.Background(), executionContext)
+}
+
+// UndoContext implements the migration.ContextMigration. It is the
+// migration.FileMigration.Undo with a `context.Context`.
+func (m *FileMigration) UndoContext(ctx context.Context, executionContext interface{}) error {
+	if !m.down {
+		return &IrreversibleMigrationError{
+			Migration: m,
+		}
+	}
+	return m.run(ctx, executionContext, "down")
+}
+
+// fileName returns the path of the file for the given direction ("up" or
+// "down"). Repeatable migrations have a single file.
+func (m *FileMigration) fileName(direction string) string {
+	if m.repeatable {
+		return filepath.Join(m.directory, fmt.Sprintf("%s.%s", m.baseFile, m.ext))
+	}
+	return filepath.Join(m.directory, fmt.Sprintf("%s.%s.%s", m.baseFile, direction, m.ext))
+}
+
+func (m *FileMigration) run(ctx context.Context, executionContext interface{}, direction string) error {
+	executor, ok := executionContext.(SQLExecutor)
+	if !ok {
+		return ErrInvalidExecutionContext
+	}
+
+	fileName := m.fileName(direction)
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	statements, err := splitter.Split(string(content), m.dialect())
+	if err != nil {
+		if syntaxErr, ok := err.(*splitter.SyntaxError); ok {
+			return &StatementError{
+				File: fileName,
+				Line: syntaxErr.Line,
+				Err:  errors.New(syntaxErr.Message),
+			}
+		}
+		return err
+	}
+
+	for _, statement := range statements {
+		if _, err := executor.ExecContext(ctx, statement.SQL); err != nil {
+			return &StatementError{
+				File: fileName,
+				Line: statement.Line,
+				Err:  err,
+			}
+		}
+	}
 	return nil
 }
 
+// Repeatable implements the migration.Repeatable.
+func (m *FileMigration) Repeatable() bool {
+	return m.repeatable
+}
+
+// Checksum implements the migration.Checksummer by returning the SHA-256 of
+// the content of the up file. Migrations without the up file have no
+// checksum, so they are not verified.
+func (m *FileMigration) Checksum() (string, error) {
+	if !m.up {
+		return "", nil
+	}
+	content, err := ioutil.ReadFile(m.fileName("up"))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// dialect returns the splitter.Dialect of the target of the manager running
+// this migration. If the target does not implement the
+// migration.DialectTarget, splitter.Generic is used.
+func (m *FileMigration) dialect() splitter.Dialect {
+	if m.manager != nil {
+		if target, ok := m.manager.Target().(DialectTarget); ok {
+			return target.Dialect()
+		}
+	}
+	return splitter.Generic
+}
+
 // GetManager implements the migration.Migration.GetManager by returning the
 // manager responsible for the execution.
 func (m *FileMigration) GetManager() Manager {
@@ -0,0 +1,160 @@
+package migration_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lab259/go-migration"
+	"github.com/lab259/go-migration/migrationtest"
+	"github.com/lab259/go-migration/splitter"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type execMock struct {
+	queries []string
+	err     error
+	failOn  string
+}
+
+func (executor *execMock) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	executor.queries = append(executor.queries, query)
+	if executor.err != nil {
+		return nil, executor.err
+	}
+	if executor.failOn != "" && strings.Contains(query, executor.failOn) {
+		return nil, errors.New("forced statement error")
+	}
+	return driver.RowsAffected(0), nil
+}
+
+type dialectTarget struct {
+	nopTarget
+	dialect splitter.Dialect
+}
+
+func (target *dialectTarget) Dialect() splitter.Dialect {
+	return target.dialect
+}
+
+var _ = Describe("FileMigration", func() {
+	listMigrations := func(directory string) []migration.Migration {
+		d := migration.DirectorySource{
+			Directory: directory,
+			Extension: "sql",
+		}
+		ms, err := d.List()
+		Expect(err).ToNot(HaveOccurred())
+		return ms
+	}
+
+	Describe("Do", func() {
+		It("should run the content of the up file", func() {
+			ms := listMigrations("test/migrations1")
+			executor := &execMock{}
+			Expect(ms[0].Do(executor)).To(Succeed())
+			Expect(executor.queries).To(Equal([]string{"CONTENT1 - UP"}))
+		})
+
+		It("should fail when the up file does not exists", func() {
+			ms := listMigrations("test/migrations1")
+			executor := &execMock{}
+			Expect(ms[1].Do(executor)).ToNot(Succeed())
+			Expect(executor.queries).To(BeEmpty())
+		})
+
+		It("should fail when the execution context cannot run SQL", func() {
+			ms := listMigrations("test/migrations1")
+			Expect(ms[0].Do(nil)).To(Equal(migration.ErrInvalidExecutionContext))
+		})
+
+		It("should return the error of the executor", func() {
+			ms := listMigrations("test/migrations1")
+			executor := &execMock{
+				err: errors.New("forced error"),
+			}
+			err := ms[0].Do(executor)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("test/migrations1/20171025191747_description1.up.sql:1: forced error"))
+		})
+
+		It("should run each statement of the file using the dialect of the target", func() {
+			ms := listMigrations("test/migrations3")
+			ms[0].SetManager(migration.NewManager(&dialectTarget{dialect: splitter.PostgreSQL}, migration.NewCodeSource()))
+			executor := &execMock{}
+			Expect(ms[0].Do(executor)).To(Succeed())
+			Expect(executor.queries).To(HaveLen(3))
+			Expect(executor.queries[0]).To(Equal("CREATE TABLE a (id int)"))
+			Expect(executor.queries[1]).To(HaveSuffix("CREATE TABLE b (name text DEFAULT 'x;y')"))
+			Expect(executor.queries[2]).To(HavePrefix("CREATE FUNCTION f()"))
+			Expect(executor.queries[2]).To(HaveSuffix("$$ LANGUAGE plpgsql"))
+		})
+
+		It("should report the file and line of the failing statement", func() {
+			ms := listMigrations("test/migrations3")
+			executor := &execMock{
+				failOn: "CREATE TABLE b",
+			}
+			err := ms[0].Do(executor)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(&migration.StatementError{}))
+			stmtErr := err.(*migration.StatementError)
+			Expect(stmtErr.File).To(Equal("test/migrations3/20180101000000_multiple_statements.up.sql"))
+			Expect(stmtErr.Line).To(Equal(4))
+			Expect(stmtErr.Err.Error()).To(Equal("forced statement error"))
+			Expect(err.Error()).To(Equal("test/migrations3/20180101000000_multiple_statements.up.sql:4: forced statement error"))
+			Expect(executor.queries).To(HaveLen(2))
+		})
+	})
+
+	Describe("Checksum", func() {
+		It("should return the SHA-256 of the up file", func() {
+			ms := listMigrations("test/migrations1")
+			Expect(ms[0].(migration.Checksummer).Checksum()).To(Equal(fmt.Sprintf("%x", sha256.Sum256([]byte("CONTENT1 - UP")))))
+		})
+
+		It("should return no checksum when the up file does not exists", func() {
+			ms := listMigrations("test/migrations1")
+			Expect(ms[1].(migration.Checksummer).Checksum()).To(BeEmpty())
+		})
+
+		It("should verify the migrations without the up file", func() {
+			source := &migration.DirectorySource{
+				Directory: "test/migrations1",
+				Extension: "sql",
+			}
+			manager := migration.NewManager(migrationtest.NewMemoryTarget(), source)
+
+			_, err := manager.Baseline(migration.NewMigrationID("20171025213303"))
+			Expect(err).ToNot(HaveOccurred())
+			mismatches, err := manager.Verify(&nopReporter{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mismatches).To(BeEmpty())
+		})
+	})
+
+	Describe("Undo", func() {
+		It("should run the content of the down file", func() {
+			ms := listMigrations("test/migrations1")
+			executor := &execMock{}
+			Expect(ms[1].Undo(executor)).To(Succeed())
+			Expect(executor.queries).To(Equal([]string{"CONTENT2 - DOWN"}))
+		})
+
+		It("should fail with an irreversible migration error when there is no down file", func() {
+			ms := listMigrations("test/migrations2")
+			Expect(ms).To(HaveLen(1))
+			executor := &execMock{}
+			err := ms[0].Undo(executor)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(&migration.IrreversibleMigrationError{}))
+			Expect(err.Error()).To(Equal("migration [20171025191747] create_users is irreversible"))
+			Expect(executor.queries).To(BeEmpty())
+		})
+	})
+})
@@ -7,11 +7,14 @@ import "time"
 type Summary struct {
 	Migration Migration
 	direction Direction
+	startedAt time.Time
 	duration  time.Duration
 	failed    bool
 	failure   error
 	panicked  bool
 	panicData interface{}
+	checksum  string
+	baselined bool
 }
 
 // NewSummary creates a new summary based on a migration instance.
@@ -51,7 +54,24 @@ func (summary *Summary) Direction() Direction {
 	return summary.direction
 }
 
+// StartedAt is when the migration started running.
+func (summary *Summary) StartedAt() time.Time {
+	return summary.startedAt
+}
+
 // Duration is how long the migration took to run.
 func (summary *Summary) Duration() time.Duration {
 	return summary.duration
 }
+
+// Checksum is the checksum of the migration when it was applied. It is empty
+// when the migration does not implement the migration.Checksummer.
+func (summary *Summary) Checksum() string {
+	return summary.checksum
+}
+
+// Baselined returns if the migration was marked as executed by
+// migration.ManagerDefault.Baseline, instead of being ran.
+func (summary *Summary) Baselined() bool {
+	return summary.baselined
+}
@@ -0,0 +1,167 @@
+package migrationtest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onsi/gomega/types"
+)
+
+const migrationIDFormat = "20060102150405"
+
+// EventMatcher matches a single Event captured by the
+// migrationtest.RecordingReporter.
+type EventMatcher struct {
+	description string
+	match       func(event Event) bool
+}
+
+// Match reports whether the event is matched.
+func (matcher EventMatcher) Match(event Event) bool {
+	return matcher.match(event)
+}
+
+func (matcher EventMatcher) String() string {
+	return matcher.description
+}
+
+// Emitted matches any event of the given kind.
+func Emitted(kind EventKind) EventMatcher {
+	return EventMatcher{
+		description: string(kind),
+		match: func(event Event) bool {
+			return event.Kind == kind
+		},
+	}
+}
+
+// EmittedFor matches the events of the given kind whose Summary is of the
+// migration with the given ID (eg. BeforeMigration and AfterMigration).
+func EmittedFor(kind EventKind, id time.Time) EventMatcher {
+	return EventMatcher{
+		description: fmt.Sprintf("%s(%s)", kind, id.Format(migrationIDFormat)),
+		match: func(event Event) bool {
+			return event.Kind == kind && event.Summary != nil && event.Summary.Migration.GetID().Equal(id)
+		},
+	}
+}
+
+// EmittedWith matches the events of the given kind that carry `n` summaries
+// (AfterMigrate, AfterRewind and AfterReset) or `n` migrations (BeforeMigrate,
+// BeforeRewind, ListPending, ListExecuted and MigrationsStarved).
+func EmittedWith(kind EventKind, n int) EventMatcher {
+	return EventMatcher{
+		description: fmt.Sprintf("%s with %d", kind, n),
+		match: func(event Event) bool {
+			if event.Kind != kind {
+				return false
+			}
+			if event.Summaries != nil {
+				return len(event.Summaries) == n
+			}
+			return len(event.Migrations) == n
+		},
+	}
+}
+
+// WithErr restricts the matcher to the events with the given error. A nil
+// `err` matches the events without errors.
+func (matcher EventMatcher) WithErr(err error) EventMatcher {
+	return EventMatcher{
+		description: fmt.Sprintf("%s [err: %v]", matcher.description, err),
+		match: func(event Event) bool {
+			return matcher.match(event) && event.Err == err
+		},
+	}
+}
+
+// InOrder checks that the events contain a sequence matching the matchers, in
+// order. Events that do not match are skipped.
+//
+// It returns an error describing the first matcher not satisfied.
+func InOrder(events []Event, matchers ...EventMatcher) error {
+	i := 0
+	for _, event := range events {
+		if i == len(matchers) {
+			break
+		}
+		if matchers[i].Match(event) {
+			i++
+		}
+	}
+	if i < len(matchers) {
+		return fmt.Errorf("expected %s after %s in the events\n\t%s", matchers[i], describeMatchers(matchers[:i]), describeEvents(events))
+	}
+	return nil
+}
+
+func describeMatchers(matchers []EventMatcher) string {
+	if len(matchers) == 0 {
+		return "the beginning"
+	}
+	descriptions := make([]string, len(matchers))
+	for i, matcher := range matchers {
+		descriptions[i] = matcher.String()
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+func describeEvents(events []Event) string {
+	descriptions := make([]string, len(events))
+	for i, event := range events {
+		if event.Summary != nil {
+			descriptions[i] = fmt.Sprintf("%s(%s)", event.Kind, event.Summary.Migration.GetID().Format(migrationIDFormat))
+		} else {
+			descriptions[i] = string(event.Kind)
+		}
+	}
+	return strings.Join(descriptions, "\n\t")
+}
+
+// HaveEmittedInOrder is the Gomega matcher of the migrationtest.InOrder. It
+// accepts a *migrationtest.RecordingReporter or a []migrationtest.Event.
+//
+//	Expect(reporter).To(migrationtest.HaveEmittedInOrder(
+//		migrationtest.EmittedFor(migrationtest.BeforeMigration, id),
+//		migrationtest.EmittedFor(migrationtest.AfterMigration, id),
+//		migrationtest.EmittedWith(migrationtest.AfterMigrate, 1),
+//	))
+func HaveEmittedInOrder(matchers ...EventMatcher) types.GomegaMatcher {
+	return &inOrderMatcher{
+		matchers: matchers,
+	}
+}
+
+type inOrderMatcher struct {
+	matchers []EventMatcher
+	err      error
+}
+
+func (matcher *inOrderMatcher) events(actual interface{}) ([]Event, error) {
+	switch a := actual.(type) {
+	case *RecordingReporter:
+		return a.Events(), nil
+	case []Event:
+		return a, nil
+	default:
+		return nil, fmt.Errorf("HaveEmittedInOrder expects a *migrationtest.RecordingReporter or a []migrationtest.Event, got %T", actual)
+	}
+}
+
+func (matcher *inOrderMatcher) Match(actual interface{}) (bool, error) {
+	events, err := matcher.events(actual)
+	if err != nil {
+		return false, err
+	}
+	matcher.err = InOrder(events, matcher.matchers...)
+	return matcher.err == nil, nil
+}
+
+func (matcher *inOrderMatcher) FailureMessage(actual interface{}) string {
+	return matcher.err.Error()
+}
+
+func (matcher *inOrderMatcher) NegatedFailureMessage(actual interface{}) string {
+	return fmt.Sprintf("expected the events not to contain %s", describeMatchers(matcher.matchers))
+}
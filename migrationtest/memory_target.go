@@ -0,0 +1,192 @@
+// Package migrationtest provides helpers for testing code built on top of the
+// migration package without a database.
+package migrationtest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lab259/go-migration"
+)
+
+// ErrForced is the error returned by the failures injected on the
+// migrationtest.MemoryTarget when no error is informed.
+var ErrForced = errors.New("migrationtest: forced error")
+
+// MemoryTarget is a migration.Target that keeps the migrations executed in
+// memory. It is safe for concurrent use.
+//
+// Besides the migration.Target, it implements the migration.ChecksumTarget,
+// the migration.HistoryTarget and the migration.RepeatableTarget, so it can be
+// used with every command of the migration.Manager.
+type MemoryTarget struct {
+	mu          sync.Mutex
+	applied     map[time.Time]*migration.Summary
+	repeatables map[string]*migration.Summary
+	history     []*migration.HistoryRecord
+
+	addMigrationCalls int
+	addMigrationFailN int
+	addMigrationErr   error
+	versionErr        error
+}
+
+// NewMemoryTarget returns a new empty instance of the
+// migrationtest.MemoryTarget.
+func NewMemoryTarget() *MemoryTarget {
+	return &MemoryTarget{
+		applied:     make(map[time.Time]*migration.Summary),
+		repeatables: make(map[string]*migration.Summary),
+	}
+}
+
+// FailAddMigrationOn makes the Nth call (starting from 1) of the
+// migrationtest.MemoryTarget.AddMigration return `err`. If `err` is nil,
+// migrationtest.ErrForced is returned.
+func (target *MemoryTarget) FailAddMigrationOn(n int, err error) *MemoryTarget {
+	if err == nil {
+		err = ErrForced
+	}
+	target.mu.Lock()
+	defer target.mu.Unlock()
+	target.addMigrationFailN = n
+	target.addMigrationErr = err
+	return target
+}
+
+// FailVersion makes the migrationtest.MemoryTarget.Version return `err`. If
+// `err` is nil, migrationtest.ErrForced is returned.
+func (target *MemoryTarget) FailVersion(err error) *MemoryTarget {
+	if err == nil {
+		err = ErrForced
+	}
+	target.mu.Lock()
+	defer target.mu.Unlock()
+	target.versionErr = err
+	return target
+}
+
+// Version implements the migration.Target.Version by returning the most
+// recent migration applied.
+func (target *MemoryTarget) Version() (time.Time, error) {
+	target.mu.Lock()
+	defer target.mu.Unlock()
+
+	if target.versionErr != nil {
+		return migration.NoVersion, target.versionErr
+	}
+
+	version := migration.NoVersion
+	for id := range target.applied {
+		if id.After(version) {
+			version = id
+		}
+	}
+	return version, nil
+}
+
+// AddMigration implements the migration.Target.AddMigration by storing the
+// summary of the migration. Repeatable migrations are stored apart, replacing
+// the summary of their previous execution.
+func (target *MemoryTarget) AddMigration(summary *migration.Summary) error {
+	target.mu.Lock()
+	defer target.mu.Unlock()
+
+	target.addMigrationCalls++
+	if target.addMigrationFailN > 0 && target.addMigrationCalls == target.addMigrationFailN {
+		return target.addMigrationErr
+	}
+
+	if migration.IsRepeatable(summary.Migration) {
+		target.repeatables[summary.Migration.GetDescription()] = summary
+		return nil
+	}
+	target.applied[summary.Migration.GetID().UTC()] = summary
+	target.history = append(target.history, migration.NewHistoryRecord(summary))
+	return nil
+}
+
+// RemoveMigration implements the migration.Target.RemoveMigration, recording
+// the revert on the history.
+func (target *MemoryTarget) RemoveMigration(summary *migration.Summary) error {
+	target.mu.Lock()
+	defer target.mu.Unlock()
+
+	delete(target.applied, summary.Migration.GetID().UTC())
+	target.history = append(target.history, migration.NewHistoryRecord(summary))
+	return nil
+}
+
+// MigrationsExecuted implements the migration.Target.MigrationsExecuted.
+func (target *MemoryTarget) MigrationsExecuted() ([]time.Time, error) {
+	return target.AppliedIDs(), nil
+}
+
+// Checksums implements the migration.ChecksumTarget.
+func (target *MemoryTarget) Checksums(ctx context.Context) (map[int64]string, error) {
+	target.mu.Lock()
+	defer target.mu.Unlock()
+
+	checksums := make(map[int64]string)
+	for id, summary := range target.applied {
+		if summary.Checksum() != "" {
+			checksums[id.UnixNano()] = summary.Checksum()
+		}
+	}
+	return checksums, nil
+}
+
+// RepeatableChecksums implements the migration.RepeatableTarget.
+func (target *MemoryTarget) RepeatableChecksums(ctx context.Context) (map[string]string, error) {
+	target.mu.Lock()
+	defer target.mu.Unlock()
+
+	checksums := make(map[string]string, len(target.repeatables))
+	for description, summary := range target.repeatables {
+		checksums[description] = summary.Checksum()
+	}
+	return checksums, nil
+}
+
+// AddFailure implements the migration.HistoryTarget by storing the failed
+// attempt.
+func (target *MemoryTarget) AddFailure(ctx context.Context, record *migration.HistoryRecord) error {
+	target.mu.Lock()
+	defer target.mu.Unlock()
+
+	target.history = append(target.history, record)
+	return nil
+}
+
+// History implements the migration.HistoryTarget by listing the migrations
+// applied and reverted along with the failed attempts, sorted by the time
+// they were applied.
+func (target *MemoryTarget) History(ctx context.Context) ([]*migration.HistoryRecord, error) {
+	target.mu.Lock()
+	defer target.mu.Unlock()
+
+	records := make([]*migration.HistoryRecord, len(target.history))
+	copy(records, target.history)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].AppliedAt.Before(records[j].AppliedAt)
+	})
+	return records, nil
+}
+
+// AppliedIDs returns the IDs of the migrations applied, sorted.
+func (target *MemoryTarget) AppliedIDs() []time.Time {
+	target.mu.Lock()
+	defer target.mu.Unlock()
+
+	ids := make([]time.Time, 0, len(target.applied))
+	for id := range target.applied {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Before(ids[j])
+	})
+	return ids
+}
@@ -0,0 +1,124 @@
+package migrationtest_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lab259/go-migration"
+	"github.com/lab259/go-migration/migrationtest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func nopHandler(executionContext interface{}) error {
+	return nil
+}
+
+var _ = Describe("MemoryTarget", func() {
+	var (
+		m1, m2, m3 *migration.DefaultMigration
+		source     *migration.CodeSource
+	)
+
+	BeforeEach(func() {
+		baseTime := time.Date(2000, 0, 0, 0, 0, 0, 0, time.UTC)
+
+		m1 = migration.NewMigration(baseTime, "Migration 1", nopHandler, nopHandler).WithChecksum("checksum 1")
+		m2 = migration.NewMigration(baseTime.Add(time.Second), "Migration 2", nopHandler, nopHandler)
+		m3 = migration.NewMigration(baseTime.Add(time.Hour), "Migration 3", nopHandler, nopHandler)
+
+		source = migration.NewCodeSource()
+		source.Register(m1)
+		source.Register(m2)
+		source.Register(m3)
+	})
+
+	It("should return NoVersion when there is no migrations ran", func() {
+		target := migrationtest.NewMemoryTarget()
+		Expect(target.Version()).To(Equal(migration.NoVersion))
+		Expect(target.AppliedIDs()).To(BeEmpty())
+	})
+
+	It("should add and remove migrations", func() {
+		target := migrationtest.NewMemoryTarget()
+		Expect(target.AddMigration(migration.NewSummary(m3))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m1))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m2))).To(Succeed())
+		Expect(target.Version()).To(Equal(m3.GetID()))
+		Expect(target.AppliedIDs()).To(Equal([]time.Time{m1.GetID(), m2.GetID(), m3.GetID()}))
+
+		Expect(target.RemoveMigration(migration.NewSummary(m3))).To(Succeed())
+		Expect(target.Version()).To(Equal(m2.GetID()))
+		Expect(target.MigrationsExecuted()).To(Equal([]time.Time{m1.GetID(), m2.GetID()}))
+	})
+
+	It("should be safe for concurrent use", func() {
+		target := migrationtest.NewMemoryTarget()
+		baseTime := time.Date(2000, 0, 0, 0, 0, 0, 0, time.UTC)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				m := migration.NewMigration(baseTime.Add(time.Duration(i)*time.Second), "Migration", nopHandler)
+				Expect(target.AddMigration(migration.NewSummary(m))).To(Succeed())
+				_, err := target.Version()
+				Expect(err).ToNot(HaveOccurred())
+			}(i)
+		}
+		wg.Wait()
+
+		Expect(target.AppliedIDs()).To(HaveLen(50))
+	})
+
+	It("should fail the Nth call to AddMigration", func() {
+		target := migrationtest.NewMemoryTarget().FailAddMigrationOn(2, nil)
+		manager := migration.NewManager(target, source)
+
+		_, err := manager.Migrate(migrationtest.NewRecordingReporter(), nil)
+		Expect(err).To(Equal(migrationtest.ErrForced))
+		Expect(target.AppliedIDs()).To(Equal([]time.Time{m1.GetID()}))
+
+		_, err = manager.Migrate(migrationtest.NewRecordingReporter(), nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target.AppliedIDs()).To(Equal([]time.Time{m1.GetID(), m2.GetID(), m3.GetID()}))
+	})
+
+	It("should fail Version", func() {
+		forcedErr := errors.New("version: forced error")
+		target := migrationtest.NewMemoryTarget().FailVersion(forcedErr)
+
+		_, err := target.Version()
+		Expect(err).To(Equal(forcedErr))
+	})
+
+	It("should store the checksums and the history of the migrations", func() {
+		source.Register(migration.NewMigration(m3.GetID().Add(time.Hour), "Migration 4", func(executionContext interface{}) error {
+			return errors.New("forced error")
+		}))
+		target := migrationtest.NewMemoryTarget()
+		manager := migration.NewManager(target, source)
+
+		_, err := manager.Migrate(migrationtest.NewRecordingReporter(), nil)
+		Expect(err).To(MatchError("forced error"))
+
+		checksums, err := target.Checksums(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(Equal(map[int64]string{
+			m1.GetID().UnixNano(): "checksum 1",
+		}))
+
+		records, err := target.History(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(4))
+		Expect(records[0].ID).To(Equal(m1.GetID()))
+		Expect(records[0].Failed).To(BeFalse())
+		Expect(records[3].Description).To(Equal("Migration 4"))
+		Expect(records[3].Failed).To(BeTrue())
+		Expect(records[3].Failure).To(Equal("forced error"))
+	})
+})
@@ -0,0 +1,239 @@
+package migrationtest
+
+import (
+	"sync"
+
+	"github.com/lab259/go-migration"
+)
+
+// EventKind identifies the migration.Reporter method that produced an Event.
+type EventKind string
+
+// The kinds of the events captured by the migrationtest.RecordingReporter, one
+// for each method of the migration.Reporter.
+const (
+	BeforeMigration    EventKind = "BeforeMigration"
+	MigrationSummary   EventKind = "MigrationSummary"
+	AfterMigration     EventKind = "AfterMigration"
+	BeforeMigrate      EventKind = "BeforeMigrate"
+	AfterMigrate       EventKind = "AfterMigrate"
+	BeforeRewind       EventKind = "BeforeRewind"
+	AfterRewind        EventKind = "AfterRewind"
+	BeforeReset        EventKind = "BeforeReset"
+	AfterReset         EventKind = "AfterReset"
+	AfterBaseline      EventKind = "AfterBaseline"
+	MigrationMarked    EventKind = "MigrationMarked"
+	ListPending        EventKind = "ListPending"
+	ListExecuted       EventKind = "ListExecuted"
+	ListHistory        EventKind = "ListHistory"
+	ListPlan           EventKind = "ListPlan"
+	ListStatus         EventKind = "ListStatus"
+	SQLScript          EventKind = "SQLScript"
+	Failure            EventKind = "Failure"
+	Exit               EventKind = "Exit"
+	MigrationsStarved  EventKind = "MigrationsStarved"
+	ChecksumMismatches EventKind = "ChecksumMismatches"
+	AfterVerify        EventKind = "AfterVerify"
+	Residues           EventKind = "Residues"
+	Usage              EventKind = "Usage"
+	CommandNotFound    EventKind = "CommandNotFound"
+	NoCommand          EventKind = "NoCommand"
+)
+
+// Event is a call to a migration.Reporter method captured by the
+// migrationtest.RecordingReporter. Only the fields related to the Kind are
+// filled.
+type Event struct {
+	Kind EventKind
+
+	// Summary is set by BeforeMigration, MigrationSummary, AfterMigration and
+	// MigrationMarked.
+	Summary *migration.Summary
+
+	// Migrations is set by BeforeMigrate, BeforeRewind, ListPending,
+	// ListExecuted and MigrationsStarved.
+	Migrations []migration.Migration
+
+	// Summaries is set by AfterMigrate, AfterRewind, AfterBaseline and
+	// AfterReset (with the summaries of the rewind).
+	Summaries []*migration.Summary
+
+	// MigrateSummaries is set by AfterReset.
+	MigrateSummaries []*migration.Summary
+
+	Records    []*migration.HistoryRecord
+	Rows       []*migration.StatusRow
+	Plan       *migration.Plan
+	Mismatches []*migration.ChecksumMismatch
+	Residues   []*migration.Residue
+	Script     string
+
+	Err     error
+	Code    int
+	Command string
+}
+
+// RecordingReporter is a migration.Reporter that captures every call as an
+// Event. It is safe for concurrent use.
+//
+// Unlike the migration.DefaultReporter, its Exit does not exit the process.
+type RecordingReporter struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewRecordingReporter returns a new instance of the
+// migrationtest.RecordingReporter.
+func NewRecordingReporter() *RecordingReporter {
+	return &RecordingReporter{}
+}
+
+func (reporter *RecordingReporter) record(event Event) {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	reporter.events = append(reporter.events, event)
+}
+
+// Events returns the events captured, in the order they happened.
+func (reporter *RecordingReporter) Events() []Event {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	events := make([]Event, len(reporter.events))
+	copy(events, reporter.events)
+	return events
+}
+
+// Kinds returns the kinds of the events captured, in the order they happened.
+func (reporter *RecordingReporter) Kinds() []EventKind {
+	events := reporter.Events()
+	kinds := make([]EventKind, len(events))
+	for i, event := range events {
+		kinds[i] = event.Kind
+	}
+	return kinds
+}
+
+// Filter returns the events captured of the given kinds.
+func (reporter *RecordingReporter) Filter(kinds ...EventKind) []Event {
+	events := make([]Event, 0)
+	for _, event := range reporter.Events() {
+		for _, kind := range kinds {
+			if event.Kind == kind {
+				events = append(events, event)
+				break
+			}
+		}
+	}
+	return events
+}
+
+// Reset discards the events captured.
+func (reporter *RecordingReporter) Reset() {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	reporter.events = nil
+}
+
+func (reporter *RecordingReporter) BeforeMigration(summary migration.Summary, err error) {
+	reporter.record(Event{Kind: BeforeMigration, Summary: &summary, Err: err})
+}
+
+func (reporter *RecordingReporter) MigrationSummary(summary *migration.Summary, err error) {
+	reporter.record(Event{Kind: MigrationSummary, Summary: summary, Err: err})
+}
+
+func (reporter *RecordingReporter) AfterMigration(summary migration.Summary, err error) {
+	reporter.record(Event{Kind: AfterMigration, Summary: &summary, Err: err})
+}
+
+func (reporter *RecordingReporter) BeforeMigrate(migrations []migration.Migration) {
+	reporter.record(Event{Kind: BeforeMigrate, Migrations: migrations})
+}
+
+func (reporter *RecordingReporter) AfterMigrate(summaries []*migration.Summary, err error) {
+	reporter.record(Event{Kind: AfterMigrate, Summaries: summaries, Err: err})
+}
+
+func (reporter *RecordingReporter) BeforeRewind(migrations []migration.Migration) {
+	reporter.record(Event{Kind: BeforeRewind, Migrations: migrations})
+}
+
+func (reporter *RecordingReporter) AfterRewind(summaries []*migration.Summary, err error) {
+	reporter.record(Event{Kind: AfterRewind, Summaries: summaries, Err: err})
+}
+
+func (reporter *RecordingReporter) BeforeReset() {
+	reporter.record(Event{Kind: BeforeReset})
+}
+
+func (reporter *RecordingReporter) AfterReset(rewindSummaries []*migration.Summary, migrateSummaries []*migration.Summary, err error) {
+	reporter.record(Event{Kind: AfterReset, Summaries: rewindSummaries, MigrateSummaries: migrateSummaries, Err: err})
+}
+
+func (reporter *RecordingReporter) AfterBaseline(summaries []*migration.Summary, err error) {
+	reporter.record(Event{Kind: AfterBaseline, Summaries: summaries, Err: err})
+}
+
+func (reporter *RecordingReporter) MigrationMarked(summary *migration.Summary, err error) {
+	reporter.record(Event{Kind: MigrationMarked, Summary: summary, Err: err})
+}
+
+func (reporter *RecordingReporter) ListPending(migrations []migration.Migration, err error) {
+	reporter.record(Event{Kind: ListPending, Migrations: migrations, Err: err})
+}
+
+func (reporter *RecordingReporter) ListExecuted(migrations []migration.Migration, err error) {
+	reporter.record(Event{Kind: ListExecuted, Migrations: migrations, Err: err})
+}
+
+func (reporter *RecordingReporter) ListHistory(records []*migration.HistoryRecord, err error) {
+	reporter.record(Event{Kind: ListHistory, Records: records, Err: err})
+}
+
+func (reporter *RecordingReporter) ListPlan(plan *migration.Plan, err error) {
+	reporter.record(Event{Kind: ListPlan, Plan: plan, Err: err})
+}
+
+func (reporter *RecordingReporter) ListStatus(rows []*migration.StatusRow, err error) {
+	reporter.record(Event{Kind: ListStatus, Rows: rows, Err: err})
+}
+
+func (reporter *RecordingReporter) SQLScript(script string, err error) {
+	reporter.record(Event{Kind: SQLScript, Script: script, Err: err})
+}
+
+func (reporter *RecordingReporter) Failure(err error) {
+	reporter.record(Event{Kind: Failure, Err: err})
+}
+
+func (reporter *RecordingReporter) Exit(code int) {
+	reporter.record(Event{Kind: Exit, Code: code})
+}
+
+func (reporter *RecordingReporter) MigrationsStarved(migrations []migration.Migration) {
+	reporter.record(Event{Kind: MigrationsStarved, Migrations: migrations})
+}
+
+func (reporter *RecordingReporter) ChecksumMismatches(mismatches []*migration.ChecksumMismatch) {
+	reporter.record(Event{Kind: ChecksumMismatches, Mismatches: mismatches})
+}
+
+func (reporter *RecordingReporter) AfterVerify(mismatches []*migration.ChecksumMismatch, err error) {
+	reporter.record(Event{Kind: AfterVerify, Mismatches: mismatches, Err: err})
+}
+
+func (reporter *RecordingReporter) Residues(residues []*migration.Residue) {
+	reporter.record(Event{Kind: Residues, Residues: residues})
+}
+
+func (reporter *RecordingReporter) Usage() {
+	reporter.record(Event{Kind: Usage})
+}
+
+func (reporter *RecordingReporter) CommandNotFound(command string) {
+	reporter.record(Event{Kind: CommandNotFound, Command: command})
+}
+
+func (reporter *RecordingReporter) NoCommand() {
+	reporter.record(Event{Kind: NoCommand})
+}
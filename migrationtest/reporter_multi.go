@@ -0,0 +1,197 @@
+package migrationtest
+
+import (
+	"github.com/lab259/go-migration"
+)
+
+// MultiReporter is a migration.Reporter that fans every call out to several
+// reporters, in the order they were given.
+//
+// Reporters that exit the process on Exit (as the migration.DefaultReporter)
+// should be the last ones. The events of the optional reporter interfaces
+// (eg. migration.HistoryReporter) are sent only to the reporters implementing
+// them.
+type MultiReporter struct {
+	reporters []migration.Reporter
+}
+
+// NewMultiReporter returns a new instance of the migrationtest.MultiReporter.
+func NewMultiReporter(reporters ...migration.Reporter) *MultiReporter {
+	return &MultiReporter{
+		reporters: reporters,
+	}
+}
+
+func (multi *MultiReporter) BeforeMigration(summary migration.Summary, err error) {
+	for _, reporter := range multi.reporters {
+		reporter.BeforeMigration(summary, err)
+	}
+}
+
+func (multi *MultiReporter) MigrationSummary(summary *migration.Summary, err error) {
+	for _, reporter := range multi.reporters {
+		reporter.MigrationSummary(summary, err)
+	}
+}
+
+func (multi *MultiReporter) AfterMigration(summary migration.Summary, err error) {
+	for _, reporter := range multi.reporters {
+		reporter.AfterMigration(summary, err)
+	}
+}
+
+func (multi *MultiReporter) BeforeMigrate(migrations []migration.Migration) {
+	for _, reporter := range multi.reporters {
+		reporter.BeforeMigrate(migrations)
+	}
+}
+
+func (multi *MultiReporter) AfterMigrate(summaries []*migration.Summary, err error) {
+	for _, reporter := range multi.reporters {
+		reporter.AfterMigrate(summaries, err)
+	}
+}
+
+func (multi *MultiReporter) BeforeRewind(migrations []migration.Migration) {
+	for _, reporter := range multi.reporters {
+		reporter.BeforeRewind(migrations)
+	}
+}
+
+func (multi *MultiReporter) AfterRewind(summaries []*migration.Summary, err error) {
+	for _, reporter := range multi.reporters {
+		reporter.AfterRewind(summaries, err)
+	}
+}
+
+func (multi *MultiReporter) BeforeReset() {
+	for _, reporter := range multi.reporters {
+		reporter.BeforeReset()
+	}
+}
+
+func (multi *MultiReporter) AfterReset(rewindSummaries []*migration.Summary, migrateSummaries []*migration.Summary, err error) {
+	for _, reporter := range multi.reporters {
+		reporter.AfterReset(rewindSummaries, migrateSummaries, err)
+	}
+}
+
+func (multi *MultiReporter) AfterBaseline(summaries []*migration.Summary, err error) {
+	for _, reporter := range multi.reporters {
+		if r, ok := reporter.(migration.BaselineReporter); ok {
+			r.AfterBaseline(summaries, err)
+		}
+	}
+}
+
+func (multi *MultiReporter) MigrationMarked(summary *migration.Summary, err error) {
+	for _, reporter := range multi.reporters {
+		if r, ok := reporter.(migration.MarkReporter); ok {
+			r.MigrationMarked(summary, err)
+		}
+	}
+}
+
+func (multi *MultiReporter) ListPending(migrations []migration.Migration, err error) {
+	for _, reporter := range multi.reporters {
+		reporter.ListPending(migrations, err)
+	}
+}
+
+func (multi *MultiReporter) ListExecuted(migrations []migration.Migration, err error) {
+	for _, reporter := range multi.reporters {
+		reporter.ListExecuted(migrations, err)
+	}
+}
+
+func (multi *MultiReporter) ListHistory(records []*migration.HistoryRecord, err error) {
+	for _, reporter := range multi.reporters {
+		if r, ok := reporter.(migration.HistoryReporter); ok {
+			r.ListHistory(records, err)
+		}
+	}
+}
+
+func (multi *MultiReporter) ListPlan(plan *migration.Plan, err error) {
+	for _, reporter := range multi.reporters {
+		if r, ok := reporter.(migration.PlanReporter); ok {
+			r.ListPlan(plan, err)
+		}
+	}
+}
+
+func (multi *MultiReporter) ListStatus(rows []*migration.StatusRow, err error) {
+	for _, reporter := range multi.reporters {
+		if r, ok := reporter.(migration.StatusReporter); ok {
+			r.ListStatus(rows, err)
+		}
+	}
+}
+
+func (multi *MultiReporter) SQLScript(script string, err error) {
+	for _, reporter := range multi.reporters {
+		if r, ok := reporter.(migration.SQLReporter); ok {
+			r.SQLScript(script, err)
+		}
+	}
+}
+
+func (multi *MultiReporter) Failure(err error) {
+	for _, reporter := range multi.reporters {
+		reporter.Failure(err)
+	}
+}
+
+func (multi *MultiReporter) Exit(code int) {
+	for _, reporter := range multi.reporters {
+		reporter.Exit(code)
+	}
+}
+
+func (multi *MultiReporter) MigrationsStarved(migrations []migration.Migration) {
+	for _, reporter := range multi.reporters {
+		reporter.MigrationsStarved(migrations)
+	}
+}
+
+func (multi *MultiReporter) ChecksumMismatches(mismatches []*migration.ChecksumMismatch) {
+	for _, reporter := range multi.reporters {
+		if r, ok := reporter.(migration.VerifyReporter); ok {
+			r.ChecksumMismatches(mismatches)
+		}
+	}
+}
+
+func (multi *MultiReporter) AfterVerify(mismatches []*migration.ChecksumMismatch, err error) {
+	for _, reporter := range multi.reporters {
+		if r, ok := reporter.(migration.VerifyReporter); ok {
+			r.AfterVerify(mismatches, err)
+		}
+	}
+}
+
+func (multi *MultiReporter) Residues(residues []*migration.Residue) {
+	for _, reporter := range multi.reporters {
+		if r, ok := reporter.(migration.ResidueReporter); ok {
+			r.Residues(residues)
+		}
+	}
+}
+
+func (multi *MultiReporter) Usage() {
+	for _, reporter := range multi.reporters {
+		reporter.Usage()
+	}
+}
+
+func (multi *MultiReporter) CommandNotFound(command string) {
+	for _, reporter := range multi.reporters {
+		reporter.CommandNotFound(command)
+	}
+}
+
+func (multi *MultiReporter) NoCommand() {
+	for _, reporter := range multi.reporters {
+		reporter.NoCommand()
+	}
+}
@@ -0,0 +1,115 @@
+package migrationtest_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lab259/go-migration"
+	"github.com/lab259/go-migration/migrationtest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RecordingReporter", func() {
+	var (
+		m1, m2 *migration.DefaultMigration
+		source *migration.CodeSource
+	)
+
+	BeforeEach(func() {
+		baseTime := time.Date(2000, 0, 0, 0, 0, 0, 0, time.UTC)
+
+		m1 = migration.NewMigration(baseTime, "Migration 1", nopHandler, nopHandler)
+		m2 = migration.NewMigration(baseTime.Add(time.Second), "Migration 2", nopHandler, nopHandler)
+
+		source = migration.NewCodeSource()
+		source.Register(m1)
+		source.Register(m2)
+	})
+
+	It("should record the events of a migrate", func() {
+		reporter := migrationtest.NewRecordingReporter()
+		runner := migration.NewArgsRunnerCustom(reporter, migration.NewManager(migrationtest.NewMemoryTarget(), source), func(int) {}, "migrate")
+
+		runner.Run(nil)
+
+		Expect(reporter.Kinds()).To(Equal([]migrationtest.EventKind{
+			migrationtest.BeforeMigrate,
+			migrationtest.BeforeMigration,
+			migrationtest.AfterMigration,
+			migrationtest.BeforeMigration,
+			migrationtest.AfterMigration,
+			migrationtest.AfterMigrate,
+		}))
+		Expect(reporter).To(migrationtest.HaveEmittedInOrder(
+			migrationtest.EmittedWith(migrationtest.BeforeMigrate, 2),
+			migrationtest.EmittedFor(migrationtest.BeforeMigration, m1.GetID()),
+			migrationtest.EmittedFor(migrationtest.AfterMigration, m1.GetID()).WithErr(nil),
+			migrationtest.EmittedFor(migrationtest.BeforeMigration, m2.GetID()),
+			migrationtest.EmittedFor(migrationtest.AfterMigration, m2.GetID()).WithErr(nil),
+			migrationtest.EmittedWith(migrationtest.AfterMigrate, 2).WithErr(nil),
+		))
+		Expect(reporter.Filter(migrationtest.AfterMigration)).To(HaveLen(2))
+	})
+
+	It("should record the failures", func() {
+		forcedErr := errors.New("forced error")
+		source.Register(migration.NewMigration(m2.GetID().Add(time.Second), "Migration 3", func(executionContext interface{}) error {
+			return forcedErr
+		}))
+		reporter := migrationtest.NewRecordingReporter()
+		manager := migration.NewManager(migrationtest.NewMemoryTarget(), source)
+
+		summaries, err := manager.Migrate(reporter, nil)
+		reporter.AfterMigrate(summaries, err)
+
+		Expect(reporter).To(migrationtest.HaveEmittedInOrder(
+			migrationtest.Emitted(migrationtest.AfterMigration).WithErr(forcedErr),
+			migrationtest.EmittedWith(migrationtest.AfterMigrate, 3).WithErr(forcedErr),
+		))
+	})
+
+	It("should describe the event missing", func() {
+		reporter := migrationtest.NewRecordingReporter()
+		reporter.BeforeReset()
+		reporter.Exit(1)
+
+		err := migrationtest.InOrder(reporter.Events(),
+			migrationtest.Emitted(migrationtest.BeforeReset),
+			migrationtest.Emitted(migrationtest.AfterReset),
+		)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(HavePrefix("expected AfterReset after BeforeReset in the events"))
+
+		Expect(migrationtest.InOrder(reporter.Events(), migrationtest.Emitted(migrationtest.Exit))).To(Succeed())
+		Expect(reporter.Events()[1].Code).To(Equal(1))
+	})
+
+	It("should discard the events", func() {
+		reporter := migrationtest.NewRecordingReporter()
+		reporter.Usage()
+		reporter.Reset()
+		Expect(reporter.Events()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("MultiReporter", func() {
+	It("should fan the events out to all reporters", func() {
+		r1 := migrationtest.NewRecordingReporter()
+		r2 := migrationtest.NewRecordingReporter()
+		reporter := migrationtest.NewMultiReporter(r1, r2)
+
+		reporter.BeforeReset()
+		reporter.CommandNotFound("unknown")
+		reporter.Exit(1)
+
+		for _, r := range []*migrationtest.RecordingReporter{r1, r2} {
+			Expect(r.Kinds()).To(Equal([]migrationtest.EventKind{
+				migrationtest.BeforeReset,
+				migrationtest.CommandNotFound,
+				migrationtest.Exit,
+			}))
+			Expect(r.Events()[1].Command).To(Equal("unknown"))
+		}
+	})
+})
@@ -0,0 +1,40 @@
+package migration
+
+// OutOfOrderPolicy defines how the migration.ManagerDefault handles starved
+// migrations: pending migrations older than the current version of the
+// target. They usually show up when branches with interleaved migrations are
+// merged.
+//
+// Whatever the policy is, the starved migrations are reported through the
+// migration.Reporter.MigrationsStarved.
+type OutOfOrderPolicy int
+
+const (
+	// OutOfOrderFail fails with migration.ErrMigrationStarved, without
+	// executing any migration, when there are starved migrations. This is the
+	// default policy.
+	OutOfOrderFail OutOfOrderPolicy = iota
+
+	// OutOfOrderWarn reports the starved migrations and applies them along
+	// with the other pending migrations, in the order of their IDs.
+	OutOfOrderWarn
+
+	// OutOfOrderExplicit applies the starved migrations only if all of them
+	// were allowed with migration.WithAllowOutOfOrder. Otherwise, it fails with
+	// migration.ErrMigrationStarved.
+	OutOfOrderExplicit
+)
+
+// String returns the name of the policy.
+func (policy OutOfOrderPolicy) String() string {
+	switch policy {
+	case OutOfOrderFail:
+		return "fail"
+	case OutOfOrderWarn:
+		return "warn"
+	case OutOfOrderExplicit:
+		return "explicit"
+	default:
+		return "unknown"
+	}
+}
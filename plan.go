@@ -0,0 +1,35 @@
+package migration
+
+// PlanStep is a step of a migration.Plan: a migration and the direction it
+// would be executed.
+type PlanStep struct {
+	Migration Migration
+	Direction Direction
+}
+
+// Plan lists, in order, the steps a command of the migration.Manager would
+// take, without executing anything.
+type Plan struct {
+	// Command is the name of the command planned (eg. migrate or rewind-to).
+	Command string
+
+	Steps []*PlanStep
+
+	// Starved are the pending migrations older than the current version of
+	// the target. While there are starved migrations, the command fails with
+	// migration.ErrMigrationStarved and no step is taken.
+	Starved []Migration
+
+	// OutOfOrder are the pending migrations older than the current version of
+	// the target that the migration.OutOfOrderPolicy allows to be applied.
+	OutOfOrder []Migration
+}
+
+func (plan *Plan) add(direction Direction, migrations ...Migration) {
+	for _, m := range migrations {
+		plan.Steps = append(plan.Steps, &PlanStep{
+			Migration: m,
+			Direction: direction,
+		})
+	}
+}
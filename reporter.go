@@ -4,6 +4,10 @@ package migration
 // Before executing any migration (in any direction) the `Manager` calls the
 // listener `Before`. Afterward, if it does not return any error, the listener's
 // `After` is called.
+//
+// The events of the newer commands are reported through the optional
+// interfaces below (eg. migration.VerifyReporter). A reporter that does not
+// implement one of them cannot be used with the commands that need it.
 type Reporter interface {
 	BeforeMigration(migration Summary, err error)
 	MigrationSummary(migration *Summary, err error)
@@ -30,3 +34,46 @@ type Reporter interface {
 	CommandNotFound(command string)
 	NoCommand()
 }
+
+// VerifyReporter is implemented by the reporters of the `verify` command.
+type VerifyReporter interface {
+	ChecksumMismatches(mismatches []*ChecksumMismatch)
+	AfterVerify(mismatches []*ChecksumMismatch, err error)
+}
+
+// HistoryReporter is implemented by the reporters of the `history` command.
+type HistoryReporter interface {
+	ListHistory(records []*HistoryRecord, err error)
+}
+
+// ResidueReporter is implemented by the reporters notified of the residues
+// found by migration.ManagerDefault.VerifyReversible.
+type ResidueReporter interface {
+	Residues(residues []*Residue)
+}
+
+// PlanReporter is implemented by the reporters of the `plan` command.
+type PlanReporter interface {
+	ListPlan(plan *Plan, err error)
+}
+
+// SQLReporter is implemented by the reporters of the `sql` command.
+type SQLReporter interface {
+	SQLScript(script string, err error)
+}
+
+// BaselineReporter is implemented by the reporters of the `baseline` command.
+type BaselineReporter interface {
+	AfterBaseline(migrations []*Summary, err error)
+}
+
+// MarkReporter is implemented by the reporters of the `mark-applied` and
+// `mark-reverted` commands.
+type MarkReporter interface {
+	MigrationMarked(migration *Summary, err error)
+}
+
+// StatusReporter is implemented by the reporters of the `status` command.
+type StatusReporter interface {
+	ListStatus(rows []*StatusRow, err error)
+}
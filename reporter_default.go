@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 )
 
 // DefaultReporter is the default implementation of a Reporter.
@@ -51,7 +52,7 @@ func (reporter *DefaultReporter) BeforeMigration(summary Summary, err error) {
 	} else {
 		reporter.print(styleNormal("  Rewinding ["))
 	}
-	reporter.print(styleMigrationID(summary.Migration.GetID().Format(migrationIDFormat)))
+	reporter.print(styleMigrationID(MigrationLabel(summary.Migration)))
 	reporter.print(styleNormal("] "))
 	reporter.print(styleMigrationTitle(summary.Migration.GetDescription()))
 	reporter.print(styleNormal("... "))
@@ -99,6 +100,7 @@ func (reporter *DefaultReporter) AfterMigrate(migrations []*Summary, err error)
 	if err != nil {
 		reporter.Failure(err)
 		reporter.Exit(11)
+		return
 	}
 	executed := 0
 	failed := 0
@@ -126,6 +128,11 @@ func (reporter *DefaultReporter) BeforeRewind(migrations []Migration) {
 
 // AfterRewind is called right after the process of rewinding is completed.
 func (reporter *DefaultReporter) AfterRewind(migrations []*Summary, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
 	executed := 0
 	failed := 0
 	for _, m := range migrations {
@@ -147,10 +154,61 @@ func (reporter *DefaultReporter) BeforeReset() {
 }
 
 // AfterReset is called right before the process of reseting is completed.
+//
+// The error, if any, is reported only once, instead of by both the
+// AfterRewind and the AfterMigrate.
 func (reporter *DefaultReporter) AfterReset(rewindSummary []*Summary, migrateSummary []*Summary, err error) {
 	reporter.printLn()
-	reporter.AfterRewind(rewindSummary, err)
-	reporter.AfterMigrate(migrateSummary, err)
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+	} else {
+		reporter.AfterRewind(rewindSummary, nil)
+		reporter.AfterMigrate(migrateSummary, nil)
+	}
+	reporter.printLn()
+}
+
+// AfterBaseline is called right after the migrations are marked as executed
+// by the baseline.
+func (reporter *DefaultReporter) AfterBaseline(migrations []*Summary, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	if len(migrations) == 0 {
+		reporter.printLn(styleWarning("  Nothing to baseline."))
+		reporter.printLn()
+		return
+	}
+	for i, m := range migrations {
+		reporter.print(styleNormal(fmt.Sprintf("  %d) [", i+1)))
+		reporter.print(styleMigrationID(m.Migration.GetID().Format(migrationIDFormat)))
+		reporter.print(styleNormal("] "))
+		reporter.printLn(styleMigrationTitle(m.Migration.GetDescription()))
+	}
+	reporter.printLn(fmt.Sprintf("  %s migrations were baselined", styleSuccess(fmt.Sprintf("%d", len(migrations)))))
+	reporter.printLn()
+}
+
+// MigrationMarked is called right after a migration is marked as applied or
+// as reverted, without running it.
+func (reporter *DefaultReporter) MigrationMarked(migration *Summary, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	status := "applied"
+	if migration.Direction() == DirectionUndo {
+		status = "reverted"
+	}
+	reporter.print(styleNormal("  Marked ["))
+	reporter.print(styleMigrationID(migration.Migration.GetID().Format(migrationIDFormat)))
+	reporter.print(styleNormal("] "))
+	reporter.print(styleMigrationTitle(migration.Migration.GetDescription()))
+	reporter.printLn(styleWarning(" as " + status))
 	reporter.printLn()
 }
 
@@ -212,17 +270,147 @@ func (reporter *DefaultReporter) ListExecuted(migrations []Migration, err error)
 	reporter.printLn()
 }
 
+// ListHistory reports the records of the applied and reverted migrations and of
+// the failed attempts.
+func (reporter *DefaultReporter) ListHistory(records []*HistoryRecord, err error) {
+	if err != nil {
+		reporter.printLn(styleError(err.Error()))
+		return
+	}
+	if len(records) == 0 {
+		reporter.printLn(styleWarning("  No history recorded."))
+		reporter.printLn()
+		return
+	}
+	reporter.printLn(fmt.Sprintf("  %d history records:", len(records)))
+	for i, record := range records {
+		reporter.print(styleNormal(fmt.Sprintf("  %d) [", i+1)))
+		reporter.print(styleMigrationID(record.ID.Format(migrationIDFormat)))
+		reporter.print(styleNormal("] "))
+		reporter.print(styleMigrationTitle(record.Description))
+		reporter.print(styleNormal(fmt.Sprintf(" %s at %s", record.Direction, record.AppliedAt.Format(time.RFC3339))))
+		reporter.print(styleDuration(fmt.Sprintf(" (%dms)", record.Duration.Nanoseconds()/1000000)))
+		reporter.print(styleNormal(fmt.Sprintf(" by %s@%s (%s) ", record.User, record.Hostname, record.ToolVersion)))
+		if record.Failed {
+			reporter.printLn(styleError("Failed: " + record.Failure))
+		} else if record.Baselined {
+			reporter.printLn(styleWarning("Baselined"))
+		} else {
+			reporter.printLn(styleSuccess("Ok"))
+		}
+	}
+	reporter.printLn()
+}
+
+// ListStatus reports the state of each migration as a table. If any of them
+// needs attention (see migration.StatusRow.Drifted), it exits with an error
+// code.
+func (reporter *DefaultReporter) ListStatus(rows []*StatusRow, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	if len(rows) == 0 {
+		reporter.printLn(styleWarning("  No migrations found."))
+		reporter.printLn()
+		return
+	}
+	drifted := 0
+	reporter.printLn(styleBold(fmt.Sprintf("  %-14s  %-17s  %s", "ID", "STATE", "DESCRIPTION")))
+	for _, row := range rows {
+		state := fmt.Sprintf("%-17s", row.State)
+		switch row.State {
+		case StateApplied:
+			state = styleSuccess(state)
+		case StatePending:
+			state = styleWarning(state)
+		default:
+			state = styleError(state)
+			drifted++
+		}
+		reporter.print(styleNormal("  "))
+		reporter.print(styleMigrationID(row.ID.Format(migrationIDFormat)))
+		reporter.print(styleNormal("  "), state, styleNormal("  "))
+		reporter.printLn(styleMigrationTitle(row.Description()))
+		if row.State == StateChecksumMismatch {
+			reporter.printLn(styleNormal(fmt.Sprintf("     expected %s, got %s", row.Expected, row.Actual)))
+		}
+	}
+	reporter.printLn()
+	if drifted > 0 {
+		reporter.printLn(styleError(fmt.Sprintf("  %d migrations need attention.", drifted)))
+		reporter.printLn()
+		reporter.Exit(10)
+	}
+}
+
+// ListPlan reports the steps a command would take. If there are starved
+// migrations, the command would fail, so it exits with an error code.
+func (reporter *DefaultReporter) ListPlan(plan *Plan, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	if len(plan.Steps) == 0 {
+		reporter.printLn(styleWarning(fmt.Sprintf("  Nothing to %s.", plan.Command)))
+	} else {
+		reporter.printLn(fmt.Sprintf("  %s would take %d steps:", plan.Command, len(plan.Steps)))
+		for i, step := range plan.Steps {
+			reporter.print(styleNormal(fmt.Sprintf("  %d) %-4s [", i+1, step.Direction)))
+			reporter.print(styleMigrationID(MigrationLabel(step.Migration)))
+			reporter.print(styleNormal("] "))
+			reporter.printLn(styleMigrationTitle(step.Migration.GetDescription()))
+		}
+	}
+	if len(plan.OutOfOrder) > 0 {
+		reporter.printLn(styleWarning(fmt.Sprintf("  %d migrations would be applied out of order.", len(plan.OutOfOrder))))
+	}
+	if len(plan.Starved) > 0 {
+		reporter.printLn()
+		reporter.MigrationsStarved(plan.Starved)
+		reporter.printLn(styleError(fmt.Sprintf("  %s would fail: %s", plan.Command, ErrMigrationStarved)))
+		reporter.printLn()
+		reporter.Exit(10)
+		return
+	}
+	reporter.printLn()
+}
+
+// SQLScript writes the SQL script of a command as it is, so the output can be
+// redirected to a file.
+func (reporter *DefaultReporter) SQLScript(script string, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	reporter.print(script)
+}
+
 // Usage prints the usage of the migration command.
 func (reporter *DefaultReporter) Usage() {
-	reporter.printLn("Usage:", os.Args[0], "[migrate | rewind | do | undo | executed | pending]")
+	reporter.printLn("Usage:", os.Args[0], "[migrate | migrate-to <id> | rewind | rewind-to <id> | do [n] | undo [n] | redo | executed | pending | history | status | verify | plan <command> | sql <command> | baseline <id> | mark-applied <id> --force | mark-reverted <id> --force]")
 	reporter.printLn()
 	line := "  %18s  %s"
 	reporter.printLn(fmt.Sprintf(line, styleBold("migrate"), "Apply all pending migrations"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("migrate-to <id>"), "Apply the pending migrations up to <id>"))
 	reporter.printLn(fmt.Sprintf(line, styleBold("rewind"), "Rewind all executed migrations"))
-	reporter.printLn(fmt.Sprintf(line, styleBold("do"), "Execute the next pending migration"))
-	reporter.printLn(fmt.Sprintf(line, styleBold("undo"), "Execute the last applied migration"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("rewind-to <id>"), "Rewind the executed migrations newer than <id>"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("do [n]"), "Execute the next n (default 1) pending migrations"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("undo [n]"), "Undo the last n (default 1) applied migrations"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("redo"), "Undo the last applied migration and execute it again"))
 	reporter.printLn(fmt.Sprintf(line, styleBold("executed"), "List all executed migrations"))
 	reporter.printLn(fmt.Sprintf(line, styleBold("pending"), "List all pending migrations"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("history"), "List the applied and reverted migrations and the failed attempts"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("status"), "List the state of each migration, including the ones missing from the source"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("verify"), "Check the executed migrations were not changed"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("plan <command>"), "Show the steps of migrate, migrate-to, rewind, rewind-to or reset without executing them"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("sql <command>"), "Print the SQL script of migrate, migrate-to, rewind, rewind-to or reset without executing it"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("baseline <id>"), "Mark the migrations up to <id> as executed, without running them"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("mark-applied <id>"), "Record <id> as executed, without running it (requires --force)"))
+	reporter.printLn(fmt.Sprintf(line, styleBold("mark-reverted <id>"), "Remove <id> from the executed migrations, without undoing it (requires --force)"))
 	reporter.printLn()
 }
 
@@ -248,3 +436,51 @@ func (reporter *DefaultReporter) MigrationsStarved(migrations []Migration) {
 		reporter.printLn(styleMigrationTitle(m.GetDescription()))
 	}
 }
+
+// ChecksumMismatches is called whenever the manager detects executed
+// migrations that were changed after being applied.
+func (reporter *DefaultReporter) ChecksumMismatches(mismatches []*ChecksumMismatch) {
+	reporter.printLn(styleError(fmt.Sprintf("Checksum mismatch detected in %d migrations", len(mismatches))))
+	for i, mismatch := range mismatches {
+		reporter.print(styleNormal(fmt.Sprintf("  %d) [", i+1)))
+		reporter.print(styleMigrationID(mismatch.Migration.GetID().Format(migrationIDFormat)))
+		reporter.print(styleNormal("] "))
+		reporter.printLn(styleMigrationTitle(mismatch.Migration.GetDescription()))
+		reporter.printLn(styleNormal(fmt.Sprintf("     expected %s, got %s", mismatch.Expected, mismatch.Actual)))
+	}
+}
+
+// Residues is called whenever the manager detects migrations whose undo does
+// not reverse their do.
+func (reporter *DefaultReporter) Residues(residues []*Residue) {
+	reporter.printLn(styleError(fmt.Sprintf("Residue detected in %d migrations", len(residues))))
+	for i, residue := range residues {
+		reporter.print(styleNormal(fmt.Sprintf("  %d) [", i+1)))
+		reporter.print(styleMigrationID(residue.Migration.GetID().Format(migrationIDFormat)))
+		reporter.print(styleNormal("] "))
+		reporter.printLn(styleMigrationTitle(residue.Migration.GetDescription()))
+		for _, entry := range residue.Added {
+			reporter.printLn(styleNormal("     + " + entry))
+		}
+		for _, entry := range residue.Removed {
+			reporter.printLn(styleNormal("     - " + entry))
+		}
+	}
+}
+
+// AfterVerify is called right after the checksums of the executed migrations
+// are verified.
+func (reporter *DefaultReporter) AfterVerify(mismatches []*ChecksumMismatch, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	if len(mismatches) > 0 {
+		reporter.printLn()
+		reporter.Exit(10)
+		return
+	}
+	reporter.printLn(styleSuccess("  All executed migrations match their checksums."))
+	reporter.printLn()
+}
@@ -0,0 +1,46 @@
+package migration_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/lab259/go-migration"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DefaultReporter", func() {
+	var (
+		output   *bytes.Buffer
+		exits    []int
+		reporter *migration.DefaultReporter
+	)
+
+	BeforeEach(func() {
+		output = bytes.NewBuffer(nil)
+		exits = nil
+		reporter = migration.NewDefaultReporterWithParams(output, func(code int) {
+			exits = append(exits, code)
+		})
+	})
+
+	It("should exit once when the rewind fails", func() {
+		reporter.AfterRewind(nil, errors.New("forced error"))
+		Expect(exits).To(Equal([]int{11}))
+		Expect(output.String()).ToNot(ContainSubstring("rewinded"))
+	})
+
+	It("should report the failure of the reset once", func() {
+		reporter.AfterReset(nil, nil, errors.New("forced error"))
+		Expect(exits).To(Equal([]int{11}))
+		Expect(strings.Count(output.String(), "forced error")).To(Equal(1))
+	})
+
+	It("should report the migrations of the reset", func() {
+		reporter.AfterReset(nil, nil, nil)
+		Expect(exits).To(BeEmpty())
+		Expect(output.String()).To(ContainSubstring("rewinded"))
+		Expect(output.String()).To(ContainSubstring("applied"))
+	})
+})
@@ -0,0 +1,473 @@
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// JSONReporter is a Reporter that writes newline-delimited JSON events,
+// meant to be parsed by deploy pipelines. Each event is an object with the
+// name of the event in the `event` field (eg. `migration_finished`).
+//
+// It exits with the same codes as the migration.DefaultReporter: 11 when a
+// command fails and 10 when migrations fail or drift. As on the
+// migration.DefaultReporter, MigrationSummary does not exit: the errors of the
+// `do`, `undo` and `redo` commands are reported by the migration.ArgsRunner,
+// through Failure and Exit.
+type JSONReporter struct {
+	encoder *json.Encoder
+	exitFnc func(code int)
+}
+
+// NewJSONReporter returns an instance of a JSONReporter that writes to the
+// standard output.
+func NewJSONReporter() *JSONReporter {
+	return NewJSONReporterWithParams(os.Stdout, os.Exit)
+}
+
+// NewJSONReporterWithParams initializes an instance of a JSONReporter with its
+// params.
+func NewJSONReporterWithParams(w io.Writer, exitFnc func(code int)) *JSONReporter {
+	return &JSONReporter{
+		encoder: json.NewEncoder(w),
+		exitFnc: exitFnc,
+	}
+}
+
+// jsonMigration identifies a migration in the events.
+type jsonMigration struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+}
+
+func newJSONMigration(m Migration) jsonMigration {
+	return jsonMigration{
+		ID:          MigrationLabel(m),
+		Description: m.GetDescription(),
+	}
+}
+
+func newJSONMigrations(migrations []Migration) []jsonMigration {
+	result := make([]jsonMigration, len(migrations))
+	for i, m := range migrations {
+		result[i] = newJSONMigration(m)
+	}
+	return result
+}
+
+// jsonSummary is the outcome of running a migration.
+type jsonSummary struct {
+	jsonMigration
+	Direction  string `json:"direction"`
+	DurationMs int64  `json:"duration_ms"`
+	Failed     bool   `json:"failed"`
+	Panicked   bool   `json:"panicked"`
+	Error      string `json:"error,omitempty"`
+}
+
+func newJSONSummary(summary *Summary) *jsonSummary {
+	if summary == nil {
+		return nil
+	}
+	result := &jsonSummary{
+		jsonMigration: newJSONMigration(summary.Migration),
+		Direction:     summary.Direction().String(),
+		DurationMs:    int64(summary.Duration() / time.Millisecond),
+		Failed:        summary.Failed(),
+		Panicked:      summary.Panicked(),
+	}
+	if summary.Panicked() {
+		result.Error = fmt.Sprintf("panic: %v", summary.PanicData())
+	} else if summary.Failed() {
+		result.Error = summary.Failure().Error()
+	}
+	return result
+}
+
+// jsonRun is the outcome of a command that runs many migrations.
+type jsonRun struct {
+	Executed   int            `json:"executed"`
+	Failed     int            `json:"failed"`
+	Migrations []*jsonSummary `json:"migrations"`
+}
+
+func newJSONRun(summaries []*Summary) *jsonRun {
+	run := &jsonRun{
+		Migrations: make([]*jsonSummary, len(summaries)),
+	}
+	for i, summary := range summaries {
+		if summary.Failed() || summary.Panicked() {
+			run.Failed++
+		} else {
+			run.Executed++
+		}
+		run.Migrations[i] = newJSONSummary(summary)
+	}
+	return run
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (reporter *JSONReporter) emit(event interface{}) {
+	reporter.encoder.Encode(event)
+}
+
+// Failure reports a failure as a `failure` event.
+func (reporter *JSONReporter) Failure(err error) {
+	reporter.emit(struct {
+		Event string `json:"event"`
+		Error string `json:"error"`
+	}{"failure", errorString(err)})
+}
+
+// Exit calls the exit function of the reporter.
+func (reporter *JSONReporter) Exit(code int) {
+	reporter.exitFnc(code)
+}
+
+// BeforeMigration emits a `migration_started` event.
+func (reporter *JSONReporter) BeforeMigration(summary Summary, err error) {
+	reporter.emit(struct {
+		Event string `json:"event"`
+		jsonMigration
+		Direction string `json:"direction"`
+	}{"migration_started", newJSONMigration(summary.Migration), summary.Direction().String()})
+}
+
+// AfterMigration emits a `migration_finished` event.
+func (reporter *JSONReporter) AfterMigration(summary Summary, err error) {
+	reporter.emit(struct {
+		Event string `json:"event"`
+		*jsonSummary
+	}{"migration_finished", newJSONSummary(&summary)})
+}
+
+// MigrationSummary emits a `migration_summary` event. The migration is null
+// when there was nothing to be done.
+func (reporter *JSONReporter) MigrationSummary(summary *Summary, err error) {
+	reporter.emit(struct {
+		Event     string       `json:"event"`
+		Migration *jsonSummary `json:"migration"`
+		Error     string       `json:"error,omitempty"`
+	}{"migration_summary", newJSONSummary(summary), errorString(err)})
+}
+
+func (reporter *JSONReporter) started(event string, migrations []Migration) {
+	reporter.emit(struct {
+		Event      string          `json:"event"`
+		Migrations []jsonMigration `json:"migrations"`
+	}{event, newJSONMigrations(migrations)})
+}
+
+// finished emits the event of a command that runs many migrations, exiting
+// with an error code if it failed.
+func (reporter *JSONReporter) finished(event string, summaries []*Summary, err error) {
+	run := newJSONRun(summaries)
+	reporter.emit(struct {
+		Event string `json:"event"`
+		*jsonRun
+		Error string `json:"error,omitempty"`
+	}{event, run, errorString(err)})
+	reporter.exitRun(err, run.Failed)
+}
+
+func (reporter *JSONReporter) exitRun(err error, failed int) {
+	if err != nil {
+		reporter.Exit(11)
+	} else if failed > 0 {
+		reporter.Exit(10)
+	}
+}
+
+// BeforeMigrate emits a `migrate_started` event with the pending migrations.
+func (reporter *JSONReporter) BeforeMigrate(migrations []Migration) {
+	reporter.started("migrate_started", migrations)
+}
+
+// AfterMigrate emits a `migrate_finished` event with the summaries.
+func (reporter *JSONReporter) AfterMigrate(migrations []*Summary, err error) {
+	reporter.finished("migrate_finished", migrations, err)
+}
+
+// BeforeRewind emits a `rewind_started` event with the migrations to rewind.
+func (reporter *JSONReporter) BeforeRewind(migrations []Migration) {
+	reporter.started("rewind_started", migrations)
+}
+
+// AfterRewind emits a `rewind_finished` event with the summaries.
+func (reporter *JSONReporter) AfterRewind(migrations []*Summary, err error) {
+	reporter.finished("rewind_finished", migrations, err)
+}
+
+// BeforeReset emits a `reset_started` event.
+func (reporter *JSONReporter) BeforeReset() {
+	reporter.emit(struct {
+		Event string `json:"event"`
+	}{"reset_started"})
+}
+
+// AfterReset emits a `reset_finished` event with the summaries of the rewind
+// and of the migrate.
+func (reporter *JSONReporter) AfterReset(rewindSummary []*Summary, migrateSummary []*Summary, err error) {
+	rewind, migrate := newJSONRun(rewindSummary), newJSONRun(migrateSummary)
+	reporter.emit(struct {
+		Event   string   `json:"event"`
+		Rewind  *jsonRun `json:"rewind"`
+		Migrate *jsonRun `json:"migrate"`
+		Error   string   `json:"error,omitempty"`
+	}{"reset_finished", rewind, migrate, errorString(err)})
+	reporter.exitRun(err, rewind.Failed+migrate.Failed)
+}
+
+// AfterBaseline emits a `baseline_finished` event with the migrations
+// baselined.
+func (reporter *JSONReporter) AfterBaseline(migrations []*Summary, err error) {
+	reporter.finished("baseline_finished", migrations, err)
+}
+
+// MigrationMarked emits a `migration_marked` event. The direction is `do` for
+// migrations marked as applied and `undo` for the ones marked as reverted.
+func (reporter *JSONReporter) MigrationMarked(migration *Summary, err error) {
+	reporter.emit(struct {
+		Event     string       `json:"event"`
+		Migration *jsonSummary `json:"migration"`
+		Error     string       `json:"error,omitempty"`
+	}{"migration_marked", newJSONSummary(migration), errorString(err)})
+	if err != nil {
+		reporter.Exit(11)
+	}
+}
+
+func (reporter *JSONReporter) list(event string, migrations []Migration, err error) {
+	reporter.emit(struct {
+		Event      string          `json:"event"`
+		Migrations []jsonMigration `json:"migrations"`
+		Error      string          `json:"error,omitempty"`
+	}{event, newJSONMigrations(migrations), errorString(err)})
+}
+
+// ListPending emits a `pending` event with the migrations pending.
+func (reporter *JSONReporter) ListPending(migrations []Migration, err error) {
+	reporter.list("pending", migrations, err)
+}
+
+// ListExecuted emits an `executed` event with the migrations executed.
+func (reporter *JSONReporter) ListExecuted(migrations []Migration, err error) {
+	reporter.list("executed", migrations, err)
+}
+
+// ListHistory emits a `history` event with the history records.
+func (reporter *JSONReporter) ListHistory(records []*HistoryRecord, err error) {
+	type jsonRecord struct {
+		ID          string    `json:"id"`
+		Description string    `json:"description"`
+		Direction   string    `json:"direction"`
+		AppliedAt   time.Time `json:"applied_at"`
+		DurationMs  int64     `json:"duration_ms"`
+		Checksum    string    `json:"checksum,omitempty"`
+		Hostname    string    `json:"hostname"`
+		User        string    `json:"user"`
+		ToolVersion string    `json:"tool_version"`
+		Failed      bool      `json:"failed"`
+		Failure     string    `json:"failure,omitempty"`
+		Baselined   bool      `json:"baselined"`
+	}
+	result := make([]jsonRecord, len(records))
+	for i, record := range records {
+		result[i] = jsonRecord{
+			ID:          record.ID.Format(migrationIDFormat),
+			Description: record.Description,
+			Direction:   record.Direction.String(),
+			AppliedAt:   record.AppliedAt,
+			DurationMs:  int64(record.Duration / time.Millisecond),
+			Checksum:    record.Checksum,
+			Hostname:    record.Hostname,
+			User:        record.User,
+			ToolVersion: record.ToolVersion,
+			Failed:      record.Failed,
+			Failure:     record.Failure,
+			Baselined:   record.Baselined,
+		}
+	}
+	reporter.emit(struct {
+		Event   string       `json:"event"`
+		Records []jsonRecord `json:"records"`
+		Error   string       `json:"error,omitempty"`
+	}{"history", result, errorString(err)})
+}
+
+// ListPlan emits a `plan` event with the steps of the command. As the
+// migration.DefaultReporter, it exits with an error code when the command
+// would fail.
+func (reporter *JSONReporter) ListPlan(plan *Plan, err error) {
+	type jsonStep struct {
+		jsonMigration
+		Direction string `json:"direction"`
+	}
+	event := struct {
+		Event      string          `json:"event"`
+		Command    string          `json:"command,omitempty"`
+		Steps      []jsonStep      `json:"steps"`
+		Starved    []jsonMigration `json:"starved"`
+		OutOfOrder []jsonMigration `json:"out_of_order"`
+		Error      string          `json:"error,omitempty"`
+	}{Event: "plan", Steps: []jsonStep{}, Starved: []jsonMigration{}, OutOfOrder: []jsonMigration{}, Error: errorString(err)}
+	if plan != nil {
+		event.Command = plan.Command
+		for _, step := range plan.Steps {
+			event.Steps = append(event.Steps, jsonStep{newJSONMigration(step.Migration), step.Direction.String()})
+		}
+		event.Starved = newJSONMigrations(plan.Starved)
+		event.OutOfOrder = newJSONMigrations(plan.OutOfOrder)
+	}
+	reporter.emit(event)
+	if err != nil {
+		reporter.Exit(11)
+	} else if len(plan.Starved) > 0 {
+		reporter.Exit(10)
+	}
+}
+
+// ListStatus emits a `status` event with the state of each migration. As the
+// migration.DefaultReporter, it exits with an error code when any of them
+// needs attention.
+func (reporter *JSONReporter) ListStatus(rows []*StatusRow, err error) {
+	type jsonRow struct {
+		jsonMigration
+		State    string `json:"state"`
+		Expected string `json:"expected,omitempty"`
+		Actual   string `json:"actual,omitempty"`
+	}
+	result := make([]jsonRow, len(rows))
+	drifted := 0
+	for i, row := range rows {
+		result[i] = jsonRow{
+			jsonMigration: jsonMigration{
+				ID:          row.ID.Format(migrationIDFormat),
+				Description: row.Description(),
+			},
+			State:    row.State.String(),
+			Expected: row.Expected,
+			Actual:   row.Actual,
+		}
+		if row.Drifted() {
+			drifted++
+		}
+	}
+	reporter.emit(struct {
+		Event string    `json:"event"`
+		Rows  []jsonRow `json:"rows"`
+		Error string    `json:"error,omitempty"`
+	}{"status", result, errorString(err)})
+	if err != nil {
+		reporter.Exit(11)
+	} else if drifted > 0 {
+		reporter.Exit(10)
+	}
+}
+
+// SQLScript emits a `sql_script` event with the script.
+func (reporter *JSONReporter) SQLScript(script string, err error) {
+	reporter.emit(struct {
+		Event  string `json:"event"`
+		Script string `json:"script"`
+		Error  string `json:"error,omitempty"`
+	}{"sql_script", script, errorString(err)})
+	if err != nil {
+		reporter.Exit(11)
+	}
+}
+
+// MigrationsStarved emits a `migrations_starved` event with the starved
+// migrations.
+func (reporter *JSONReporter) MigrationsStarved(migrations []Migration) {
+	reporter.started("migrations_starved", migrations)
+}
+
+type jsonMismatch struct {
+	jsonMigration
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+func newJSONMismatches(mismatches []*ChecksumMismatch) []jsonMismatch {
+	result := make([]jsonMismatch, len(mismatches))
+	for i, mismatch := range mismatches {
+		result[i] = jsonMismatch{newJSONMigration(mismatch.Migration), mismatch.Expected, mismatch.Actual}
+	}
+	return result
+}
+
+// ChecksumMismatches emits a `checksum_mismatches` event.
+func (reporter *JSONReporter) ChecksumMismatches(mismatches []*ChecksumMismatch) {
+	reporter.emit(struct {
+		Event      string         `json:"event"`
+		Mismatches []jsonMismatch `json:"mismatches"`
+	}{"checksum_mismatches", newJSONMismatches(mismatches)})
+}
+
+// AfterVerify emits a `verify_finished` event. As the
+// migration.DefaultReporter, it exits with an error code when any mismatch is
+// found.
+func (reporter *JSONReporter) AfterVerify(mismatches []*ChecksumMismatch, err error) {
+	reporter.emit(struct {
+		Event      string         `json:"event"`
+		Mismatches []jsonMismatch `json:"mismatches"`
+		Error      string         `json:"error,omitempty"`
+	}{"verify_finished", newJSONMismatches(mismatches), errorString(err)})
+	if err != nil {
+		reporter.Exit(11)
+	} else if len(mismatches) > 0 {
+		reporter.Exit(10)
+	}
+}
+
+// Residues emits a `residues` event.
+func (reporter *JSONReporter) Residues(residues []*Residue) {
+	type jsonResidue struct {
+		jsonMigration
+		Added   []string `json:"added"`
+		Removed []string `json:"removed"`
+	}
+	result := make([]jsonResidue, len(residues))
+	for i, residue := range residues {
+		result[i] = jsonResidue{newJSONMigration(residue.Migration), residue.Added, residue.Removed}
+	}
+	reporter.emit(struct {
+		Event    string        `json:"event"`
+		Residues []jsonResidue `json:"residues"`
+	}{"residues", result})
+}
+
+// Usage emits an `usage` event with the commands available.
+func (reporter *JSONReporter) Usage() {
+	reporter.emit(struct {
+		Event    string   `json:"event"`
+		Commands []string `json:"commands"`
+	}{"usage", []string{
+		"migrate", "migrate-to <id>", "rewind", "rewind-to <id>", "do [n]", "undo [n]", "redo",
+		"executed", "pending", "history", "status", "verify", "plan <command>", "sql <command>",
+		"baseline <id>", "mark-applied <id> --force", "mark-reverted <id> --force",
+	}})
+}
+
+// CommandNotFound emits a `command_not_found` event.
+func (reporter *JSONReporter) CommandNotFound(command string) {
+	reporter.emit(struct {
+		Event   string `json:"event"`
+		Command string `json:"command"`
+	}{"command_not_found", command})
+}
+
+// NoCommand gets called when no command is provided to the migration tool.
+func (reporter *JSONReporter) NoCommand() {
+	reporter.Usage()
+}
@@ -0,0 +1,148 @@
+package migration_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lab259/go-migration"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSONReporter", func() {
+	var (
+		output   *bytes.Buffer
+		exitCode int
+		reporter *migration.JSONReporter
+	)
+
+	BeforeEach(func() {
+		output = bytes.NewBuffer(nil)
+		exitCode = 0
+		reporter = migration.NewJSONReporterWithParams(output, func(code int) {
+			exitCode = code
+		})
+	})
+
+	events := func() []map[string]interface{} {
+		result := make([]map[string]interface{}, 0)
+		for _, line := range strings.Split(strings.TrimSpace(output.String()), "\n") {
+			event := make(map[string]interface{})
+			Expect(json.Unmarshal([]byte(line), &event)).To(Succeed(), line)
+			result = append(result, event)
+		}
+		return result
+	}
+
+	It("should emit a JSON event for each migration and for the summary", func() {
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler))
+		source.Register(migration.NewMigration(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), "Description 2", func(executionContext interface{}) error {
+			return errors.New("forced error")
+		}))
+		manager := migration.NewDefaultManager(&nopTarget{}, source)
+
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "migrate").Run(nil)
+
+		list := events()
+		Expect(list).To(HaveLen(6))
+		Expect(list[0]["event"]).To(Equal("migrate_started"))
+		Expect(list[0]["migrations"]).To(HaveLen(2))
+		Expect(list[1]).To(Equal(map[string]interface{}{
+			"event":       "migration_started",
+			"id":          "20010101000000",
+			"description": "Description 1",
+			"direction":   "do",
+		}))
+		Expect(list[2]["event"]).To(Equal("migration_finished"))
+		Expect(list[2]["failed"]).To(BeFalse())
+		Expect(list[2]).To(HaveKey("duration_ms"))
+		Expect(list[2]).ToNot(HaveKey("error"))
+		Expect(list[4]["event"]).To(Equal("migration_finished"))
+		Expect(list[4]["id"]).To(Equal("20020101000000"))
+		Expect(list[4]["failed"]).To(BeTrue())
+		Expect(list[4]["panicked"]).To(BeFalse())
+		Expect(list[4]["error"]).To(Equal("forced error"))
+		Expect(list[5]["event"]).To(Equal("migrate_finished"))
+		Expect(list[5]["executed"]).To(BeEquivalentTo(1))
+		Expect(list[5]["failed"]).To(BeEquivalentTo(1))
+		Expect(list[5]["migrations"]).To(HaveLen(2))
+		Expect(list[5]["error"]).To(Equal("forced error"))
+		Expect(exitCode).To(Equal(11))
+	})
+
+	It("should report panics", func() {
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", func(executionContext interface{}) error {
+			panic("panic data")
+		}))
+		manager := migration.NewDefaultManager(&nopTarget{}, source)
+
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "do").Run(nil)
+
+		list := events()
+		Expect(list[1]["event"]).To(Equal("migration_finished"))
+		Expect(list[1]["panicked"]).To(BeTrue())
+		Expect(list[1]["error"]).To(Equal("panic: panic data"))
+		Expect(list[2]["event"]).To(Equal("migration_summary"))
+		Expect(list[2]["error"]).To(Equal(migration.ErrMigrationPanicked.Error()))
+	})
+
+	It("should emit the summaries of the reset", func() {
+		m1 := migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler, nopHandler)
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		target := &nopTarget{}
+		target.AddMigration(migration.NewSummary(m1))
+		manager := migration.NewDefaultManager(target, source)
+
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "reset").Run(nil)
+
+		list := events()
+		last := list[len(list)-1]
+		Expect(last["event"]).To(Equal("reset_finished"))
+		Expect(last["rewind"]).To(HaveKeyWithValue("executed", BeEquivalentTo(1)))
+		Expect(last["migrate"]).To(HaveKeyWithValue("executed", BeEquivalentTo(1)))
+		Expect(exitCode).To(Equal(0))
+	})
+
+	It("should list the starved migrations", func() {
+		m1 := migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler)
+		m2 := migration.NewMigration(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), "Description 2", nopHandler)
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		source.Register(m2)
+		target := &nopTarget{}
+		target.AddMigration(migration.NewSummary(m2))
+		manager := migration.NewDefaultManager(target, source)
+
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "migrate").Run(nil)
+
+		list := events()
+		Expect(list[0]["event"]).To(Equal("migrations_starved"))
+		Expect(list[0]["migrations"]).To(Equal([]interface{}{
+			map[string]interface{}{"id": "20010101000000", "description": "Description 1"},
+		}))
+		Expect(list[1]["event"]).To(Equal("migrate_finished"))
+		Expect(list[1]["error"]).To(Equal(migration.ErrMigrationStarved.Error()))
+		Expect(exitCode).To(Equal(11))
+	})
+
+	It("should exit with an error code when do is aborted", func() {
+		target := &LockerTarget{}
+		target.lockErr = migration.ErrLockTimeout
+		manager := migration.NewManager(target, migration.NewCodeSource())
+
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "do").Run(nil)
+
+		list := events()
+		Expect(list[len(list)-1]).To(Equal(map[string]interface{}{
+			"event": "failure",
+			"error": migration.ErrLockTimeout.Error(),
+		}))
+		Expect(exitCode).To(Equal(11))
+	})
+})
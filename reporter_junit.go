@@ -0,0 +1,145 @@
+package migration
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// JUnitReporter is a Reporter that writes the summaries of the migrate and
+// of the reset commands as a JUnit XML report, so CI servers can show which
+// migration broke. Each migration is a `<testcase>`, failing when the
+// migration failed or panicked.
+//
+// All the calls are forwarded to the wrapped Reporter, which is responsible
+// for the output and the exit codes. The report is written before the
+// wrapped Reporter is called, as it may exit the process.
+type JUnitReporter struct {
+	Reporter
+	writer io.Writer
+}
+
+// NewJUnitReporter returns an instance of a JUnitReporter that wraps the
+// `reporter` and writes the report to `w`.
+func NewJUnitReporter(reporter Reporter, w io.Writer) *JUnitReporter {
+	return &JUnitReporter{
+		Reporter: reporter,
+		writer:   w,
+	}
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name          `xml:"testsuites"`
+	Suites  []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Cases    []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Error     *junitFailure `xml:"error,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Data    string `xml:",chardata"`
+}
+
+func junitSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+// newJUnitTestSuite creates the suite of a command from its summaries. If the
+// command failed without any migration failing (eg. starved migrations), the
+// error is added as an errored test case named after the command.
+func newJUnitTestSuite(name string, summaries []*Summary, err error) *junitTestSuite {
+	suite := &junitTestSuite{
+		Name:  name,
+		Cases: make([]*junitTestCase, 0, len(summaries)),
+	}
+	total := 0.0
+	for _, summary := range summaries {
+		seconds := summary.Duration().Seconds()
+		total += seconds
+		testCase := &junitTestCase{
+			ClassName: name,
+			Name:      fmt.Sprintf("%s %s", MigrationLabel(summary.Migration), summary.Migration.GetDescription()),
+			Time:      junitSeconds(seconds),
+		}
+		if summary.Panicked() {
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("panic: %v", summary.PanicData()),
+				Type:    "panic",
+				Data:    fmt.Sprintf("%+v", summary.PanicData()),
+			}
+		} else if summary.Failed() {
+			testCase.Failure = &junitFailure{
+				Message: summary.Failure().Error(),
+				Type:    "failure",
+				Data:    fmt.Sprintf("%+v", summary.Failure()),
+			}
+		}
+		if testCase.Failure != nil {
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	if err != nil && suite.Failures == 0 {
+		suite.Errors++
+		suite.Cases = append(suite.Cases, &junitTestCase{
+			ClassName: name,
+			Name:      name,
+			Time:      junitSeconds(0),
+			Error: &junitFailure{
+				Message: err.Error(),
+				Type:    "error",
+				Data:    err.Error(),
+			},
+		})
+	}
+	suite.Tests = len(suite.Cases)
+	suite.Time = junitSeconds(total)
+	return suite
+}
+
+// write writes the report with the given suites. Failures writing it are
+// reported through the wrapped Reporter.
+func (reporter *JUnitReporter) write(suites ...*junitTestSuite) {
+	data, err := xml.MarshalIndent(&junitTestSuites{Suites: suites}, "", "  ")
+	if err == nil {
+		_, err = fmt.Fprintf(reporter.writer, "%s%s\n", xml.Header, data)
+	}
+	if err != nil {
+		reporter.Reporter.Failure(fmt.Errorf("writing the JUnit report: %s", err))
+	}
+}
+
+// AfterMigrate writes the report with the summaries of the migrate.
+func (reporter *JUnitReporter) AfterMigrate(migrations []*Summary, err error) {
+	reporter.write(newJUnitTestSuite("migrate", migrations, err))
+	reporter.Reporter.AfterMigrate(migrations, err)
+}
+
+// AfterReset writes the report with the summaries of the rewind and of the
+// migrate, as two suites. Errors not related to a migration are added to the
+// migrate suite.
+func (reporter *JUnitReporter) AfterReset(rewindSummary []*Summary, migrateSummary []*Summary, err error) {
+	rewind := newJUnitTestSuite("rewind", rewindSummary, nil)
+	migrateErr := err
+	if rewind.Failures > 0 {
+		migrateErr = nil
+	}
+	reporter.write(rewind, newJUnitTestSuite("migrate", migrateSummary, migrateErr))
+	reporter.Reporter.AfterReset(rewindSummary, migrateSummary, err)
+}
@@ -0,0 +1,138 @@
+package migration_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"time"
+
+	"github.com/lab259/go-migration"
+	"github.com/lab259/go-migration/migrationtest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type junitReport struct {
+	Suites []struct {
+		Name     string `xml:"name,attr"`
+		Tests    int    `xml:"tests,attr"`
+		Failures int    `xml:"failures,attr"`
+		Errors   int    `xml:"errors,attr"`
+		Cases    []struct {
+			Name    string `xml:"name,attr"`
+			Time    string `xml:"time,attr"`
+			Failure *struct {
+				Message string `xml:"message,attr"`
+				Type    string `xml:"type,attr"`
+				Data    string `xml:",chardata"`
+			} `xml:"failure"`
+			Error *struct {
+				Message string `xml:"message,attr"`
+			} `xml:"error"`
+		} `xml:"testcase"`
+	} `xml:"testsuite"`
+}
+
+var _ = Describe("JUnitReporter", func() {
+	var (
+		output    *bytes.Buffer
+		recording *migrationtest.RecordingReporter
+		reporter  *migration.JUnitReporter
+		m1, m2    *migration.DefaultMigration
+	)
+
+	BeforeEach(func() {
+		output = bytes.NewBuffer(nil)
+		recording = migrationtest.NewRecordingReporter()
+		reporter = migration.NewJUnitReporter(recording, output)
+		m1 = migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler, nopHandler)
+		m2 = migration.NewMigration(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), "Description 2", func(executionContext interface{}) error {
+			return errors.New("forced error")
+		}, nopHandler)
+	})
+
+	report := func() *junitReport {
+		var r junitReport
+		Expect(xml.Unmarshal(output.Bytes(), &r)).To(Succeed())
+		return &r
+	}
+
+	It("should write a test case for each migration of the migrate", func() {
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		source.Register(m2)
+		manager := migration.NewDefaultManager(&nopTarget{}, source)
+
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "migrate").Run(nil)
+
+		r := report()
+		Expect(r.Suites).To(HaveLen(1))
+		suite := r.Suites[0]
+		Expect(suite.Name).To(Equal("migrate"))
+		Expect(suite.Tests).To(Equal(2))
+		Expect(suite.Failures).To(Equal(1))
+		Expect(suite.Errors).To(Equal(0))
+		Expect(suite.Cases[0].Name).To(Equal("20010101000000 Description 1"))
+		Expect(suite.Cases[0].Time).To(MatchRegexp(`^\d+\.\d{3}$`))
+		Expect(suite.Cases[0].Failure).To(BeNil())
+		Expect(suite.Cases[1].Name).To(Equal("20020101000000 Description 2"))
+		Expect(suite.Cases[1].Failure).ToNot(BeNil())
+		Expect(suite.Cases[1].Failure.Type).To(Equal("failure"))
+		Expect(suite.Cases[1].Failure.Message).To(Equal("forced error"))
+
+		Expect(recording.Kinds()).To(ContainElement(migrationtest.AfterMigrate))
+	})
+
+	It("should map panics to failures", func() {
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(m1.GetID(), "Panic", func(executionContext interface{}) error {
+			panic("panic data")
+		}))
+		manager := migration.NewDefaultManager(&nopTarget{}, source)
+
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "migrate").Run(nil)
+
+		failure := report().Suites[0].Cases[0].Failure
+		Expect(failure).ToNot(BeNil())
+		Expect(failure.Type).To(Equal("panic"))
+		Expect(failure.Message).To(Equal("panic: panic data"))
+		Expect(failure.Data).To(Equal("panic data"))
+	})
+
+	It("should add the errors not related to a migration as an errored test case", func() {
+		latest := migration.NewMigration(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), "Description 2", nopHandler)
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		source.Register(latest)
+		target := &nopTarget{}
+		target.AddMigration(migration.NewSummary(latest))
+		manager := migration.NewDefaultManager(target, source)
+
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "migrate").Run(nil)
+
+		suite := report().Suites[0]
+		Expect(suite.Errors).To(Equal(1))
+		Expect(suite.Cases).To(HaveLen(1))
+		Expect(suite.Cases[0].Name).To(Equal("migrate"))
+		Expect(suite.Cases[0].Error.Message).To(Equal(migration.ErrMigrationStarved.Error()))
+	})
+
+	It("should write the rewind and the migrate of the reset as suites", func() {
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		target := &nopTarget{}
+		target.AddMigration(migration.NewSummary(m1))
+		manager := migration.NewDefaultManager(target, source)
+
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "reset").Run(nil)
+
+		r := report()
+		Expect(r.Suites).To(HaveLen(2))
+		Expect(r.Suites[0].Name).To(Equal("rewind"))
+		Expect(r.Suites[0].Tests).To(Equal(1))
+		Expect(r.Suites[1].Name).To(Equal("migrate"))
+		Expect(r.Suites[1].Tests).To(Equal(1))
+		Expect(r.Suites[1].Failures).To(Equal(0))
+		Expect(recording.Kinds()).To(ContainElement(migrationtest.AfterReset))
+	})
+})
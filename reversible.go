@@ -0,0 +1,58 @@
+package migration
+
+import (
+	"context"
+	"sort"
+)
+
+// Snapshot is the state of a database captured by a migration.Snapshotter.
+// Each entry describes one object of the database (eg. a column or an index).
+type Snapshot []string
+
+// Diff compares the snapshot with an `older` one. It returns the entries that
+// were added and the entries that were removed since the `older` snapshot,
+// both sorted.
+func (snapshot Snapshot) Diff(older Snapshot) (added []string, removed []string) {
+	current := make(map[string]bool, len(snapshot))
+	for _, entry := range snapshot {
+		current[entry] = true
+	}
+	previous := make(map[string]bool, len(older))
+	for _, entry := range older {
+		previous[entry] = true
+	}
+
+	for entry := range current {
+		if !previous[entry] {
+			added = append(added, entry)
+		}
+	}
+	for entry := range previous {
+		if !current[entry] {
+			removed = append(removed, entry)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return
+}
+
+// Snapshotter captures the state of a database, so
+// migration.ManagerDefault.VerifyReversible can check if the undo of a
+// migration brings the database back to the state it was before the do.
+type Snapshotter interface {
+	Snapshot(ctx context.Context) (Snapshot, error)
+}
+
+// Residue describes a migration whose undo does not bring the database back to
+// the state it was before its do.
+type Residue struct {
+	Migration Migration
+
+	// Added are the entries left behind by the undo.
+	Added []string
+
+	// Removed are the entries that existed before the do and were not
+	// restored by the undo.
+	Removed []string
+}
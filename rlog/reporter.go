@@ -43,7 +43,7 @@ func (reporter *rlogReporter) BeforeMigration(summary migration.Summary, err err
 	} else {
 		action = "Rewinding"
 	}
-	reporter.logger.Tracef(2, "  %s [%s] %s ...", action, styleMigrationID(summary.Migration.GetID().Format(migrationIDFormat)), styleMigrationTitle(summary.Migration.GetDescription()))
+	reporter.logger.Tracef(2, "  %s [%s] %s ...", action, styleMigrationID(migration.MigrationLabel(summary.Migration)), styleMigrationTitle(summary.Migration.GetDescription()))
 }
 
 // AfterMigration is called by the Manager right after a migrations is ran.
@@ -86,6 +86,7 @@ func (reporter *rlogReporter) AfterMigrate(migrations []*migration.Summary, err
 	if err != nil {
 		reporter.logger.Error("    %s", err)
 		reporter.Exit(11)
+		return
 	}
 	executed := 0
 	failed := 0
@@ -113,6 +114,11 @@ func (reporter *rlogReporter) BeforeRewind(migrations []migration.Migration) {
 
 // AfterRewind is called right after the process of rewinding is completed.
 func (reporter *rlogReporter) AfterRewind(migrations []*migration.Summary, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
 	executed := 0
 	failed := 0
 	for _, m := range migrations {
@@ -135,8 +141,46 @@ func (reporter *rlogReporter) BeforeReset() {
 
 // AfterReset is called right before the process of reseting is completed.
 func (reporter *rlogReporter) AfterReset(rewindSummary []*migration.Summary, migrateSummary []*migration.Summary, err error) {
-	reporter.AfterRewind(rewindSummary, err)
-	reporter.AfterMigrate(migrateSummary, err)
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	reporter.AfterRewind(rewindSummary, nil)
+	reporter.AfterMigrate(migrateSummary, nil)
+}
+
+// AfterBaseline is called right after the migrations are marked as executed
+// by the baseline.
+func (reporter *rlogReporter) AfterBaseline(migrations []*migration.Summary, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	if len(migrations) == 0 {
+		reporter.logger.Warn("Nothing to baseline.")
+		return
+	}
+	for i, m := range migrations {
+		reporter.logger.Infof("%d) [%s] %s", i+1, styleMigrationID(m.Migration.GetID().Format(migrationIDFormat)), styleMigrationTitle(m.Migration.GetDescription()))
+	}
+	reporter.logger.Infof("%s migrations were baselined", styleSuccess(fmt.Sprintf("%d", len(migrations))))
+}
+
+// MigrationMarked is called right after a migration is marked as applied or
+// as reverted, without running it.
+func (reporter *rlogReporter) MigrationMarked(summary *migration.Summary, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	status := "applied"
+	if summary.Direction() == migration.DirectionUndo {
+		status = "reverted"
+	}
+	reporter.logger.Warnf("Marked [%s] %s as %s", styleMigrationID(summary.Migration.GetID().Format(migrationIDFormat)), styleMigrationTitle(summary.Migration.GetDescription()), status)
 }
 
 // MigrationSummary prints the summary of the migration.
@@ -186,16 +230,125 @@ func (reporter *rlogReporter) ListExecuted(migrations []migration.Migration, err
 	}
 }
 
+// ListHistory reports the records of the applied and reverted migrations and of
+// the failed attempts.
+func (reporter *rlogReporter) ListHistory(records []*migration.HistoryRecord, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		return
+	}
+	if len(records) == 0 {
+		reporter.logger.Warn("No history recorded.")
+		return
+	}
+	reporter.logger.Infof("%d history records:", len(records))
+	for i, record := range records {
+		status := styleSuccess("Ok")
+		if record.Failed {
+			status = styleError("Failed: " + record.Failure)
+		} else if record.Baselined {
+			status = styleWarning("Baselined")
+		}
+		reporter.logger.Infof("%d) [%s] %s %s at %s (%dms) by %s@%s (%s) %s", i+1, styleMigrationID(record.ID.Format(migrationIDFormat)), styleMigrationTitle(record.Description), record.Direction, record.AppliedAt.Format(time.RFC3339), record.Duration.Nanoseconds()/1000000, record.User, record.Hostname, record.ToolVersion, status)
+	}
+}
+
+// ListStatus reports the state of each migration, with the ID, the state and
+// the description as fields of the log entries. If any of them needs
+// attention (see migration.StatusRow.Drifted), it exits with an error code.
+func (reporter *rlogReporter) ListStatus(rows []*migration.StatusRow, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	if len(rows) == 0 {
+		reporter.logger.Warn("No migrations found.")
+		return
+	}
+	drifted := 0
+	for _, row := range rows {
+		fields := rlog2.Fields{
+			"id":          row.ID.Format(migrationIDFormat),
+			"state":       row.State.String(),
+			"description": row.Description(),
+		}
+		if row.State == migration.StateChecksumMismatch {
+			fields["expected"] = row.Expected
+			fields["actual"] = row.Actual
+		}
+		logger := reporter.logger.WithFields(fields)
+		if row.Drifted() {
+			drifted++
+			logger.Errorf("[%s] %s", styleMigrationID(row.ID.Format(migrationIDFormat)), row.State)
+		} else {
+			logger.Infof("[%s] %s", styleMigrationID(row.ID.Format(migrationIDFormat)), row.State)
+		}
+	}
+	if drifted > 0 {
+		reporter.logger.Errorf("%d migrations need attention.", drifted)
+		reporter.Exit(10)
+	}
+}
+
+// ListPlan reports the steps a command would take. If there are starved
+// migrations, the command would fail, so it exits with an error code.
+func (reporter *rlogReporter) ListPlan(plan *migration.Plan, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	if len(plan.Steps) == 0 {
+		reporter.logger.Warnf("Nothing to %s.", plan.Command)
+	} else {
+		reporter.logger.Infof("%s would take %d steps:", plan.Command, len(plan.Steps))
+		for i, step := range plan.Steps {
+			reporter.logger.Infof("%d) %-4s [%s] %s", i+1, step.Direction, styleMigrationID(migration.MigrationLabel(step.Migration)), styleMigrationTitle(step.Migration.GetDescription()))
+		}
+	}
+	if len(plan.OutOfOrder) > 0 {
+		reporter.logger.Warnf("%d migrations would be applied out of order.", len(plan.OutOfOrder))
+	}
+	if len(plan.Starved) > 0 {
+		reporter.MigrationsStarved(plan.Starved)
+		reporter.logger.Error(styleError(fmt.Sprintf("%s would fail: %s", plan.Command, migration.ErrMigrationStarved)))
+		reporter.Exit(10)
+	}
+}
+
+// SQLScript writes the SQL script of a command straight to the standard
+// output, bypassing the logger, so it can be redirected to a file.
+func (reporter *rlogReporter) SQLScript(script string, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	fmt.Fprint(os.Stdout, script)
+}
+
 // Usage prints the usage of the migration command.
 func (reporter *rlogReporter) Usage() {
-	reporter.logger.Info("Usage:", os.Args[0], "[migrate | rewind | do | undo | executed | pending]")
+	reporter.logger.Info("Usage:", os.Args[0], "[migrate | migrate-to <id> | rewind | rewind-to <id> | do [n] | undo [n] | redo | executed | pending | history | status | verify | plan <command> | sql <command> | baseline <id> | mark-applied <id> --force | mark-reverted <id> --force]")
 	line := "  %18s  %s"
 	reporter.logger.Infof(line, styleBold("migrate"), "Apply all pending migrations")
+	reporter.logger.Infof(line, styleBold("migrate-to <id>"), "Apply the pending migrations up to <id>")
 	reporter.logger.Infof(line, styleBold("rewind"), "Rewind all executed migrations")
-	reporter.logger.Infof(line, styleBold("do"), "Execute the next pending migration")
-	reporter.logger.Infof(line, styleBold("undo"), "Execute the last applied migration")
+	reporter.logger.Infof(line, styleBold("rewind-to <id>"), "Rewind the executed migrations newer than <id>")
+	reporter.logger.Infof(line, styleBold("do [n]"), "Execute the next n (default 1) pending migrations")
+	reporter.logger.Infof(line, styleBold("undo [n]"), "Undo the last n (default 1) applied migrations")
+	reporter.logger.Infof(line, styleBold("redo"), "Undo the last applied migration and execute it again")
 	reporter.logger.Infof(line, styleBold("executed"), "List all executed migrations")
 	reporter.logger.Infof(line, styleBold("pending"), "List all pending migrations")
+	reporter.logger.Infof(line, styleBold("history"), "List the applied and reverted migrations and the failed attempts")
+	reporter.logger.Infof(line, styleBold("status"), "List the state of each migration, including the ones missing from the source")
+	reporter.logger.Infof(line, styleBold("verify"), "Check the executed migrations were not changed")
+	reporter.logger.Infof(line, styleBold("plan <command>"), "Show the steps of migrate, migrate-to, rewind, rewind-to or reset without executing them")
+	reporter.logger.Infof(line, styleBold("sql <command>"), "Print the SQL script of migrate, migrate-to, rewind, rewind-to or reset without executing it")
+	reporter.logger.Infof(line, styleBold("baseline <id>"), "Mark the migrations up to <id> as executed, without running them")
+	reporter.logger.Infof(line, styleBold("mark-applied <id>"), "Record <id> as executed, without running it (requires --force)")
+	reporter.logger.Infof(line, styleBold("mark-reverted <id>"), "Remove <id> from the executed migrations, without undoing it (requires --force)")
 }
 
 // CommandNotFound reports the command executed by the migration tool was not
@@ -217,3 +370,42 @@ func (reporter *rlogReporter) MigrationsStarved(migrations []migration.Migration
 		reporter.logger.Infof("  %d) [%s] %s", i+1, styleMigrationID(m.GetID().Format(migrationIDFormat)), styleMigrationTitle(m.GetDescription()))
 	}
 }
+
+// ChecksumMismatches is called whenever the manager detects executed
+// migrations that were changed after being applied.
+func (reporter *rlogReporter) ChecksumMismatches(mismatches []*migration.ChecksumMismatch) {
+	reporter.logger.Error(styleError(fmt.Sprintf("Checksum mismatch detected in %d migrations", len(mismatches))))
+	for i, mismatch := range mismatches {
+		reporter.logger.Infof("  %d) [%s] %s: expected %s, got %s", i+1, styleMigrationID(mismatch.Migration.GetID().Format(migrationIDFormat)), styleMigrationTitle(mismatch.Migration.GetDescription()), mismatch.Expected, mismatch.Actual)
+	}
+}
+
+// Residues is called whenever the manager detects migrations whose undo does
+// not reverse their do.
+func (reporter *rlogReporter) Residues(residues []*migration.Residue) {
+	reporter.logger.Error(styleError(fmt.Sprintf("Residue detected in %d migrations", len(residues))))
+	for i, residue := range residues {
+		reporter.logger.Infof("  %d) [%s] %s", i+1, styleMigrationID(residue.Migration.GetID().Format(migrationIDFormat)), styleMigrationTitle(residue.Migration.GetDescription()))
+		for _, entry := range residue.Added {
+			reporter.logger.Info("     + " + entry)
+		}
+		for _, entry := range residue.Removed {
+			reporter.logger.Info("     - " + entry)
+		}
+	}
+}
+
+// AfterVerify is called right after the checksums of the executed migrations
+// are verified.
+func (reporter *rlogReporter) AfterVerify(mismatches []*migration.ChecksumMismatch, err error) {
+	if err != nil {
+		reporter.Failure(err)
+		reporter.Exit(11)
+		return
+	}
+	if len(mismatches) > 0 {
+		reporter.Exit(10)
+		return
+	}
+	reporter.logger.Info(styleSuccess("All executed migrations match their checksums."))
+}
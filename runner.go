@@ -1,6 +1,15 @@
 package migration
 
+import "context"
+
 // Runner defines the basic contract for a something that might run a migration.
 type Runner interface {
 	Run(executionContext interface{})
 }
+
+// ContextRunner is implemented by runners that accept a `context.Context`
+// (eg. migration.ArgsRunner). When the context is done, the running command
+// stops before the next migration.
+type ContextRunner interface {
+	RunContext(ctx context.Context, executionContext interface{})
+}
@@ -1,7 +1,13 @@
 package migration
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // ArgsRunner is the Runner that will provide the default implementation of
@@ -35,35 +41,318 @@ func NewArgsRunnerCustom(reporter Reporter, manager Manager, exitFnc func(code i
 
 // Run performs the actions based on the arguments captured.
 func (runner *ArgsRunner) Run(executionContext interface{}) {
+	runner.RunContext(context.Background(), executionContext)
+}
+
+// RunContext is the migration.ArgsRunner.Run with a `context.Context`. When
+// the `ctx` is canceled, the running command stops before the next migration.
+func (runner *ArgsRunner) RunContext(ctx context.Context, executionContext interface{}) {
 	target := runner.manager.Target()
 	if beforeHook, ok := target.(BeforeRun); ok {
 		beforeHook.BeforeRun(executionContext)
 	}
 
-	args := runner.args
-	if len(args) > 0 {
-		for _, s := range args {
-			switch s {
-			case "pending":
-				runner.reporter.ListPending(runner.manager.MigrationsPending())
-			case "executed":
-				runner.reporter.ListExecuted(runner.manager.MigrationsExecuted())
-			case "migrate":
-				runner.reporter.AfterMigrate(runner.manager.Migrate(runner.reporter, executionContext))
-			case "rewind":
-				runner.reporter.AfterRewind(runner.manager.Rewind(runner.reporter, executionContext))
-			case "do":
-				runner.reporter.MigrationSummary(runner.manager.Do(runner.reporter, executionContext))
-			case "undo":
-				runner.reporter.MigrationSummary(runner.manager.Undo(runner.reporter, executionContext))
-			case "reset":
-				runner.reporter.AfterReset(runner.manager.Reset(runner.reporter, executionContext))
-			default:
-				runner.reporter.CommandNotFound(s)
+	if len(runner.args) == 0 {
+		runner.reporter.NoCommand()
+		return
+	}
+
+	command, params := runner.args[0], runner.args[1:]
+	switch command {
+	case "pending":
+		if runner.noParams(command, params) {
+			runner.reporter.ListPending(runner.manager.MigrationsPending())
+		}
+	case "executed":
+		if runner.noParams(command, params) {
+			runner.reporter.ListExecuted(runner.manager.MigrationsExecuted())
+		}
+	case "history":
+		if manager, ok := runner.manager.(ManagerHistory); !ok {
+			runner.unsupported(command)
+		} else if reporter, ok := runner.reporter.(HistoryReporter); !ok {
+			runner.unsupportedReporter(command)
+		} else if runner.noParams(command, params) {
+			reporter.ListHistory(manager.HistoryContext(ctx))
+		}
+	case "status":
+		if manager, ok := runner.manager.(ManagerHistory); !ok {
+			runner.unsupported(command)
+		} else if reporter, ok := runner.reporter.(StatusReporter); !ok {
+			runner.unsupportedReporter(command)
+		} else if runner.noParams(command, params) {
+			reporter.ListStatus(manager.StatusContext(ctx))
+		}
+	case "migrate":
+		if runner.noParams(command, params) {
+			runner.reporter.AfterMigrate(managerMigrate(ctx, runner.manager, runner.reporter, executionContext))
+		}
+	case "migrate-to":
+		if manager, ok := runner.manager.(ManagerTo); !ok {
+			runner.unsupported(command)
+		} else if version, ok := runner.versionParam(command, params); ok {
+			runner.reporter.AfterMigrate(manager.MigrateToContext(ctx, version, runner.reporter, executionContext))
+		}
+	case "rewind":
+		if runner.noParams(command, params) {
+			runner.reporter.AfterRewind(managerRewind(ctx, runner.manager, runner.reporter, executionContext))
+		}
+	case "rewind-to":
+		if manager, ok := runner.manager.(ManagerTo); !ok {
+			runner.unsupported(command)
+		} else if version, ok := runner.versionParam(command, params); ok {
+			runner.reporter.AfterRewind(manager.RewindToContext(ctx, version, runner.reporter, executionContext))
+		}
+	case "do":
+		if steps, ok := runner.stepsParam(command, params); ok {
+			if manager, ok := runner.manager.(ManagerSteps); ok {
+				_, err := manager.DoNContext(ctx, steps, runner.reporter, executionContext)
+				runner.exitOnError(err)
+				return
 			}
-			break
+			runner.exitOnError(runner.steps(steps, func() (*Summary, error) {
+				return managerDo(ctx, runner.manager, runner.reporter, executionContext)
+			}))
 		}
-	} else {
-		runner.reporter.NoCommand()
+	case "undo":
+		if steps, ok := runner.stepsParam(command, params); ok {
+			if manager, ok := runner.manager.(ManagerSteps); ok {
+				_, err := manager.UndoNContext(ctx, steps, runner.reporter, executionContext)
+				runner.exitOnError(err)
+				return
+			}
+			runner.exitOnError(runner.steps(steps, func() (*Summary, error) {
+				return managerUndo(ctx, runner.manager, runner.reporter, executionContext)
+			}))
+		}
+	case "redo":
+		if manager, ok := runner.manager.(ManagerRedo); !ok {
+			runner.unsupported(command)
+		} else if runner.noParams(command, params) {
+			undone, done, err := manager.RedoContext(ctx, runner.reporter, executionContext)
+			if done == nil {
+				runner.reporter.MigrationSummary(undone, err)
+			} else {
+				runner.reporter.MigrationSummary(undone, nil)
+				runner.reporter.MigrationSummary(done, err)
+			}
+			runner.exitOnError(err)
+		}
+	case "verify":
+		if manager, ok := runner.manager.(ManagerVerifier); !ok {
+			runner.unsupported(command)
+		} else if reporter, ok := runner.reporter.(VerifyReporter); !ok {
+			runner.unsupportedReporter(command)
+		} else if runner.noParams(command, params) {
+			reporter.AfterVerify(manager.VerifyContext(ctx, runner.reporter))
+		}
+	case "baseline":
+		if manager, ok := runner.manager.(ManagerMarker); !ok {
+			runner.unsupported(command)
+		} else if reporter, ok := runner.reporter.(BaselineReporter); !ok {
+			runner.unsupportedReporter(command)
+		} else if version, ok := runner.versionParam(command, params); ok {
+			reporter.AfterBaseline(manager.BaselineContext(ctx, version))
+		}
+	case "mark-applied":
+		if manager, ok := runner.manager.(ManagerMarker); !ok {
+			runner.unsupported(command)
+		} else if reporter, ok := runner.reporter.(MarkReporter); !ok {
+			runner.unsupportedReporter(command)
+		} else if version, ok := runner.forcedVersionParam(command, params); ok {
+			reporter.MigrationMarked(manager.MarkAppliedContext(ctx, version))
+		}
+	case "mark-reverted":
+		if manager, ok := runner.manager.(ManagerMarker); !ok {
+			runner.unsupported(command)
+		} else if reporter, ok := runner.reporter.(MarkReporter); !ok {
+			runner.unsupportedReporter(command)
+		} else if version, ok := runner.forcedVersionParam(command, params); ok {
+			reporter.MigrationMarked(manager.MarkRevertedContext(ctx, version))
+		}
+	case "plan":
+		if manager, ok := runner.manager.(ManagerPlanner); !ok {
+			runner.unsupported(command)
+		} else if reporter, ok := runner.reporter.(PlanReporter); !ok {
+			runner.unsupportedReporter(command)
+		} else {
+			runner.planCommand(manager, command, params, reporter.ListPlan)
+		}
+	case "sql":
+		if manager, ok := runner.manager.(ManagerPlanner); !ok {
+			runner.unsupported(command)
+		} else if reporter, ok := runner.reporter.(SQLReporter); !ok {
+			runner.unsupportedReporter(command)
+		} else {
+			runner.sql(manager, reporter, params)
+		}
+	case "reset":
+		if runner.noParams(command, params) {
+			runner.reporter.AfterReset(managerReset(ctx, runner.manager, runner.reporter, executionContext))
+		}
+	default:
+		runner.reporter.CommandNotFound(command)
+	}
+}
+
+// steps calls `step` up to `n` times, reporting each summary, for managers
+// that are not a migration.ManagerSteps. It stops at the first error, or when
+// there is nothing else to be done.
+func (runner *ArgsRunner) steps(n int, step func() (*Summary, error)) error {
+	for i := 0; i < n; i++ {
+		summary, err := step()
+		runner.reporter.MigrationSummary(summary, err)
+		if summary == nil || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sql reports the SQL script of the command given as the first param, without
+// executing anything.
+func (runner *ArgsRunner) sql(manager ManagerPlanner, reporter SQLReporter, params []string) {
+	runner.planCommand(manager, "sql", params, func(plan *Plan, err error) {
+		if err != nil {
+			reporter.SQLScript("", err)
+			return
+		}
+		var script bytes.Buffer
+		err = manager.WriteSQL(plan, &script)
+		reporter.SQLScript(script.String(), err)
+	})
+}
+
+// planCommand plans the command given as the first param and passes the
+// result to `report`. The `name` prefixes the failures.
+func (runner *ArgsRunner) planCommand(manager ManagerPlanner, name string, params []string, report func(*Plan, error)) {
+	if len(params) == 0 {
+		runner.fail(fmt.Errorf("%s: expected a command (migrate, migrate-to, rewind, rewind-to or reset)", name))
+		return
+	}
+
+	command, params := params[0], params[1:]
+	commandName := name + " " + command
+	switch command {
+	case "migrate":
+		if runner.noParams(commandName, params) {
+			report(manager.PlanMigrate())
+		}
+	case "migrate-to":
+		if version, ok := runner.versionParam(commandName, params); ok {
+			report(manager.PlanMigrateTo(version))
+		}
+	case "rewind":
+		if runner.noParams(commandName, params) {
+			report(manager.PlanRewind())
+		}
+	case "rewind-to":
+		if version, ok := runner.versionParam(commandName, params); ok {
+			report(manager.PlanRewindTo(version))
+		}
+	case "reset":
+		if runner.noParams(commandName, params) {
+			report(manager.PlanReset())
+		}
+	default:
+		runner.fail(fmt.Errorf("%s: cannot plan %s", name, command))
+	}
+}
+
+// noParams checks the `command` received no params. Otherwise, the failure is
+// reported and it returns false.
+func (runner *ArgsRunner) noParams(command string, params []string) bool {
+	if len(params) > 0 {
+		runner.fail(fmt.Errorf("%s: unexpected arguments %s", command, strings.Join(params, " ")))
+		return false
+	}
+	return true
+}
+
+// stepsParam parses the optional number of steps of the `command`, which
+// defaults to 1. When the param is invalid, the failure is reported and it
+// returns false.
+func (runner *ArgsRunner) stepsParam(command string, params []string) (int, bool) {
+	switch len(params) {
+	case 0:
+		return 1, true
+	case 1:
+		steps, err := strconv.Atoi(params[0])
+		if err != nil || steps < 1 {
+			runner.fail(fmt.Errorf("%s: invalid number of steps %q: expected a positive integer", command, params[0]))
+			return 0, false
+		}
+		return steps, true
+	default:
+		runner.fail(fmt.Errorf("%s: unexpected arguments %s", command, strings.Join(params[1:], " ")))
+		return 0, false
+	}
+}
+
+// versionParam parses the migration ID passed as the single param of the
+// `command`. When the param is missing or invalid, the failure is reported and
+// it returns false.
+func (runner *ArgsRunner) versionParam(command string, params []string) (time.Time, bool) {
+	if len(params) == 0 {
+		runner.fail(fmt.Errorf("%s: expected a migration id (eg. %s %s)", command, command, migrationIDFormat))
+		return NoVersion, false
+	}
+	if len(params) > 1 {
+		runner.fail(fmt.Errorf("%s: unexpected arguments %s", command, strings.Join(params[1:], " ")))
+		return NoVersion, false
+	}
+	version, err := ParseMigrationID(params[0])
+	if err != nil {
+		runner.fail(fmt.Errorf("%s: %s", command, err))
+		return NoVersion, false
 	}
+	return version, true
+}
+
+// forcedVersionParam is the migration.ArgsRunner.versionParam of commands
+// that must be confirmed with the --force flag, as they change the migrations
+// executed without running them.
+func (runner *ArgsRunner) forcedVersionParam(command string, params []string) (time.Time, bool) {
+	forced := false
+	rest := make([]string, 0, len(params))
+	for _, param := range params {
+		if param == "--force" {
+			forced = true
+			continue
+		}
+		rest = append(rest, param)
+	}
+	if !forced {
+		runner.fail(fmt.Errorf("%s: the migrations executed are changed without running anything, pass --force to confirm", command))
+		return NoVersion, false
+	}
+	return runner.versionParam(command, rest)
+}
+
+// unsupported reports the `command` is not supported by the manager, as it
+// does not implement the interface of the command (eg. migration.ManagerTo).
+func (runner *ArgsRunner) unsupported(command string) {
+	runner.fail(fmt.Errorf("%s: the manager does not support this command", command))
+}
+
+// unsupportedReporter reports the `command` is not supported by the reporter,
+// as it does not implement the interface of the command (eg.
+// migration.HistoryReporter).
+func (runner *ArgsRunner) unsupportedReporter(command string) {
+	runner.fail(fmt.Errorf("%s: the reporter does not support this command", command))
+}
+
+// exitOnError reports the error that aborted a command, if any, and exits with
+// the same code as the migrate command.
+func (runner *ArgsRunner) exitOnError(err error) {
+	if err != nil {
+		runner.reporter.Failure(err)
+		runner.reporter.Exit(11)
+	}
+}
+
+// fail reports the error as a failure and exits with an error code.
+func (runner *ArgsRunner) fail(err error) {
+	runner.reporter.Failure(err)
+	runner.reporter.Exit(1)
 }
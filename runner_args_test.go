@@ -1,6 +1,10 @@
 package migration_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/lab259/go-migration"
@@ -18,9 +22,18 @@ type customReporter struct {
 	afterRewind      func(migrations []*migration.Summary, err error)
 	beforeReset      func()
 	afterReset       func(rewindSummary []*migration.Summary, migrateSummary []*migration.Summary, err error)
+	afterBaseline    func(migrations []*migration.Summary, err error)
+	migrationMarked  func(migration *migration.Summary, err error)
 	listPending      func(migrations []migration.Migration, err error)
 	listExecuted     func(migrations []migration.Migration, err error)
+	listHistory      func(records []*migration.HistoryRecord, err error)
 	migrationStarved func(migrations []migration.Migration)
+	mismatches       func(mismatches []*migration.ChecksumMismatch)
+	afterVerify      func(mismatches []*migration.ChecksumMismatch, err error)
+	residues         func(residues []*migration.Residue)
+	listPlan         func(plan *migration.Plan, err error)
+	listStatus       func(rows []*migration.StatusRow, err error)
+	sqlScript        func(script string, err error)
 	failure          func(err error)
 	exit             func(code int)
 	usage            func()
@@ -76,6 +89,18 @@ func (reporter *customReporter) BeforeReset() {
 	}
 }
 
+func (reporter *customReporter) AfterBaseline(migrations []*migration.Summary, err error) {
+	if reporter.afterBaseline != nil {
+		reporter.afterBaseline(migrations, err)
+	}
+}
+
+func (reporter *customReporter) MigrationMarked(migration *migration.Summary, err error) {
+	if reporter.migrationMarked != nil {
+		reporter.migrationMarked(migration, err)
+	}
+}
+
 func (reporter *customReporter) AfterReset(rewindSummary []*migration.Summary, migrateSummary []*migration.Summary, err error) {
 	if reporter.afterReset != nil {
 		reporter.afterReset(rewindSummary, migrateSummary, err)
@@ -94,12 +119,54 @@ func (reporter *customReporter) ListExecuted(migrations []migration.Migration, e
 	}
 }
 
+func (reporter *customReporter) ListHistory(records []*migration.HistoryRecord, err error) {
+	if reporter.listHistory != nil {
+		reporter.listHistory(records, err)
+	}
+}
+
 func (reporter *customReporter) MigrationsStarved(migrations []migration.Migration) {
 	if reporter.migrationStarved != nil {
 		reporter.migrationStarved(migrations)
 	}
 }
 
+func (reporter *customReporter) ChecksumMismatches(mismatches []*migration.ChecksumMismatch) {
+	if reporter.mismatches != nil {
+		reporter.mismatches(mismatches)
+	}
+}
+
+func (reporter *customReporter) AfterVerify(mismatches []*migration.ChecksumMismatch, err error) {
+	if reporter.afterVerify != nil {
+		reporter.afterVerify(mismatches, err)
+	}
+}
+
+func (reporter *customReporter) Residues(residues []*migration.Residue) {
+	if reporter.residues != nil {
+		reporter.residues(residues)
+	}
+}
+
+func (reporter *customReporter) ListPlan(plan *migration.Plan, err error) {
+	if reporter.listPlan != nil {
+		reporter.listPlan(plan, err)
+	}
+}
+
+func (reporter *customReporter) ListStatus(rows []*migration.StatusRow, err error) {
+	if reporter.listStatus != nil {
+		reporter.listStatus(rows, err)
+	}
+}
+
+func (reporter *customReporter) SQLScript(script string, err error) {
+	if reporter.sqlScript != nil {
+		reporter.sqlScript(script, err)
+	}
+}
+
 func (reporter *customReporter) Failure(err error) {
 	if reporter.failure != nil {
 		reporter.failure(err)
@@ -130,6 +197,10 @@ func (reporter *customReporter) NoCommand() {
 	}
 }
 
+func nopHandler(executionContext interface{}) error {
+	return nil
+}
+
 var _ = Describe("RunnerArgs", func() {
 	It("should create a new instance", func() {
 		manager := migration.NewDefaultManager(&nopTarget{}, migration.NewCodeSource())
@@ -230,6 +301,602 @@ var _ = Describe("RunnerArgs", func() {
 		Expect(ran).To(BeTrue())
 	})
 
+	It("should run the migrate-to command", func() {
+		m1 := migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler, nopHandler)
+		m2 := migration.NewMigration(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), "Description 2", nopHandler, nopHandler)
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		source.Register(m2)
+		target := &nopTarget{}
+		manager := migration.NewDefaultManager(target, source)
+		var summaries []*migration.Summary
+		r := migration.NewArgsRunnerCustom(&customReporter{
+			afterMigrate: func(migrations []*migration.Summary, err error) {
+				Expect(err).ToNot(HaveOccurred())
+				summaries = migrations
+			},
+		}, manager, func(code int) {}, "migrate-to", "20010101000000")
+		r.Run(nil)
+		Expect(summaries).To(HaveLen(1))
+		Expect(summaries[0].Migration).To(Equal(m1))
+		Expect(target.Version()).To(Equal(m1.GetID()))
+	})
+
+	It("should run the baseline command", func() {
+		notRun := func(executionContext interface{}) error {
+			Fail("baselined migrations should not run")
+			return nil
+		}
+		m1 := migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", notRun, notRun)
+		m2 := migration.NewMigration(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), "Description 2", notRun, notRun)
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		source.Register(m2)
+		target := &nopTarget{}
+		manager := migration.NewDefaultManager(target, source)
+		var summaries []*migration.Summary
+		r := migration.NewArgsRunnerCustom(&customReporter{
+			afterBaseline: func(migrations []*migration.Summary, err error) {
+				Expect(err).ToNot(HaveOccurred())
+				summaries = migrations
+			},
+		}, manager, func(code int) {}, "baseline", "20010101000000")
+		r.Run(nil)
+		Expect(summaries).To(HaveLen(1))
+		Expect(summaries[0].Migration).To(Equal(m1))
+		Expect(summaries[0].Baselined()).To(BeTrue())
+		Expect(target.Version()).To(Equal(m1.GetID()))
+	})
+
+	It("should run the mark-applied and mark-reverted commands", func() {
+		m1 := migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", func(executionContext interface{}) error {
+			Fail("marked migrations should not run")
+			return nil
+		})
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		target := &nopTarget{}
+		manager := migration.NewDefaultManager(target, source)
+		var marked []*migration.Summary
+		reporter := &customReporter{
+			migrationMarked: func(summary *migration.Summary, err error) {
+				Expect(err).ToNot(HaveOccurred())
+				marked = append(marked, summary)
+			},
+		}
+
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "mark-applied", "20010101000000", "--force").Run(nil)
+		Expect(target.Version()).To(Equal(m1.GetID()))
+
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "mark-reverted", "--force", "20010101000000").Run(nil)
+		Expect(target.Version()).To(Equal(migration.NoVersion))
+
+		Expect(marked).To(HaveLen(2))
+		Expect(marked[0].Direction()).To(Equal(migration.DirectionDo))
+		Expect(marked[1].Direction()).To(Equal(migration.DirectionUndo))
+	})
+
+	It("should require --force to mark migrations", func() {
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler))
+		target := &nopTarget{}
+		manager := migration.NewDefaultManager(target, source)
+		for _, command := range []string{"mark-applied", "mark-reverted"} {
+			var failure error
+			exitCode := 0
+			r := migration.NewArgsRunnerCustom(&customReporter{
+				failure: func(err error) {
+					failure = err
+				},
+				exit: func(code int) {
+					exitCode = code
+				},
+				migrationMarked: func(summary *migration.Summary, err error) {
+					Fail("the migration should not be marked")
+				},
+			}, manager, func(code int) {}, command, "20010101000000")
+			r.Run(nil)
+			Expect(failure).To(HaveOccurred())
+			Expect(failure.Error()).To(ContainSubstring("--force"))
+			Expect(exitCode).To(Equal(1))
+		}
+		Expect(target.Version()).To(Equal(migration.NoVersion))
+	})
+
+	It("should run the rewind-to command", func() {
+		m1 := migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler, nopHandler)
+		m2 := migration.NewMigration(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), "Description 2", nopHandler, nopHandler)
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		source.Register(m2)
+		target := &nopTarget{}
+		target.AddMigration(migration.NewSummary(m1))
+		target.AddMigration(migration.NewSummary(m2))
+		manager := migration.NewDefaultManager(target, source)
+		var summaries []*migration.Summary
+		r := migration.NewArgsRunnerCustom(&customReporter{
+			afterRewind: func(migrations []*migration.Summary, err error) {
+				Expect(err).ToNot(HaveOccurred())
+				summaries = migrations
+			},
+		}, manager, func(code int) {}, "rewind-to", "20010101000000")
+		r.Run(nil)
+		Expect(summaries).To(HaveLen(1))
+		Expect(summaries[0].Migration).To(Equal(m2))
+		Expect(target.Version()).To(Equal(m1.GetID()))
+	})
+
+	It("should report a version that does not exist", func() {
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1"))
+		manager := migration.NewDefaultManager(&nopTarget{}, source)
+		var migrateErr error
+		r := migration.NewArgsRunnerCustom(&customReporter{
+			afterMigrate: func(migrations []*migration.Summary, err error) {
+				migrateErr = err
+			},
+		}, manager, func(code int) {}, "migrate-to", "20020101000000")
+		r.Run(nil)
+		Expect(migrateErr).To(Equal(migration.ErrMigrationNotFound))
+	})
+
+	It("should fail when the version is missing or invalid", func() {
+		manager := migration.NewDefaultManager(&nopTarget{}, migration.NewCodeSource())
+		for _, args := range [][]string{{"migrate-to"}, {"rewind-to", "yesterday"}, {"migrate-to", "20010101000000", "20020101000000"}} {
+			var failure error
+			exitCode := 0
+			r := migration.NewArgsRunnerCustom(&customReporter{
+				failure: func(err error) {
+					failure = err
+				},
+				exit: func(code int) {
+					exitCode = code
+				},
+				afterMigrate: func(migrations []*migration.Summary, err error) {
+					Fail("migrate should not run")
+				},
+				afterRewind: func(migrations []*migration.Summary, err error) {
+					Fail("rewind should not run")
+				},
+			}, manager, func(code int) {}, args...)
+			r.Run(nil)
+			Expect(failure).To(HaveOccurred())
+			Expect(failure.Error()).To(HavePrefix(args[0] + ": "))
+			Expect(exitCode).To(Equal(1))
+		}
+	})
+
+	Describe("managers implementing only the migration.Manager", func() {
+		// basicManager hides the optional interfaces of the wrapped manager.
+		type basicManager struct {
+			migration.Manager
+		}
+
+		var (
+			source *migration.CodeSource
+			target *nopTarget
+		)
+
+		BeforeEach(func() {
+			source = migration.NewCodeSource()
+			source.Register(migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler, nopHandler))
+			target = &nopTarget{}
+		})
+
+		It("should run the commands of the migration.Manager", func() {
+			var summaries []*migration.Summary
+			migration.NewArgsRunnerCustom(&customReporter{
+				afterMigrate: func(s []*migration.Summary, err error) {
+					Expect(err).ToNot(HaveOccurred())
+					summaries = s
+				},
+			}, &basicManager{migration.NewDefaultManager(target, source)}, func(code int) {}, "migrate").Run(nil)
+			Expect(summaries).To(HaveLen(1))
+			Expect(target.executed).To(HaveLen(1))
+		})
+
+		It("should fail the commands the manager does not support", func() {
+			for _, args := range [][]string{
+				{"migrate-to", "20010101000000"},
+				{"history"},
+				{"baseline", "20010101000000"},
+				{"plan", "migrate"},
+			} {
+				var failure error
+				exitCode := 0
+				migration.NewArgsRunnerCustom(&customReporter{
+					failure: func(err error) {
+						failure = err
+					},
+					exit: func(code int) {
+						exitCode = code
+					},
+				}, &basicManager{migration.NewDefaultManager(target, source)}, func(code int) {}, args...).Run(nil)
+				Expect(failure).To(MatchError(args[0]+": the manager does not support this command"), args[0])
+				Expect(exitCode).To(Equal(1))
+			}
+			Expect(target.executed).To(BeEmpty())
+		})
+	})
+
+	Describe("reporters implementing only the migration.Reporter", func() {
+		// basicReporter hides the optional interfaces of the wrapped reporter.
+		type basicReporter struct {
+			migration.Reporter
+		}
+
+		It("should fail the commands the reporter does not support", func() {
+			source := migration.NewCodeSource()
+			source.Register(migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler, nopHandler))
+			target := &nopTarget{}
+
+			for _, args := range [][]string{
+				{"history"},
+				{"status"},
+				{"verify"},
+				{"baseline", "20010101000000"},
+				{"mark-applied", "20010101000000", "--force"},
+				{"mark-reverted", "20010101000000", "--force"},
+				{"plan", "migrate"},
+				{"sql", "migrate"},
+			} {
+				var failure error
+				exitCode := 0
+				migration.NewArgsRunnerCustom(&basicReporter{&customReporter{
+					failure: func(err error) {
+						failure = err
+					},
+					exit: func(code int) {
+						exitCode = code
+					},
+				}}, migration.NewManager(target, source), func(code int) {}, args...).Run(nil)
+				Expect(failure).To(MatchError(args[0]+": the reporter does not support this command"), args[0])
+				Expect(exitCode).To(Equal(1))
+			}
+			Expect(target.executed).To(BeEmpty())
+		})
+	})
+
+	Describe("steps", func() {
+		var (
+			m1, m2, m3 migration.Migration
+			target     *nopTarget
+			manager    migration.Manager
+			summaries  []*migration.Summary
+			reporter   *customReporter
+		)
+
+		BeforeEach(func() {
+			m1 = migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler, nopHandler)
+			m2 = migration.NewMigration(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), "Description 2", nopHandler, nopHandler)
+			m3 = migration.NewMigration(time.Date(2003, 1, 1, 0, 0, 0, 0, time.UTC), "Description 3", nopHandler, nopHandler)
+			source := migration.NewCodeSource()
+			source.Register(m1)
+			source.Register(m2)
+			source.Register(m3)
+			target = &nopTarget{}
+			manager = migration.NewDefaultManager(target, source)
+			summaries = nil
+			reporter = &customReporter{
+				migrationSummary: func(summary *migration.Summary, err error) {
+					Expect(err).ToNot(HaveOccurred())
+					summaries = append(summaries, summary)
+				},
+				failure: func(err error) {
+					Fail(err.Error())
+				},
+			}
+		})
+
+		It("should run the do command with a number of steps", func() {
+			migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "do", "2").Run(nil)
+			Expect(summaries).To(HaveLen(2))
+			Expect(summaries[0].Migration).To(Equal(m1))
+			Expect(summaries[1].Migration).To(Equal(m2))
+			Expect(target.Version()).To(Equal(m2.GetID()))
+		})
+
+		It("should stop doing when there is nothing else to be done", func() {
+			migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "do", "5").Run(nil)
+			Expect(summaries).To(HaveLen(4))
+			Expect(summaries[2].Migration).To(Equal(m3))
+			Expect(summaries[3]).To(BeNil())
+		})
+
+		It("should run the undo command with a number of steps", func() {
+			target.AddMigration(migration.NewSummary(m1))
+			target.AddMigration(migration.NewSummary(m2))
+			target.AddMigration(migration.NewSummary(m3))
+
+			migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "undo", "2").Run(nil)
+			Expect(summaries).To(HaveLen(2))
+			Expect(summaries[0].Migration).To(Equal(m3))
+			Expect(summaries[1].Migration).To(Equal(m2))
+			Expect(target.Version()).To(Equal(m1.GetID()))
+		})
+
+		It("should stop at the first failure", func() {
+			m2 = migration.NewMigration(m2.GetID(), "Description 2", func(executionContext interface{}) error {
+				return errors.New("forced error")
+			})
+			source := migration.NewCodeSource()
+			source.Register(m1)
+			source.Register(m2)
+			source.Register(m3)
+			manager = migration.NewDefaultManager(target, source)
+
+			var (
+				errs     []error
+				failure  error
+				exitCode int
+			)
+			reporter.migrationSummary = func(summary *migration.Summary, err error) {
+				summaries = append(summaries, summary)
+				errs = append(errs, err)
+			}
+			reporter.failure = func(err error) {
+				failure = err
+			}
+			reporter.exit = func(code int) {
+				exitCode = code
+			}
+			migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "do", "3").Run(nil)
+			Expect(summaries).To(HaveLen(2))
+			Expect(errs[0]).ToNot(HaveOccurred())
+			Expect(errs[1]).To(MatchError("forced error"))
+			Expect(failure).To(MatchError("forced error"))
+			Expect(exitCode).To(Equal(11))
+			Expect(target.Version()).To(Equal(m1.GetID()))
+		})
+
+		It("should exit with an error code when the run is aborted", func() {
+			lockerTarget := &LockerTarget{}
+			lockerTarget.lockErr = migration.ErrLockTimeout
+			lockerTarget.nopTarget.AddMigration(migration.NewSummary(m1))
+
+			for _, command := range []string{"do", "undo", "redo"} {
+				var failure error
+				exitCode := 0
+				reporter.migrationSummary = nil
+				reporter.failure = func(err error) {
+					failure = err
+				}
+				reporter.exit = func(code int) {
+					exitCode = code
+				}
+				migration.NewArgsRunnerCustom(reporter, migration.NewManager(lockerTarget, migration.NewCodeSource()), func(code int) {}, command).Run(nil)
+				Expect(failure).To(Equal(migration.ErrLockTimeout), command)
+				Expect(exitCode).To(Equal(11), command)
+			}
+		})
+
+		It("should run the redo command", func() {
+			target.AddMigration(migration.NewSummary(m1))
+
+			migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "redo").Run(nil)
+			Expect(summaries).To(HaveLen(2))
+			Expect(summaries[0].Migration).To(Equal(m1))
+			Expect(summaries[0].Direction()).To(Equal(migration.DirectionUndo))
+			Expect(summaries[1].Migration).To(Equal(m1))
+			Expect(summaries[1].Direction()).To(Equal(migration.DirectionDo))
+			Expect(target.Version()).To(Equal(m1.GetID()))
+		})
+
+		It("should fail with invalid arguments", func() {
+			for _, args := range [][]string{{"do", "0"}, {"undo", "-1"}, {"do", "two"}, {"do", "1", "2"}, {"redo", "1"}, {"migrate", "now"}, {"pending", "all"}} {
+				var failure error
+				exitCode := 0
+				migration.NewArgsRunnerCustom(&customReporter{
+					failure: func(err error) {
+						failure = err
+					},
+					exit: func(code int) {
+						exitCode = code
+					},
+					beforeMigration: func(summary migration.Summary, err error) {
+						Fail("no migration should run")
+					},
+					listPending: func(migrations []migration.Migration, err error) {
+						Fail("pending should not run")
+					},
+				}, manager, func(code int) {}, args...).Run(nil)
+				Expect(failure).To(HaveOccurred(), "%v", args)
+				Expect(failure.Error()).To(HavePrefix(args[0] + ": "))
+				Expect(exitCode).To(Equal(1))
+			}
+		})
+	})
+
+	It("should run the history command", func() {
+		m := migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler)
+		source := migration.NewCodeSource()
+		source.Register(m)
+		manager := migration.NewDefaultManager(&nopTarget{}, source)
+		_, err := manager.Migrate(&nopReporter{}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		var history []*migration.HistoryRecord
+		migration.NewArgsRunnerCustom(&customReporter{
+			listHistory: func(records []*migration.HistoryRecord, err error) {
+				Expect(err).ToNot(HaveOccurred())
+				history = records
+			},
+		}, manager, func(code int) {}, "history").Run(nil)
+		Expect(history).To(HaveLen(1))
+		Expect(history[0].ID).To(Equal(m.GetID()))
+
+		output := bytes.NewBuffer(nil)
+		migration.NewArgsRunnerCustom(migration.NewDefaultReporterWithParams(output, func(code int) {}), manager, func(code int) {}, "history").Run(nil)
+		Expect(output.String()).To(ContainSubstring("1 history records:"))
+		Expect(output.String()).To(ContainSubstring("20010101000000"))
+	})
+
+	It("should run the verify command", func() {
+		m := migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler).WithChecksum("v1")
+		source := migration.NewCodeSource()
+		source.Register(m)
+		manager := migration.NewDefaultManager(&nopTarget{}, source)
+		_, err := manager.Migrate(&nopReporter{}, nil)
+		Expect(err).ToNot(HaveOccurred())
+
+		exitCode := 0
+		output := bytes.NewBuffer(nil)
+		reporter := migration.NewDefaultReporterWithParams(output, func(code int) {
+			exitCode = code
+		})
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "verify").Run(nil)
+		Expect(exitCode).To(Equal(0))
+
+		m.WithChecksum("v2")
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "verify").Run(nil)
+		Expect(exitCode).To(Equal(10))
+		Expect(output.String()).To(ContainSubstring("Checksum mismatch detected in 1 migrations"))
+		Expect(output.String()).To(ContainSubstring("expected v1, got v2"))
+	})
+
+	It("should run the status command", func() {
+		m1 := migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler)
+		m2 := migration.NewMigration(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), "Description 2", nopHandler)
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		target := &nopTarget{}
+		target.AddMigration(migration.NewSummary(m1))
+		manager := migration.NewDefaultManager(target, source)
+
+		var rows []*migration.StatusRow
+		migration.NewArgsRunnerCustom(&customReporter{
+			listStatus: func(r []*migration.StatusRow, err error) {
+				Expect(err).ToNot(HaveOccurred())
+				rows = r
+			},
+		}, manager, func(code int) {}, "status").Run(nil)
+		Expect(rows).To(HaveLen(1))
+		Expect(rows[0].State).To(Equal(migration.StateApplied))
+
+		output := bytes.NewBuffer(nil)
+		exitCode := 0
+		reporter := migration.NewDefaultReporterWithParams(output, func(code int) {
+			exitCode = code
+		})
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "status").Run(nil)
+		Expect(exitCode).To(Equal(0))
+		Expect(output.String()).To(ContainSubstring("applied"))
+		Expect(output.String()).To(ContainSubstring("Description 1"))
+
+		target.AddMigration(migration.NewSummary(m2))
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "status").Run(nil)
+		Expect(exitCode).To(Equal(10))
+		Expect(output.String()).To(ContainSubstring("20020101000000"))
+		Expect(output.String()).To(ContainSubstring("orphaned"))
+		Expect(output.String()).To(ContainSubstring("1 migrations need attention."))
+	})
+
+	It("should run the plan command", func() {
+		m1 := migration.NewMigration(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), "Description 1", nopHandler, nopHandler)
+		m2 := migration.NewMigration(time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), "Description 2", nopHandler, nopHandler)
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		source.Register(m2)
+		target := &nopTarget{}
+		target.AddMigration(migration.NewSummary(m1))
+		manager := migration.NewDefaultManager(target, source)
+
+		for args, expected := range map[string][]string{
+			"migrate":                   {"do Description 2"},
+			"migrate-to 20020101000000": {"do Description 2"},
+			"rewind":                    {"undo Description 1"},
+			"rewind-to 20010101000000":  {},
+			"reset":                     {"undo Description 1", "do Description 1", "do Description 2"},
+		} {
+			var plan *migration.Plan
+			migration.NewArgsRunnerCustom(&customReporter{
+				listPlan: func(p *migration.Plan, err error) {
+					Expect(err).ToNot(HaveOccurred())
+					plan = p
+				},
+			}, manager, func(code int) {}, append([]string{"plan"}, strings.Fields(args)...)...).Run(nil)
+			Expect(plan).ToNot(BeNil(), args)
+			steps := make([]string, len(plan.Steps))
+			for i, step := range plan.Steps {
+				steps[i] = step.Direction.String() + " " + step.Migration.GetDescription()
+			}
+			Expect(steps).To(Equal(expected), args)
+		}
+		Expect(target.Version()).To(Equal(m1.GetID()))
+
+		output := bytes.NewBuffer(nil)
+		exitCode := 0
+		reporter := migration.NewDefaultReporterWithParams(output, func(code int) {
+			exitCode = code
+		})
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "plan", "reset").Run(nil)
+		Expect(exitCode).To(Equal(0))
+		Expect(output.String()).To(ContainSubstring("reset would take 3 steps:"))
+		Expect(output.String()).To(ContainSubstring("20020101000000"))
+
+		target.AddMigration(migration.NewSummary(m2))
+		target.RemoveMigration(migration.NewSummary(m1))
+		migration.NewArgsRunnerCustom(reporter, manager, func(code int) {}, "plan", "migrate").Run(nil)
+		Expect(exitCode).To(Equal(10))
+		Expect(output.String()).To(ContainSubstring("Starvation detected in 1 migrations"))
+		Expect(output.String()).To(ContainSubstring("migrate would fail"))
+	})
+
+	It("should fail when the command to plan is missing or invalid", func() {
+		manager := migration.NewDefaultManager(&nopTarget{}, migration.NewCodeSource())
+		for _, args := range [][]string{{"plan"}, {"plan", "do"}, {"plan", "migrate", "now"}, {"plan", "migrate-to"}} {
+			var failure error
+			exitCode := 0
+			migration.NewArgsRunnerCustom(&customReporter{
+				failure: func(err error) {
+					failure = err
+				},
+				exit: func(code int) {
+					exitCode = code
+				},
+				listPlan: func(plan *migration.Plan, err error) {
+					Fail("plan should not be listed")
+				},
+			}, manager, func(code int) {}, args...).Run(nil)
+			Expect(failure).To(HaveOccurred())
+			Expect(failure.Error()).To(HavePrefix("plan"))
+			Expect(exitCode).To(Equal(1))
+		}
+	})
+
+	It("should report the SQL script of the command", func() {
+		manager := migration.NewDefaultManager(&nopTarget{}, migration.NewCodeSource())
+		for _, args := range [][]string{{"sql", "migrate"}, {"sql", "reset"}} {
+			var failure error
+			migration.NewArgsRunnerCustom(&customReporter{
+				sqlScript: func(script string, err error) {
+					Expect(script).To(BeEmpty())
+					failure = err
+				},
+			}, manager, func(code int) {}, args...).Run(nil)
+			Expect(failure).To(Equal(migration.ErrScriptNotSupported))
+		}
+
+		for _, args := range [][]string{{"sql"}, {"sql", "do"}, {"sql", "rewind", "now"}} {
+			var failure error
+			exitCode := 0
+			migration.NewArgsRunnerCustom(&customReporter{
+				failure: func(err error) {
+					failure = err
+				},
+				exit: func(code int) {
+					exitCode = code
+				},
+				sqlScript: func(script string, err error) {
+					Fail("script should not be reported")
+				},
+			}, manager, func(code int) {}, args...).Run(nil)
+			Expect(failure).To(HaveOccurred())
+			Expect(failure.Error()).To(HavePrefix("sql"))
+			Expect(exitCode).To(Equal(1))
+		}
+	})
+
 	It("should run the reset command", func() {
 		ran := false
 		m := migration.NewMigration(time.Now(), "Description 1")
@@ -301,4 +968,22 @@ var _ = Describe("RunnerArgs", func() {
 		Expect(ran).To(BeTrue())
 		Expect(target.BeforeRuns).To(Equal(1))
 	})
+
+	It("should run the migrate command with a context", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var migrateErr error
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(time.Now(), "Description 1"))
+		manager := migration.NewDefaultManager(&nopTarget{}, source)
+		r := migration.NewArgsRunnerCustom(&customReporter{
+			afterMigrate: func(migrations []*migration.Summary, err error) {
+				migrateErr = err
+			},
+		}, manager, func(code int) {}, "migrate")
+		Expect(r).To(BeAssignableToTypeOf(&migration.ArgsRunner{}))
+		r.(migration.ContextRunner).RunContext(ctx, nil)
+		Expect(migrateErr).To(Equal(context.Canceled))
+	})
 })
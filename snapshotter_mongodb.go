@@ -0,0 +1,92 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/globalsign/mgo"
+)
+
+// MongoDBSnapshotter implements the migration.Snapshotter of the MongoDB. The
+// snapshot lists the collections and their indexes.
+//
+// The collections used by the migration.MongoDBTarget (with the default
+// names) are ignored, as they are created on demand.
+type MongoDBSnapshotter struct {
+	db     *mgo.Database
+	ignore map[string]bool
+}
+
+// NewMongoDBSnapshotter returns a new instance of the
+// migration.MongoDBSnapshotter.
+func NewMongoDBSnapshotter(db *mgo.Database) *MongoDBSnapshotter {
+	return (&MongoDBSnapshotter{
+		db:     db,
+		ignore: make(map[string]bool),
+	}).Ignore(DefaultMigrationTable, DefaultMigrationTable+"_history", DefaultMigrationTable+"_lock", DefaultMigrationTable+"_repeatable")
+}
+
+// Ignore removes the given collections from the snapshots (eg. when the
+// migration.MongoDBTarget uses a custom collection name).
+//
+// It returns itself for sugar syntax.
+func (snapshotter *MongoDBSnapshotter) Ignore(collections ...string) *MongoDBSnapshotter {
+	for _, collection := range collections {
+		snapshotter.ignore[collection] = true
+	}
+	return snapshotter
+}
+
+// Snapshot implements the migration.Snapshotter.
+func (snapshotter *MongoDBSnapshotter) Snapshot(ctx context.Context) (Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	names, err := snapshotter.db.CollectionNames()
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := make(Snapshot, 0)
+	for _, name := range names {
+		if snapshotter.ignore[name] || strings.HasPrefix(name, "system.") {
+			continue
+		}
+		snapshot = append(snapshot, "collection "+name)
+
+		indexes, err := snapshotter.db.C(name).Indexes()
+		if err != nil {
+			return nil, err
+		}
+		for _, index := range indexes {
+			snapshot = append(snapshot, fmt.Sprintf("index %s.%s %s%s", name, index.Name, strings.Join(index.Key, ","), mongoDBIndexOptions(index)))
+		}
+	}
+	sort.Strings(snapshot)
+	return snapshot, nil
+}
+
+// mongoDBIndexOptions describes the options of the index that change its
+// behavior.
+func mongoDBIndexOptions(index mgo.Index) string {
+	options := make([]string, 0)
+	if index.Unique {
+		options = append(options, "unique")
+	}
+	if index.Sparse {
+		options = append(options, "sparse")
+	}
+	if index.ExpireAfter > 0 {
+		options = append(options, "expireAfter="+index.ExpireAfter.String())
+	}
+	if index.PartialFilter != nil {
+		options = append(options, fmt.Sprintf("partialFilter=%v", index.PartialFilter))
+	}
+	if len(options) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(options, ", ") + ")"
+}
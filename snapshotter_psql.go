@@ -0,0 +1,91 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+// PostgreSQLSnapshotter implements the migration.Snapshotter of the
+// PostgreSQL. The snapshot lists the tables, columns, constraints, indexes
+// and sequences of every schema but the system ones.
+type PostgreSQLSnapshotter struct {
+	db *sql.DB
+}
+
+// NewPostgreSQLSnapshotter returns a new instance of the
+// migration.PostgreSQLSnapshotter.
+func NewPostgreSQLSnapshotter(db *sql.DB) *PostgreSQLSnapshotter {
+	return &PostgreSQLSnapshotter{
+		db: db,
+	}
+}
+
+// postgreSQLSnapshotQueries are the queries that compose the snapshot. Each
+// row becomes an entry, with its columns separated by spaces.
+var postgreSQLSnapshotQueries = []string{
+	`SELECT 'table', table_schema, table_name, table_type
+		FROM information_schema.tables
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema')`,
+	`SELECT 'column', table_schema || '.' || table_name || '.' || column_name, data_type, is_nullable, COALESCE(column_default, '')
+		FROM information_schema.columns
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema')`,
+	`SELECT 'constraint', constraint_schema || '.' || table_name || '.' || constraint_name, constraint_type
+		FROM information_schema.table_constraints
+		WHERE constraint_schema NOT IN ('pg_catalog', 'information_schema')`,
+	`SELECT 'index', schemaname || '.' || tablename || '.' || indexname, indexdef
+		FROM pg_indexes
+		WHERE schemaname NOT IN ('pg_catalog', 'information_schema')`,
+	`SELECT 'sequence', sequence_schema || '.' || sequence_name, data_type
+		FROM information_schema.sequences
+		WHERE sequence_schema NOT IN ('pg_catalog', 'information_schema')`,
+}
+
+// Snapshot implements the migration.Snapshotter.
+func (snapshotter *PostgreSQLSnapshotter) Snapshot(ctx context.Context) (Snapshot, error) {
+	snapshot := make(Snapshot, 0)
+	for _, query := range postgreSQLSnapshotQueries {
+		entries, err := snapshotter.query(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		snapshot = append(snapshot, entries...)
+	}
+	sort.Strings(snapshot)
+	return snapshot, nil
+}
+
+func (snapshotter *PostgreSQLSnapshotter) query(ctx context.Context, query string) ([]string, error) {
+	rows, err := snapshotter.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]string, 0)
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		fields := make([]string, len(values))
+		for i, value := range values {
+			fields[i] = value.String
+		}
+		entries = append(entries, strings.Join(fields, " "))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -12,3 +12,12 @@ type Source interface {
 	// List lists all migrations available for this migrations.Source.
 	List() ([]Migration, error)
 }
+
+// RepeatableSource is implemented by sources that also provide
+// migration.Repeatable migrations. They are listed apart, as they are not
+// versioned.
+type RepeatableSource interface {
+	// ListRepeatable lists all the repeatable migrations available for this
+	// migrations.Source.
+	ListRepeatable() ([]Migration, error)
+}
@@ -7,7 +7,8 @@ import "fmt"
 //
 // TODO add examples
 type CodeSource struct {
-	migrations []Migration
+	migrations  []Migration
+	repeatables []Migration
 }
 
 var defaultCode *CodeSource
@@ -31,7 +32,8 @@ func DefaultCodeSource() *CodeSource {
 // NewCodeSource returns a new instance of a migration.CodeSource.
 func NewCodeSource() *CodeSource {
 	return &CodeSource{
-		migrations: make([]Migration, 0),
+		migrations:  make([]Migration, 0),
+		repeatables: make([]Migration, 0),
 	}
 }
 
@@ -41,8 +43,20 @@ func (s *CodeSource) List() ([]Migration, error) {
 	return s.migrations, nil
 }
 
-// Register registers the migration for further use.
+// ListRepeatable implements the migration.RepeatableSource by listing all
+// the registered repeatable migrations of this instance, in the order they
+// were registered.
+func (s *CodeSource) ListRepeatable() ([]Migration, error) {
+	return s.repeatables, nil
+}
+
+// Register registers the migration for further use. Repeatable migrations
+// (see migration.Repeatable) are registered apart from the versioned ones.
 func (s *CodeSource) Register(migration Migration) {
+	if IsRepeatable(migration) {
+		s.registerRepeatable(migration)
+		return
+	}
 	for i := 0; i < len(s.migrations); i++ {
 		if s.migrations[i].GetID() == migration.GetID() {
 			panic(fmt.Sprintf("Migrations %s and %s have the same ID", s.migrations[i].GetDescription(), migration.GetDescription()))
@@ -55,6 +69,15 @@ func (s *CodeSource) Register(migration Migration) {
 	s.migrations = append(s.migrations, migration)
 }
 
+func (s *CodeSource) registerRepeatable(migration Migration) {
+	for _, m := range s.repeatables {
+		if m.GetDescription() == migration.GetDescription() {
+			panic(fmt.Sprintf("Repeatable migration %s is registered twice", migration.GetDescription()))
+		}
+	}
+	s.repeatables = append(s.repeatables, migration)
+}
+
 // Register registers the migration on the migration.DefaultCodeSource instance.
 func Register(migration Migration) {
 	defaultCode.Register(migration)
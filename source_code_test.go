@@ -19,6 +19,8 @@ type migrationMock struct {
 	donePanicData   interface{}
 	undoneErr       error
 	undonePanicData interface{}
+
+	executionContext interface{}
 }
 
 func (m *migrationMock) GetID() time.Time {
@@ -31,6 +33,7 @@ func (m *migrationMock) GetDescription() string {
 
 func (m *migrationMock) Do(executionContext interface{}) error {
 	m.done = true
+	m.executionContext = executionContext
 	if m.donePanicData != nil {
 		panic(m.donePanicData)
 	}
@@ -39,6 +42,7 @@ func (m *migrationMock) Do(executionContext interface{}) error {
 
 func (m *migrationMock) Undo(executionContext interface{}) error {
 	m.undone = true
+	m.executionContext = executionContext
 	if m.undonePanicData != nil {
 		panic(m.undonePanicData)
 	}
@@ -133,4 +137,31 @@ var _ = Describe("Source Code", func() {
 			Expect(list[2].GetDescription()).To(Equal("GetDescription 1"))
 		})
 	})
+
+	Describe("ListRepeatable", func() {
+		It("should list the repeatable migrations apart", func() {
+			d := migration.NewCodeSource()
+			d.Register(m1)
+			d.Register(migration.NewRepeatableMigration("View 2", nopHandler))
+			d.Register(migration.NewRepeatableMigration("View 1", nopHandler))
+
+			list, err := d.List()
+			Expect(err).To(BeNil())
+			Expect(list).To(HaveLen(1))
+
+			list, err = d.ListRepeatable()
+			Expect(err).To(BeNil())
+			Expect(list).To(HaveLen(2))
+			Expect(list[0].GetDescription()).To(Equal("View 2"))
+			Expect(list[1].GetDescription()).To(Equal("View 1"))
+		})
+
+		It("should panic when a repeatable migration is registered twice", func() {
+			d := migration.NewCodeSource()
+			d.Register(migration.NewRepeatableMigration("View", nopHandler))
+			Expect(func() {
+				d.Register(migration.NewRepeatableMigration("View", nopHandler))
+			}).To(Panic())
+		})
+	})
 })
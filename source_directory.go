@@ -30,6 +30,11 @@ import (
 // Invalid naming examples:
 //     20171525191747_Creates_user_table.down.sql    : Invalid date (month 15?)
 //     20171025191747_Creates_user_table.NNN.sql     : Invalid sufix (should be up or down)
+//
+// Repeatable migrations (see migration.Repeatable) use the pattern
+// R__<DESCRIPTION>.(extension) instead:
+//
+//     R__Users_view.sql                             : (Re)creates the users view
 type DirectorySource struct {
 	// Directory represents the path that the migrations file will be searched
 	// for.
@@ -72,7 +77,9 @@ func (s *DirectorySource) List() ([]Migration, error) {
 					migration := &FileMigration{
 						id:          id,
 						description: description,
+						directory:   s.Directory,
 						baseFile:    toks[0],
+						ext:         toks[2],
 						up:          toks[1] == "up",
 						down:        toks[1] == "down",
 					}
@@ -89,3 +96,36 @@ func (s *DirectorySource) List() ([]Migration, error) {
 	}
 	return nil, err
 }
+
+var directorySourceRepeatablePattern = regexp.MustCompile("^R__(.+)$")
+
+// ListRepeatable implements the migration.RepeatableSource by listing all the
+// R__<DESCRIPTION> files inside the migration.DirectorySource.Directory using
+// the migration.DirectorySource.Extension, sorted by their names.
+func (s *DirectorySource) ListRepeatable() ([]Migration, error) {
+	files, err := ioutil.ReadDir(s.Directory)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Migration, 0)
+	for _, f := range files {
+		toks := strings.Split(filepath.Base(f.Name()), ".")
+		if len(toks) != 2 || strings.ToLower(toks[1]) != strings.ToLower(s.Extension) {
+			continue
+		}
+		groups := directorySourceRepeatablePattern.FindStringSubmatch(toks[0])
+		if len(groups) != 2 {
+			continue
+		}
+		result = append(result, &FileMigration{
+			id:          NoVersion,
+			description: groups[1],
+			directory:   s.Directory,
+			baseFile:    toks[0],
+			ext:         toks[1],
+			up:          true,
+			repeatable:  true,
+		})
+	}
+	return result, nil
+}
@@ -25,4 +25,24 @@ var _ = Describe("Source Directory", func() {
 			Expect(ms[1].GetDescription()).To(Equal("description2"))
 		})
 	})
+
+	Describe("ListRepeatable", func() {
+		It("should list the repeatable migrations of a directory", func() {
+			d := migration.DirectorySource{
+				Directory: "test/repeatable",
+				Extension: "sql",
+			}
+			ms, err := d.ListRepeatable()
+			Expect(err).To(BeNil())
+			Expect(ms).To(HaveLen(1))
+			Expect(ms[0].GetID()).To(Equal(migration.NoVersion))
+			Expect(ms[0].GetDescription()).To(Equal("users_view"))
+			Expect(migration.IsRepeatable(ms[0])).To(BeTrue())
+
+			ms, err = d.List()
+			Expect(err).To(BeNil())
+			Expect(ms).To(HaveLen(1))
+			Expect(migration.IsRepeatable(ms[0])).To(BeFalse())
+		})
+	})
 })
@@ -0,0 +1,377 @@
+// Package splitter breaks SQL scripts into the statements that compose them.
+//
+// A naive split on semicolons breaks whenever a semicolon shows up inside of a
+// string literal, a comment, a PostgreSQL dollar quoted body or a MySQL (or
+// SQLite) `BEGIN ... END` block. The splitter.Split understands those constructs,
+// according to the splitter.Dialect informed, and keeps them together.
+package splitter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect is the enum that represents the SQL flavour used to split a script.
+type Dialect uint
+
+const (
+	// Generic understands single quoted strings, double quoted identifiers,
+	// `--` and `/* */` comments.
+	Generic Dialect = iota
+	// PostgreSQL adds dollar quoted bodies (`$$ ... $$` and `$tag$ ... $tag$`),
+	// escape strings (`E'...'`) and nested block comments to the Generic
+	// dialect.
+	PostgreSQL
+	// MySQL adds backslash escapes, backtick identifiers, `#` comments and
+	// `BEGIN ... END` blocks (used by triggers and procedures) to the Generic
+	// dialect.
+	MySQL
+	// SQLite adds the `BEGIN ... END` blocks of triggers to the Generic
+	// dialect.
+	SQLite
+)
+
+// String returns the name of the dialect.
+func (d Dialect) String() string {
+	switch d {
+	case PostgreSQL:
+		return "postgresql"
+	case MySQL:
+		return "mysql"
+	case SQLite:
+		return "sqlite"
+	default:
+		return "generic"
+	}
+}
+
+// Statement is a single SQL statement extracted from a script.
+type Statement struct {
+	// SQL is the text of the statement, without the trailing semicolon.
+	SQL string
+
+	// Line is the line (starting from 1) where the statement starts in the
+	// script.
+	Line int
+
+	// Compound is set when the statement has semicolons inside of a
+	// `BEGIN ... END` block (eg. a MySQL trigger or stored procedure). Such
+	// statements need a custom `DELIMITER` to be run by the MySQL clients.
+	Compound bool
+}
+
+// SyntaxError is returned when the script has a construct that was never
+// closed (eg. a string literal missing its closing quote).
+type SyntaxError struct {
+	Line    int
+	Message string
+}
+
+// Error implements the error interface.
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", err.Line, err.Message)
+}
+
+// Split breaks the `script` into its statements, using the `dialect` rules.
+//
+// Statements containing only whitespaces and comments are dropped.
+func Split(script string, dialect Dialect) ([]Statement, error) {
+	s := &scanner{
+		src:     script,
+		dialect: dialect,
+		line:    1,
+	}
+	return s.split()
+}
+
+type scanner struct {
+	src     string
+	dialect Dialect
+	pos     int
+	line    int
+
+	statements []Statement
+	start      int
+	startLine  int
+	hasCode    bool
+	depth      int
+	compound   bool
+}
+
+func (s *scanner) split() ([]Statement, error) {
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == '\n':
+			s.line++
+			s.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			s.pos++
+		case c == '-' && s.peek(1) == '-':
+			s.skipLineComment()
+		case c == '#' && s.dialect == MySQL:
+			s.skipLineComment()
+		case c == '/' && s.peek(1) == '*':
+			if err := s.skipBlockComment(); err != nil {
+				return nil, err
+			}
+		case c == ';':
+			if s.depth > 0 {
+				s.compound = true
+				s.pos++
+				continue
+			}
+			s.flush(s.pos)
+			s.pos++
+			s.start = s.pos
+		case c == '\'':
+			s.markCode()
+			escapes := s.dialect == MySQL || (s.dialect == PostgreSQL && s.isEscapeString())
+			if err := s.skipQuoted('\'', escapes); err != nil {
+				return nil, err
+			}
+		case c == '"':
+			s.markCode()
+			if err := s.skipQuoted('"', s.dialect == MySQL); err != nil {
+				return nil, err
+			}
+		case c == '`' && s.dialect == MySQL:
+			s.markCode()
+			if err := s.skipQuoted('`', false); err != nil {
+				return nil, err
+			}
+		case c == '$' && s.dialect == PostgreSQL:
+			s.markCode()
+			if err := s.skipDollarQuoted(); err != nil {
+				return nil, err
+			}
+		case isIdentStart(c):
+			s.markCode()
+			s.word()
+		default:
+			s.markCode()
+			s.pos++
+		}
+	}
+	s.flush(len(s.src))
+	return s.statements, nil
+}
+
+func (s *scanner) peek(offset int) byte {
+	if s.pos+offset < len(s.src) {
+		return s.src[s.pos+offset]
+	}
+	return 0
+}
+
+// markCode flags the current statement as having something other than
+// whitespaces and comments.
+func (s *scanner) markCode() {
+	if !s.hasCode {
+		s.hasCode = true
+		s.startLine = s.line
+	}
+}
+
+// flush appends the statement that goes from the start to `end` (exclusive)
+// to the result.
+func (s *scanner) flush(end int) {
+	if s.hasCode {
+		s.statements = append(s.statements, Statement{
+			SQL:      strings.TrimSpace(s.src[s.start:end]),
+			Line:     s.startLine,
+			Compound: s.compound,
+		})
+	}
+	s.hasCode = false
+	s.depth = 0
+	s.compound = false
+}
+
+func (s *scanner) skipLineComment() {
+	for s.pos < len(s.src) && s.src[s.pos] != '\n' {
+		s.pos++
+	}
+}
+
+func (s *scanner) skipBlockComment() error {
+	line := s.line
+	level := 0
+	for s.pos < len(s.src) {
+		switch {
+		case s.src[s.pos] == '/' && s.peek(1) == '*':
+			if level == 0 || s.dialect == PostgreSQL {
+				level++
+			}
+			s.pos += 2
+		case s.src[s.pos] == '*' && s.peek(1) == '/':
+			level--
+			s.pos += 2
+			if level == 0 {
+				return nil
+			}
+		default:
+			if s.src[s.pos] == '\n' {
+				s.line++
+			}
+			s.pos++
+		}
+	}
+	return &SyntaxError{Line: line, Message: "unterminated block comment"}
+}
+
+// isEscapeString checks if the string literal starting at the current
+// position has the PostgreSQL escape prefix (E'...').
+func (s *scanner) isEscapeString() bool {
+	if s.pos == 0 || (s.src[s.pos-1] != 'E' && s.src[s.pos-1] != 'e') {
+		return false
+	}
+	return s.pos == 1 || !isIdentChar(s.src[s.pos-2])
+}
+
+func (s *scanner) skipQuoted(quote byte, escapes bool) error {
+	line := s.line
+	s.pos++
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == '\\' && escapes:
+			if s.peek(1) == '\n' {
+				s.line++
+			}
+			s.pos += 2
+		case c == quote:
+			if s.peek(1) == quote {
+				s.pos += 2
+				continue
+			}
+			s.pos++
+			return nil
+		default:
+			if c == '\n' {
+				s.line++
+			}
+			s.pos++
+		}
+	}
+	return &SyntaxError{Line: line, Message: fmt.Sprintf("unterminated quoted text (%c)", quote)}
+}
+
+// skipDollarQuoted skips a PostgreSQL dollar quoted body. When the `$` does
+// not start a dollar quote (eg. a positional parameter `$1`), only the `$` is
+// consumed.
+func (s *scanner) skipDollarQuoted() error {
+	if s.pos > 0 && isIdentChar(s.src[s.pos-1]) {
+		s.pos++
+		return nil
+	}
+	end := s.pos + 1
+	if end < len(s.src) && isIdentStart(s.src[end]) {
+		for end < len(s.src) && isTagChar(s.src[end]) {
+			end++
+		}
+	}
+	if end >= len(s.src) || s.src[end] != '$' {
+		s.pos++
+		return nil
+	}
+	tag := s.src[s.pos : end+1]
+	line := s.line
+	closing := strings.Index(s.src[end+1:], tag)
+	if closing == -1 {
+		return &SyntaxError{Line: line, Message: fmt.Sprintf("unterminated dollar quoted text (%s)", tag)}
+	}
+	closing += end + 1
+	s.line += strings.Count(s.src[s.pos:closing], "\n")
+	s.pos = closing + len(tag)
+	return nil
+}
+
+// word consumes a keyword or identifier. For the MySQL and SQLite dialects,
+// it keeps track of the `BEGIN ... END` and `CASE ... END` blocks.
+func (s *scanner) word() {
+	w := s.readWord()
+	if s.dialect != MySQL && s.dialect != SQLite {
+		return
+	}
+	switch strings.ToUpper(w) {
+	case "BEGIN":
+		// `BEGIN;`, `BEGIN WORK` and `BEGIN TRANSACTION` (or, on SQLite,
+		// `BEGIN DEFERRED`, `BEGIN IMMEDIATE` and `BEGIN EXCLUSIVE`) start
+		// transactions, not blocks.
+		switch strings.ToUpper(s.lookAhead()) {
+		case ";", "WORK", "TRANSACTION", "DEFERRED", "IMMEDIATE", "EXCLUSIVE", "":
+		default:
+			s.depth++
+		}
+	case "CASE":
+		s.depth++
+	case "END":
+		switch strings.ToUpper(s.lookAhead()) {
+		case "IF", "LOOP", "WHILE", "REPEAT":
+			// Those blocks only exist inside of a BEGIN ... END block.
+			s.skipSpaces()
+			s.readWord()
+			return
+		case "CASE":
+			s.skipSpaces()
+			s.readWord()
+		}
+		if s.depth > 0 {
+			s.depth--
+		}
+	}
+}
+
+func (s *scanner) readWord() string {
+	start := s.pos
+	for s.pos < len(s.src) && isIdentChar(s.src[s.pos]) {
+		s.pos++
+	}
+	return s.src[start:s.pos]
+}
+
+// lookAhead returns the next word (or the next char, when it is not part of
+// a word) after the current position, without consuming it.
+func (s *scanner) lookAhead() string {
+	i := s.pos
+	for i < len(s.src) && isSpace(s.src[i]) {
+		i++
+	}
+	if i >= len(s.src) {
+		return ""
+	}
+	if !isIdentStart(s.src[i]) {
+		return s.src[i : i+1]
+	}
+	start := i
+	for i < len(s.src) && isIdentChar(s.src[i]) {
+		i++
+	}
+	return s.src[start:i]
+}
+
+func (s *scanner) skipSpaces() {
+	for s.pos < len(s.src) && isSpace(s.src[s.pos]) {
+		if s.src[s.pos] == '\n' {
+			s.line++
+		}
+		s.pos++
+	}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isTagChar(c byte) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func isIdentChar(c byte) bool {
+	return isTagChar(c) || c == '$'
+}
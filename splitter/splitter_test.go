@@ -0,0 +1,167 @@
+package splitter_test
+
+import (
+	"github.com/lab259/go-migration/splitter"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Split", func() {
+	sqls := func(statements []splitter.Statement) []string {
+		result := make([]string, len(statements))
+		for i, s := range statements {
+			result[i] = s.SQL
+		}
+		return result
+	}
+
+	Describe("Generic", func() {
+		It("should split statements by semicolons", func() {
+			statements, err := splitter.Split("CREATE TABLE a (id int);\nCREATE TABLE b (id int);\n", splitter.Generic)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statements).To(Equal([]splitter.Statement{
+				{SQL: "CREATE TABLE a (id int)", Line: 1},
+				{SQL: "CREATE TABLE b (id int)", Line: 2},
+			}))
+		})
+
+		It("should keep the last statement without a semicolon", func() {
+			statements, err := splitter.Split("SELECT 1;\nSELECT 2", splitter.Generic)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sqls(statements)).To(Equal([]string{"SELECT 1", "SELECT 2"}))
+		})
+
+		It("should not split semicolons inside of strings", func() {
+			statements, err := splitter.Split("INSERT INTO a VALUES ('a;b', 'it''s;');\nSELECT \"weird;name\" FROM a;", splitter.Generic)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sqls(statements)).To(Equal([]string{
+				"INSERT INTO a VALUES ('a;b', 'it''s;')",
+				"SELECT \"weird;name\" FROM a",
+			}))
+		})
+
+		It("should not split semicolons inside of comments", func() {
+			statements, err := splitter.Split("-- first; comment\nSELECT 1; /* block;\ncomment */\nSELECT 2;", splitter.Generic)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statements).To(HaveLen(2))
+			Expect(statements[0].SQL).To(Equal("-- first; comment\nSELECT 1"))
+			Expect(statements[0].Line).To(Equal(2))
+			Expect(statements[1].SQL).To(Equal("/* block;\ncomment */\nSELECT 2"))
+			Expect(statements[1].Line).To(Equal(4))
+		})
+
+		It("should drop statements with only comments and whitespaces", func() {
+			statements, err := splitter.Split("SELECT 1;\n;\n-- nothing here\n", splitter.Generic)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sqls(statements)).To(Equal([]string{"SELECT 1"}))
+		})
+
+		It("should fail with an unterminated string", func() {
+			_, err := splitter.Split("SELECT 1;\nSELECT 'abc;", splitter.Generic)
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(&splitter.SyntaxError{}))
+			Expect(err.(*splitter.SyntaxError).Line).To(Equal(2))
+		})
+
+		It("should fail with an unterminated block comment", func() {
+			_, err := splitter.Split("/* SELECT 1;", splitter.Generic)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("line 1: unterminated block comment"))
+		})
+	})
+
+	Describe("PostgreSQL", func() {
+		It("should not split dollar quoted bodies", func() {
+			script := `CREATE FUNCTION f() RETURNS trigger AS $$
+BEGIN
+  NEW.updated_at = now();
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+CREATE FUNCTION g() RETURNS text AS $body$ SELECT 'a;$$;b' $body$ LANGUAGE sql;
+SELECT 1;`
+			statements, err := splitter.Split(script, splitter.PostgreSQL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statements).To(HaveLen(3))
+			Expect(statements[0].SQL).To(HavePrefix("CREATE FUNCTION f()"))
+			Expect(statements[0].SQL).To(HaveSuffix("$$ LANGUAGE plpgsql"))
+			Expect(statements[1].SQL).To(Equal("CREATE FUNCTION g() RETURNS text AS $body$ SELECT 'a;$$;b' $body$ LANGUAGE sql"))
+			Expect(statements[1].Line).To(Equal(7))
+			Expect(statements[2].Line).To(Equal(8))
+		})
+
+		It("should not take positional parameters as dollar quotes", func() {
+			statements, err := splitter.Split("PREPARE p AS SELECT $1, $2;\nSELECT 1;", splitter.PostgreSQL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sqls(statements)).To(Equal([]string{"PREPARE p AS SELECT $1, $2", "SELECT 1"}))
+		})
+
+		It("should support escape strings", func() {
+			statements, err := splitter.Split(`SELECT E'it\'s;';SELECT 2;`, splitter.PostgreSQL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sqls(statements)).To(Equal([]string{`SELECT E'it\'s;'`, "SELECT 2"}))
+		})
+
+		It("should support nested block comments", func() {
+			statements, err := splitter.Split("/* a /* b; */ c; */ SELECT 1; SELECT 2;", splitter.PostgreSQL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statements).To(HaveLen(2))
+			Expect(statements[1].SQL).To(Equal("SELECT 2"))
+		})
+
+		It("should fail with an unterminated dollar quote", func() {
+			_, err := splitter.Split("SELECT 1;\n\nDO $$ BEGIN; END;", splitter.PostgreSQL)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("line 3: unterminated dollar quoted text ($$)"))
+		})
+	})
+
+	Describe("MySQL", func() {
+		It("should not split BEGIN ... END blocks", func() {
+			script := "CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW\nBEGIN\n  IF NEW.x < 0 THEN\n    SET NEW.x = 0;\n  END IF;\n  SET NEW.y = CASE WHEN NEW.x > 1 THEN 1 ELSE 0 END;\nEND;\nSELECT 1;"
+			statements, err := splitter.Split(script, splitter.MySQL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statements).To(HaveLen(2))
+			Expect(statements[0].SQL).To(HaveSuffix("END"))
+			Expect(statements[0].Compound).To(BeTrue())
+			Expect(statements[1].SQL).To(Equal("SELECT 1"))
+			Expect(statements[1].Line).To(Equal(8))
+			Expect(statements[1].Compound).To(BeFalse())
+		})
+
+		It("should not take transactions as blocks", func() {
+			statements, err := splitter.Split("BEGIN;\nINSERT INTO a VALUES (1);\nCOMMIT;", splitter.MySQL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sqls(statements)).To(Equal([]string{"BEGIN", "INSERT INTO a VALUES (1)", "COMMIT"}))
+			Expect(statements[0].Compound).To(BeFalse())
+		})
+
+		It("should support backslash escapes, backticks and hash comments", func() {
+			statements, err := splitter.Split("# comment;\nINSERT INTO `a;b` VALUES ('it\\'s;', \"x;\");\nSELECT 2;", splitter.MySQL)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statements).To(HaveLen(2))
+			Expect(statements[0].SQL).To(Equal("# comment;\nINSERT INTO `a;b` VALUES ('it\\'s;', \"x;\")"))
+			Expect(statements[0].Line).To(Equal(2))
+		})
+	})
+
+	Describe("SQLite", func() {
+		It("should not split the BEGIN ... END blocks of triggers", func() {
+			script := "CREATE TRIGGER t AFTER INSERT ON a\nBEGIN\n  UPDATE a SET b = 1;\n  UPDATE a SET c = CASE WHEN b THEN 1 ELSE 0 END;\nEND;\nSELECT 1;"
+			statements, err := splitter.Split(script, splitter.SQLite)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(statements).To(HaveLen(2))
+			Expect(statements[0].SQL).To(HavePrefix("CREATE TRIGGER t"))
+			Expect(statements[0].SQL).To(HaveSuffix("END"))
+			Expect(statements[0].Compound).To(BeTrue())
+			Expect(statements[1].SQL).To(Equal("SELECT 1"))
+			Expect(statements[1].Line).To(Equal(6))
+		})
+
+		It("should not take transactions as blocks", func() {
+			statements, err := splitter.Split("BEGIN IMMEDIATE;\nINSERT INTO a VALUES (1);\nEND;", splitter.SQLite)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sqls(statements)).To(Equal([]string{"BEGIN IMMEDIATE", "INSERT INTO a VALUES (1)", "END"}))
+		})
+	})
+})
@@ -0,0 +1,174 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lab259/go-migration/splitter"
+)
+
+// ErrScriptNotSupported is returned by migration.ManagerDefault.WriteSQL when
+// the target does not implement the migration.ScriptTarget.
+var ErrScriptNotSupported = errors.New("the target does not support writing SQL scripts")
+
+// UnscriptableMigrationError is returned by migration.ManagerDefault.WriteSQL
+// when the plan has a migration that is not a migration.FileMigration (eg. a
+// migration written in Go). Its statements cannot be written to the script,
+// and running it would apply its side effects for real.
+type UnscriptableMigrationError struct {
+	Migration Migration
+}
+
+// Error implements the error interface.
+func (err *UnscriptableMigrationError) Error() string {
+	return fmt.Sprintf("migration [%s] %s is not a SQL file and cannot be written to a SQL script", MigrationLabel(err.Migration), err.Migration.GetDescription())
+}
+
+// SQLWriter is a migration.SQLExecutor that writes the statements to an
+// io.Writer, instead of executing them. Each statement is terminated by a
+// semicolon and a new line, replacing the one it may already have.
+//
+// The arguments of the statements are inlined as literals, according to the
+// splitter.Dialect. Placeholders can be either `?` or `$N`.
+//
+// On MySQL, statements with semicolons inside of a `BEGIN ... END` block (eg.
+// triggers and stored procedures) are wrapped by `DELIMITER //` and
+// `DELIMITER ;`, so the script can be run by the mysql client.
+type SQLWriter struct {
+	w       io.Writer
+	dialect splitter.Dialect
+}
+
+// NewSQLWriter returns a new instance of the migration.SQLWriter.
+func NewSQLWriter(w io.Writer, dialect splitter.Dialect) *SQLWriter {
+	return &SQLWriter{
+		w:       w,
+		dialect: dialect,
+	}
+}
+
+// ExecContext implements the migration.SQLExecutor by writing the statement.
+func (writer *SQLWriter) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if len(args) > 0 {
+		var err error
+		if query, err = writer.bind(query, args); err != nil {
+			return nil, err
+		}
+	}
+	query = strings.TrimRight(strings.TrimSpace(query), ";")
+	format := "%s;\n"
+	if writer.compound(query) {
+		format = "DELIMITER //\n%s\n//\nDELIMITER ;\n"
+	}
+	if _, err := fmt.Fprintf(writer.w, format, query); err != nil {
+		return nil, err
+	}
+	return driver.ResultNoRows, nil
+}
+
+// compound checks if the query is a MySQL compound statement, which needs a
+// custom delimiter (see splitter.Statement.Compound).
+func (writer *SQLWriter) compound(query string) bool {
+	if writer.dialect != splitter.MySQL {
+		return false
+	}
+	statements, err := splitter.Split(query, splitter.MySQL)
+	return err == nil && len(statements) == 1 && statements[0].Compound
+}
+
+// comment writes a SQL comment line.
+func (writer *SQLWriter) comment(format string, args ...interface{}) error {
+	_, err := fmt.Fprintf(writer.w, "-- %s\n", fmt.Sprintf(format, args...))
+	return err
+}
+
+// bind replaces the placeholders of the query, outside of string literals,
+// with the literals of the arguments.
+func (writer *SQLWriter) bind(query string, args []interface{}) (string, error) {
+	var (
+		result  strings.Builder
+		next    int
+		quoted  bool
+		literal string
+		err     error
+	)
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			quoted = !quoted
+			result.WriteByte(c)
+			continue
+		case quoted:
+			result.WriteByte(c)
+			continue
+		case c == '?':
+			if next >= len(args) {
+				return "", fmt.Errorf("sql: missing argument for the placeholder %d", next+1)
+			}
+			literal, err = writer.literal(args[next])
+			next++
+		case c == '$' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			n, _ := strconv.Atoi(query[i+1 : j])
+			if n < 1 || n > len(args) {
+				return "", fmt.Errorf("sql: missing argument for the placeholder $%d", n)
+			}
+			literal, err = writer.literal(args[n-1])
+			i = j - 1
+		default:
+			result.WriteByte(c)
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		result.WriteString(literal)
+	}
+	return result.String(), nil
+}
+
+// literal formats the value as a SQL literal.
+func (writer *SQLWriter) literal(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return writer.quote(v), nil
+	case []byte:
+		return writer.quote(string(v)), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v), nil
+	case float32, float64:
+		return fmt.Sprintf("%v", v), nil
+	case time.Time:
+		if writer.dialect == splitter.MySQL {
+			return writer.quote(v.UTC().Format("2006-01-02 15:04:05.999999")), nil
+		}
+		return writer.quote(v.Format("2006-01-02 15:04:05.999999999-07:00")), nil
+	default:
+		return "", fmt.Errorf("sql: cannot write a literal of %T", value)
+	}
+}
+
+func (writer *SQLWriter) quote(s string) string {
+	if writer.dialect == splitter.MySQL {
+		s = strings.Replace(s, `\`, `\\`, -1)
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
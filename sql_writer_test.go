@@ -0,0 +1,72 @@
+package migration_test
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/lab259/go-migration"
+	"github.com/lab259/go-migration/splitter"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SQLWriter", func() {
+	var output *bytes.Buffer
+
+	BeforeEach(func() {
+		output = bytes.NewBuffer(nil)
+	})
+
+	It("should write the statements instead of executing them", func() {
+		writer := migration.NewSQLWriter(output, splitter.PostgreSQL)
+		_, err := writer.ExecContext(context.Background(), "CREATE TABLE users (id int)")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = writer.ExecContext(context.Background(), "  DROP TABLE posts;  ")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(Equal("CREATE TABLE users (id int);\nDROP TABLE posts;\n"))
+	})
+
+	It("should inline the arguments of the placeholders", func() {
+		at := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+		writer := migration.NewSQLWriter(output, splitter.PostgreSQL)
+		_, err := writer.ExecContext(context.Background(), "INSERT INTO t VALUES ($2, $1, '$3?', ?, $3, $4, $5)", "it's", 42, at, nil, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(Equal("INSERT INTO t VALUES (42, 'it''s', '$3?', 'it''s', '2018-01-02 03:04:05+00:00', NULL, TRUE);\n"))
+	})
+
+	It("should escape the literals according to the dialect", func() {
+		at := time.Date(2018, 1, 2, 3, 4, 5, 600000000, time.FixedZone("", -3*60*60))
+		writer := migration.NewSQLWriter(output, splitter.MySQL)
+		_, err := writer.ExecContext(context.Background(), "INSERT INTO t VALUES (?, ?)", `a\'b`, at)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(Equal(`INSERT INTO t VALUES ('a\\''b', '2018-01-02 06:04:05.6');` + "\n"))
+	})
+
+	It("should wrap the MySQL compound statements with a custom delimiter", func() {
+		trigger := "CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW\nBEGIN\n  SET NEW.x = 0;\nEND"
+		writer := migration.NewSQLWriter(output, splitter.MySQL)
+		_, err := writer.ExecContext(context.Background(), trigger+";")
+		Expect(err).ToNot(HaveOccurred())
+		_, err = writer.ExecContext(context.Background(), "SELECT CASE WHEN 1 THEN 1 END")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(Equal("DELIMITER //\n" + trigger + "\n//\nDELIMITER ;\nSELECT CASE WHEN 1 THEN 1 END;\n"))
+	})
+
+	It("should not change the delimiter of other dialects", func() {
+		body := "CREATE FUNCTION f() RETURNS trigger AS $$ BEGIN RETURN NEW; END; $$ LANGUAGE plpgsql"
+		writer := migration.NewSQLWriter(output, splitter.PostgreSQL)
+		_, err := writer.ExecContext(context.Background(), body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(output.String()).To(Equal(body + ";\n"))
+	})
+
+	It("should fail when an argument is missing or cannot be written", func() {
+		writer := migration.NewSQLWriter(output, splitter.PostgreSQL)
+		_, err := writer.ExecContext(context.Background(), "SELECT $2", 1)
+		Expect(err).To(HaveOccurred())
+		_, err = writer.ExecContext(context.Background(), "SELECT ?", struct{}{})
+		Expect(err).To(HaveOccurred())
+		Expect(output.Len()).To(BeZero())
+	})
+})
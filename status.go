@@ -0,0 +1,75 @@
+package migration
+
+import "time"
+
+// MigrationState is the state of a migration in the migration.StatusRow
+// reconciling the source with the target.
+type MigrationState int
+
+const (
+	// StateApplied is the state of the migrations executed on the target.
+	StateApplied MigrationState = iota
+
+	// StatePending is the state of the migrations not executed yet.
+	StatePending
+
+	// StateStarved is the state of the pending migrations older than the
+	// current version of the target (see migration.ErrMigrationStarved).
+	StateStarved
+
+	// StateOrphaned is the state of the IDs recorded on the target that have
+	// no migration on the source (eg. deleted or renamed migration files).
+	StateOrphaned
+
+	// StateChecksumMismatch is the state of the migrations executed whose
+	// checksum differs from the one recorded when they were applied.
+	StateChecksumMismatch
+)
+
+// String returns the name of the state.
+func (state MigrationState) String() string {
+	switch state {
+	case StateApplied:
+		return "applied"
+	case StatePending:
+		return "pending"
+	case StateStarved:
+		return "starved"
+	case StateOrphaned:
+		return "orphaned"
+	case StateChecksumMismatch:
+		return "checksum-mismatch"
+	default:
+		return "unknown"
+	}
+}
+
+// StatusRow is the state of a migration ID, either listed by the source or
+// recorded on the target.
+type StatusRow struct {
+	ID    time.Time
+	State MigrationState
+
+	// Migration is the migration of the source. It is nil for orphaned IDs.
+	Migration Migration
+
+	// Expected and Actual are the checksums recorded on the target and of the
+	// migration. They are set for checksum mismatches.
+	Expected string
+	Actual   string
+}
+
+// Description returns the description of the migration, or an empty string
+// for orphaned IDs.
+func (row *StatusRow) Description() string {
+	if row.Migration == nil {
+		return ""
+	}
+	return row.Migration.GetDescription()
+}
+
+// Drifted checks if the row needs attention: starved and orphaned IDs, and
+// checksum mismatches.
+func (row *StatusRow) Drifted() bool {
+	return row.State == StateStarved || row.State == StateOrphaned || row.State == StateChecksumMismatch
+}
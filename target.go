@@ -1,10 +1,25 @@
 package migration
 
-import "time"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
 
 // NoVersion represents a zero version
 var NoVersion = time.Date(0, 0, 0, 0, 0, 0, 0, time.UTC)
 
+// ErrLockTimeout is returned when the migration lock could not be acquired
+// before the timeout.
+var ErrLockTimeout = errors.New("timeout acquiring the migration lock: another process might be migrating the database")
+
+// DefaultLockTimeout is how long the manager waits for the migration lock.
+const DefaultLockTimeout = time.Minute
+
 // Target describes the common interface for target of migrations. Each target
 // is a implementation of a specific database (or anything versionable).
 type Target interface {
@@ -22,7 +37,236 @@ type Target interface {
 	MigrationsExecuted() ([]time.Time, error)
 }
 
+// ContextTarget describes a target whose methods accept a `context.Context`,
+// so long running queries can be cancelled.
+//
+// The manager uses the context aware methods whenever the target implements
+// this interface.
+type ContextTarget interface {
+	Target
+
+	// VersionContext is the Version with a `context.Context`.
+	VersionContext(ctx context.Context) (time.Time, error)
+
+	// AddMigrationContext is the AddMigration with a `context.Context`.
+	AddMigrationContext(ctx context.Context, summary *Summary) error
+
+	// RemoveMigrationContext is the RemoveMigration with a `context.Context`.
+	RemoveMigrationContext(ctx context.Context, summary *Summary) error
+
+	// MigrationsExecutedContext is the MigrationsExecuted with a
+	// `context.Context`.
+	MigrationsExecutedContext(ctx context.Context) ([]time.Time, error)
+}
+
+func targetVersion(ctx context.Context, target Target) (time.Time, error) {
+	if t, ok := target.(ContextTarget); ok {
+		return t.VersionContext(ctx)
+	}
+	return target.Version()
+}
+
+func targetAddMigration(ctx context.Context, target Target, summary *Summary) error {
+	if t, ok := target.(ContextTarget); ok {
+		return t.AddMigrationContext(ctx, summary)
+	}
+	return target.AddMigration(summary)
+}
+
+func targetRemoveMigration(ctx context.Context, target Target, summary *Summary) error {
+	if t, ok := target.(ContextTarget); ok {
+		return t.RemoveMigrationContext(ctx, summary)
+	}
+	return target.RemoveMigration(summary)
+}
+
+func targetMigrationsExecuted(ctx context.Context, target Target) ([]time.Time, error) {
+	if t, ok := target.(ContextTarget); ok {
+		return t.MigrationsExecutedContext(ctx)
+	}
+	return target.MigrationsExecuted()
+}
+
+// ChecksumTarget is implemented by targets that store the checksum of the
+// applied migrations (see migration.Summary.Checksum).
+type ChecksumTarget interface {
+	// Checksums returns the checksums recorded for the executed migrations,
+	// indexed by the `UnixNano` of their IDs. Migrations recorded without a
+	// checksum might be left out.
+	Checksums(ctx context.Context) (map[int64]string, error)
+}
+
+// HistoryTarget is implemented by targets that keep the history of the
+// migrations. `AddMigration` and `RemoveMigration` append a record, with the
+// details of the migration.Summary, for each migration applied and reverted,
+// while the failed attempts are stored by `AddFailure`.
+type HistoryTarget interface {
+	// AddFailure stores a failed attempt of running a migration.
+	AddFailure(ctx context.Context, record *HistoryRecord) error
+
+	// History returns the records of the migrations applied and reverted,
+	// and of the failed attempts, oldest first.
+	History(ctx context.Context) ([]*HistoryRecord, error)
+}
+
+// RepeatableTarget is implemented by targets that track the
+// migration.Repeatable migrations apart from the versioned ones.
+//
+// The summaries of repeatable migrations are passed to `AddMigration` (of the
+// target or of the migration.Transaction), which must record their
+// checksums, replacing the previous ones, instead of adding a version.
+type RepeatableTarget interface {
+	// RepeatableChecksums returns the checksums recorded when the repeatable
+	// migrations were last applied, indexed by their descriptions.
+	RepeatableChecksums(ctx context.Context) (map[string]string, error)
+}
+
+// ScriptTarget is implemented by the SQL targets that can write the
+// statements recording the migrations to a migration.SQLExecutor (usually a
+// migration.SQLWriter), instead of running them on the database. It is used
+// by migration.ManagerDefault.WriteSQL.
+type ScriptTarget interface {
+	DialectTarget
+
+	// ScriptSetup writes the statements that create the migrations tables,
+	// when they do not exist. It is written once, at the top of the script.
+	ScriptSetup(ctx context.Context, executor SQLExecutor) error
+
+	// ScriptAddMigration writes the statement that records the migration as
+	// applied.
+	ScriptAddMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error
+
+	// ScriptRemoveMigration writes the statement that removes the record of
+	// the migration.
+	ScriptRemoveMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error
+}
+
 // BeforeRun describes a hook to be called before the Runner actually run.
 type BeforeRun interface {
 	BeforeRun(executionContext interface{})
 }
+
+// Transaction is a unit of work opened by a migration.TransactionalTarget.
+//
+// The migration and its bookkeeping run inside of the transaction and are
+// committed, or rolled back, together.
+type Transaction interface {
+	// ExecutionContext returns the execution context passed to the migration
+	// running inside of the transaction (eg. the `*sql.Tx`).
+	ExecutionContext() interface{}
+
+	// AddMigration persists the version inside of the transaction.
+	AddMigration(summary *Summary) error
+
+	// RemoveMigration removes a migration record inside of the transaction.
+	RemoveMigration(summary *Summary) error
+
+	// Commit commits the transaction.
+	Commit() error
+
+	// Rollback aborts the transaction.
+	Rollback() error
+}
+
+// TransactionalTarget describes a target that is able to run each migration,
+// and its bookkeeping, in a single transaction.
+type TransactionalTarget interface {
+	Target
+
+	// Begin starts a new transaction. If the `ctx` is done before the
+	// transaction is committed, it is rolled back.
+	Begin(ctx context.Context) (Transaction, error)
+}
+
+// Locker is implemented by targets able to hold a lock shared by every process
+// migrating the same database. It prevents concurrent deploys from running the
+// same migrations at the same time.
+type Locker interface {
+	// Lock acquires the lock, waiting up to `timeout` for it. A zero (or
+	// negative) timeout waits indefinitely, or until the `ctx` is done.
+	//
+	// If the lock cannot be acquired in time, migration.ErrLockTimeout is
+	// returned.
+	Lock(ctx context.Context, timeout time.Duration) error
+
+	// Unlock releases the lock.
+	Unlock() error
+}
+
+// transactionBeginner is implemented by the `*sql.DB` and by the `*sql.Conn`.
+type transactionBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// runInTransaction calls `h` inside of a transaction, committing it if `h`
+// succeeds.
+func runInTransaction(ctx context.Context, db transactionBeginner, h func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = h(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// ensureOnce runs the setup of the tables of a SQL target once. If the setup
+// fails, it is tried again on the next call.
+type ensureOnce struct {
+	mu   sync.Mutex
+	done bool
+}
+
+func (once *ensureOnce) do(h func() error) error {
+	once.mu.Lock()
+	defer once.mu.Unlock()
+	if once.done {
+		return nil
+	}
+	if err := h(); err != nil {
+		return err
+	}
+	once.done = true
+	return nil
+}
+
+// tableColumn is a column added to a table after the table was introduced, so
+// it is added to the tables created by older versions.
+type tableColumn struct {
+	name       string
+	definition string
+}
+
+// addMissingColumns adds the `columns` that are not in `existing` to the
+// `table`.
+func addMissingColumns(ctx context.Context, executor SQLExecutor, table string, existing map[string]bool, columns []tableColumn) error {
+	for _, column := range columns {
+		if existing[column.name] {
+			continue
+		}
+		if _, err := executor.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column.name, column.definition)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanColumnNames reads the names of the columns listed by the `rows`, which
+// have the name in the column given by `index` out of `count`, in lower case.
+func scanColumnNames(rows *sql.Rows, index, count int) (map[string]bool, error) {
+	defer rows.Close()
+	columns := make(map[string]bool)
+	values := make([]interface{}, count)
+	for i := range values {
+		values[i] = new(sql.RawBytes)
+	}
+	for rows.Next() {
+		if err := rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		columns[strings.ToLower(string(*values[index].(*sql.RawBytes)))] = true
+	}
+	return columns, rows.Err()
+}
@@ -1,6 +1,9 @@
 package migration
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"time"
 
 	"github.com/globalsign/mgo"
@@ -14,18 +17,73 @@ import (
 type MongoDBTarget struct {
 	db             *mgo.Database
 	collectionName string
+	lockTTL        time.Duration
+	lockOwner      string
+	lockRefresh    chan struct{}
 }
 
 // mongoDBMigrationVersion represents the version stored on the MongoDB.
 type mongoDBMigrationVersion struct {
-	ID time.Time `bson:"_id"`
+	ID          time.Time `bson:"_id"`
+	Checksum    string    `bson:"checksum,omitempty"`
+	Description string    `bson:"description,omitempty"`
+	AppliedAt   time.Time `bson:"appliedAt,omitempty"`
+	DurationMs  int64     `bson:"durationMs,omitempty"`
+	Hostname    string    `bson:"hostname,omitempty"`
+	User        string    `bson:"user,omitempty"`
+	ToolVersion string    `bson:"toolVersion,omitempty"`
+	Baselined   bool      `bson:"baselined,omitempty"`
 }
 
+// mongoDBHistoryRecord represents a migration applied, reverted or failed
+// stored on the history collection.
+type mongoDBHistoryRecord struct {
+	ID          bson.ObjectId `bson:"_id"`
+	MigrationID time.Time     `bson:"migrationId"`
+	Description string        `bson:"description"`
+	Direction   string        `bson:"direction"`
+	AppliedAt   time.Time     `bson:"appliedAt"`
+	DurationMs  int64         `bson:"durationMs"`
+	Checksum    string        `bson:"checksum,omitempty"`
+	Hostname    string        `bson:"hostname"`
+	User        string        `bson:"user"`
+	ToolVersion string        `bson:"toolVersion"`
+	Failed      bool          `bson:"failed"`
+	Failure     string        `bson:"failure,omitempty"`
+	Baselined   bool          `bson:"baselined,omitempty"`
+}
+
+// mongoDBRepeatable represents a repeatable migration stored on the
+// `<collection>_repeatable` collection.
+type mongoDBRepeatable struct {
+	Description string    `bson:"_id"`
+	Checksum    string    `bson:"checksum"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+// mongoDBLock represents the lock document stored on the MongoDB.
+type mongoDBLock struct {
+	ID        string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// DefaultMongoDBLockTTL is how long the lock document lives without being
+// refreshed. It only matters when the process holding the lock dies without
+// releasing it.
+const DefaultMongoDBLockTTL = time.Minute
+
+const (
+	mongoDBLockID            = "lock"
+	mongoDBLockRetryInterval = 500 * time.Millisecond
+)
+
 // NewMongoDB returns a new instance of the migration.MongoDBTarget
 func NewMongoDB(db *mgo.Database) *MongoDBTarget {
 	return &MongoDBTarget{
 		collectionName: DefaultMigrationTable,
 		db:             db,
+		lockTTL:        DefaultMongoDBLockTTL,
 	}
 }
 
@@ -62,30 +120,73 @@ func (t *MongoDBTarget) Version() (migrationID time.Time, err error) {
 	return
 }
 
-// SetVersion implements the migration.Target.SetVersion by storing the passed
-// version on the database.
+// AddMigration implements the migration.Target.AddMigration by storing the
+// migration on the collection and appending it to the history collection.
+//
+// MGo does not support transactions, so the migration might be stored
+// without its history record if the insert of the latter fails.
 //
 // It returns eny error returned by the MGo.
 func (t *MongoDBTarget) AddMigration(summary *Summary) error {
 	return t.runWithDB(func(db *mgo.Database) error {
 		c := t.collection(db)
 
+		record := NewHistoryRecord(summary)
+		if IsRepeatable(summary.Migration) {
+			_, err := t.repeatableCollection(db).UpsertId(record.Description, &mongoDBRepeatable{
+				Description: record.Description,
+				Checksum:    record.Checksum,
+				AppliedAt:   record.AppliedAt,
+			})
+			return err
+		}
 		if _, err := c.Upsert(
-			bson.M{"_id": summary.Migration.GetID()},
+			bson.M{"_id": record.ID},
 			&mongoDBMigrationVersion{
-				ID: summary.Migration.GetID(),
+				ID:          record.ID,
+				Checksum:    record.Checksum,
+				Description: record.Description,
+				AppliedAt:   record.AppliedAt,
+				DurationMs:  int64(record.Duration / time.Millisecond),
+				Hostname:    record.Hostname,
+				User:        record.User,
+				ToolVersion: record.ToolVersion,
+				Baselined:   record.Baselined,
 			}); err != nil {
 			return err
 		}
-		return nil
+		return t.addHistory(db, record)
 	})
 }
 
-// RemoveMigration find and removes a migrations from the collection.
+// RemoveMigration find and removes a migrations from the collection,
+// appending the revert to the history collection.
 func (t *MongoDBTarget) RemoveMigration(summary *Summary) error {
 	return t.runWithDB(func(db *mgo.Database) error {
 		c := t.collection(db)
-		return c.Remove(map[string]interface{}{"_id": summary.Migration.GetID()})
+		if err := c.Remove(map[string]interface{}{"_id": summary.Migration.GetID()}); err != nil {
+			return err
+		}
+		return t.addHistory(db, NewHistoryRecord(summary))
+	})
+}
+
+// addHistory inserts the record into the `<collection>_history` collection.
+func (t *MongoDBTarget) addHistory(db *mgo.Database, record *HistoryRecord) error {
+	return t.historyCollection(db).Insert(&mongoDBHistoryRecord{
+		ID:          bson.NewObjectId(),
+		MigrationID: record.ID,
+		Description: record.Description,
+		Direction:   record.Direction.String(),
+		AppliedAt:   record.AppliedAt,
+		DurationMs:  int64(record.Duration / time.Millisecond),
+		Checksum:    record.Checksum,
+		Hostname:    record.Hostname,
+		User:        record.User,
+		ToolVersion: record.ToolVersion,
+		Failed:      record.Failed,
+		Failure:     record.Failure,
+		Baselined:   record.Baselined,
 	})
 }
 
@@ -109,6 +210,90 @@ func (t *MongoDBTarget) MigrationsExecuted() ([]time.Time, error) {
 	return r, nil
 }
 
+// Checksums implements the migration.ChecksumTarget.
+func (t *MongoDBTarget) Checksums(ctx context.Context) (map[int64]string, error) {
+	migrations := make([]mongoDBMigrationVersion, 0)
+	err := t.runWithDB(func(db *mgo.Database) error {
+		return t.collection(db).Find(bson.M{"checksum": bson.M{"$exists": true}}).All(&migrations)
+	})
+	if err != nil {
+		return nil, err
+	}
+	checksums := make(map[int64]string, len(migrations))
+	for _, migration := range migrations {
+		checksums[migration.ID.UnixNano()] = migration.Checksum
+	}
+	return checksums, nil
+}
+
+func (t *MongoDBTarget) historyCollection(db *mgo.Database) *mgo.Collection {
+	return db.C(t.collectionName + "_history")
+}
+
+func (t *MongoDBTarget) repeatableCollection(db *mgo.Database) *mgo.Collection {
+	return db.C(t.collectionName + "_repeatable")
+}
+
+// RepeatableChecksums implements the migration.RepeatableTarget by listing
+// the `<collection>_repeatable` collection.
+func (t *MongoDBTarget) RepeatableChecksums(ctx context.Context) (map[string]string, error) {
+	repeatables := make([]mongoDBRepeatable, 0)
+	err := t.runWithDB(func(db *mgo.Database) error {
+		return t.repeatableCollection(db).Find(nil).All(&repeatables)
+	})
+	if err != nil {
+		return nil, err
+	}
+	checksums := make(map[string]string, len(repeatables))
+	for _, repeatable := range repeatables {
+		checksums[repeatable.Description] = repeatable.Checksum
+	}
+	return checksums, nil
+}
+
+// AddFailure implements the migration.HistoryTarget by inserting the record
+// into the `<collection>_history` collection.
+func (t *MongoDBTarget) AddFailure(ctx context.Context, record *HistoryRecord) error {
+	return t.runWithDB(func(db *mgo.Database) error {
+		return t.addHistory(db, record)
+	})
+}
+
+// History implements the migration.HistoryTarget by listing the history
+// collection.
+func (t *MongoDBTarget) History(ctx context.Context) ([]*HistoryRecord, error) {
+	history := make([]mongoDBHistoryRecord, 0)
+	err := t.runWithDB(func(db *mgo.Database) error {
+		return t.historyCollection(db).Find(nil).Sort("appliedAt", "_id").All(&history)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*HistoryRecord, 0, len(history))
+	for _, h := range history {
+		direction, err := ParseDirection(h.Direction)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, &HistoryRecord{
+			ID:          h.MigrationID.UTC(),
+			Description: h.Description,
+			Direction:   direction,
+			AppliedAt:   h.AppliedAt,
+			Duration:    time.Duration(h.DurationMs) * time.Millisecond,
+			Checksum:    h.Checksum,
+			Hostname:    h.Hostname,
+			User:        h.User,
+			ToolVersion: h.ToolVersion,
+			Failed:      h.Failed,
+			Failure:     h.Failure,
+			Baselined:   h.Baselined,
+		})
+	}
+	return records, nil
+}
+
 // SetCollectionName sets the name of the collection used to store the current
 // version of the database.
 func (t *MongoDBTarget) SetCollectionName(collection string) *MongoDBTarget {
@@ -116,6 +301,12 @@ func (t *MongoDBTarget) SetCollectionName(collection string) *MongoDBTarget {
 	return t
 }
 
+// SetLockTTL sets how long the lock document lives without being refreshed.
+func (t *MongoDBTarget) SetLockTTL(ttl time.Duration) *MongoDBTarget {
+	t.lockTTL = ttl
+	return t
+}
+
 // Database returns the `*mgo.Database` reference of this target.
 func (t *MongoDBTarget) Database() *mgo.Database {
 	return t.db
@@ -130,3 +321,106 @@ func (t *MongoDBTarget) BeforeRun(executionContext interface{}) {
 		session.ResetIndexCache()
 	}
 }
+
+func (t *MongoDBTarget) lockCollection(db *mgo.Database) *mgo.Collection {
+	return db.C(t.collectionName + "_lock")
+}
+
+// Lock implements the migration.Locker by inserting a lock document into the
+// `<collection>_lock` collection. While the lock is held, the document
+// expiration is refreshed in background, so a lock left behind by a process
+// that died expires after the TTL (see migration.MongoDBTarget.SetLockTTL).
+func (t *MongoDBTarget) Lock(ctx context.Context, timeout time.Duration) error {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	owner := hex.EncodeToString(token)
+
+	err := t.runWithDB(func(db *mgo.Database) error {
+		c := t.lockCollection(db)
+		if err := c.EnsureIndex(mgo.Index{
+			Key:         []string{"expiresAt"},
+			ExpireAfter: time.Second,
+		}); err != nil {
+			return err
+		}
+
+		deadline := time.Now().Add(timeout)
+		for {
+			now := time.Now()
+			// Expired locks are removed by the TTL monitor eventually, but it
+			// only runs once a minute.
+			if _, err := c.RemoveAll(bson.M{"_id": mongoDBLockID, "expiresAt": bson.M{"$lt": now}}); err != nil {
+				return err
+			}
+			err := c.Insert(&mongoDBLock{
+				ID:        mongoDBLockID,
+				Owner:     owner,
+				ExpiresAt: now.Add(t.lockTTL),
+			})
+			if err == nil {
+				return nil
+			}
+			if !mgo.IsDup(err) {
+				return err
+			}
+			if timeout > 0 && time.Now().After(deadline) {
+				return ErrLockTimeout
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(mongoDBLockRetryInterval):
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	t.lockOwner = owner
+	t.lockRefresh = make(chan struct{})
+	go t.refreshLock(owner, t.lockRefresh)
+	return nil
+}
+
+// refreshLock keeps extending the expiration of the lock document until the
+// `done` channel is closed.
+func (t *MongoDBTarget) refreshLock(owner string, done chan struct{}) {
+	ticker := time.NewTicker(t.lockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			t.runWithDB(func(db *mgo.Database) error {
+				return t.lockCollection(db).Update(
+					bson.M{"_id": mongoDBLockID, "owner": owner},
+					bson.M{"$set": bson.M{"expiresAt": time.Now().Add(t.lockTTL)}},
+				)
+			})
+		}
+	}
+}
+
+// Unlock implements the migration.Locker by removing the lock document.
+func (t *MongoDBTarget) Unlock() error {
+	if t.lockRefresh == nil {
+		return nil
+	}
+	close(t.lockRefresh)
+	t.lockRefresh = nil
+	owner := t.lockOwner
+	t.lockOwner = ""
+
+	return t.runWithDB(func(db *mgo.Database) error {
+		err := t.lockCollection(db).Remove(bson.M{"_id": mongoDBLockID, "owner": owner})
+		if err == mgo.ErrNotFound {
+			// The lock has expired.
+			return nil
+		}
+		return err
+	})
+}
@@ -1,6 +1,8 @@
 package migration_test
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"github.com/globalsign/mgo"
@@ -32,12 +34,12 @@ var _ = Describe("MongoDBTarget", func() {
 		Expect(err).To(BeNil())
 		session = s
 
-		// Drops the _migrations table
+		// Drops the _migrations and _migrations_history collections
 		names, err := session.DB("").CollectionNames()
 		Expect(err).ToNot(HaveOccurred())
 		for _, name := range names {
-			if name == migration.DefaultMigrationTable {
-				err = session.DB("").C(migration.DefaultMigrationTable).DropCollection()
+			if name == migration.DefaultMigrationTable || name == migration.DefaultMigrationTable+"_history" {
+				err = session.DB("").C(name).DropCollection()
 				Expect(err).ToNot(HaveOccurred())
 			}
 		}
@@ -142,4 +144,96 @@ var _ = Describe("MongoDBTarget", func() {
 		Expect(migrations[0]).To(Equal(m1.GetID()))
 		Expect(migrations[1]).To(Equal(m5.GetID()))
 	})
+
+	It("should store the checksum of the migrations", func() {
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(m1.GetID(), "Migration 1", nopHandler).WithChecksum("checksum 1"))
+		source.Register(migration.NewMigration(m2.GetID(), "Migration 2", nopHandler))
+		target := migration.NewMongoDB(session.DB(""))
+		manager := migration.NewManager(target, source)
+
+		_, err := manager.Migrate(&nopReporter{}, session.DB(""))
+		Expect(err).ToNot(HaveOccurred())
+
+		checksums, err := target.Checksums(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(Equal(map[int64]string{
+			m1.GetID().UnixNano(): "checksum 1",
+		}))
+	})
+
+	It("should store the history of the migrations", func() {
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(m1.GetID(), "Migration 1", nopHandler).WithChecksum("checksum 1"))
+		source.Register(migration.NewMigration(m2.GetID(), "Migration 2", func(executionContext interface{}) error {
+			return errors.New("forced error")
+		}))
+		target := migration.NewMongoDB(session.DB(""))
+		manager := migration.NewManager(target, source)
+
+		_, err := manager.Migrate(&nopReporter{}, session.DB(""))
+		Expect(err).To(HaveOccurred())
+
+		records, err := manager.History()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].ID).To(Equal(m1.GetID()))
+		Expect(records[0].Description).To(Equal("Migration 1"))
+		Expect(records[0].Checksum).To(Equal("checksum 1"))
+		Expect(records[0].ToolVersion).To(Equal(migration.ToolVersion))
+		Expect(records[0].AppliedAt).ToNot(BeZero())
+		Expect(records[0].Failed).To(BeFalse())
+		Expect(records[1].ID).To(Equal(m2.GetID()))
+		Expect(records[1].Direction).To(Equal(migration.DirectionDo))
+		Expect(records[1].Failed).To(BeTrue())
+		Expect(records[1].Failure).To(Equal("forced error"))
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(HaveLen(1))
+	})
+
+	It("should detect the residue left by the undo of a migration", func() {
+		db := session.DB("")
+		for _, name := range []string{"reversible_test", "residue_test"} {
+			db.C(name).DropCollection()
+			defer db.C(name).DropCollection()
+		}
+
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(m1.GetID(), "Reversible", func(executionContext interface{}) error {
+			return db.C("reversible_test").EnsureIndex(mgo.Index{Key: []string{"name"}, Unique: true})
+		}, func(executionContext interface{}) error {
+			return db.C("reversible_test").DropCollection()
+		}))
+		source.Register(migration.NewMigration(m2.GetID(), "Leaves residue", func(executionContext interface{}) error {
+			return db.C("residue_test").EnsureIndex(mgo.Index{Key: []string{"name"}})
+		}, func(executionContext interface{}) error {
+			return nil
+		}))
+		manager := migration.NewManager(migration.NewMongoDB(db), source)
+
+		residues, err := manager.VerifyReversible(migration.NewMongoDBSnapshotter(db), &nopReporter{}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(residues).To(HaveLen(1))
+		Expect(residues[0].Migration.GetID()).To(Equal(m2.GetID()))
+		Expect(residues[0].Added).To(Equal([]string{
+			"collection residue_test",
+			"index residue_test._id_ _id",
+			"index residue_test.name_1 name",
+		}))
+		Expect(residues[0].Removed).To(BeEmpty())
+	})
+
+	It("should fail acquiring a lock held by another target", func() {
+		target1 := migration.NewMongoDB(session.DB(""))
+		target2 := migration.NewMongoDB(session.DB(""))
+
+		Expect(target1.Lock(context.Background(), time.Second)).To(Succeed())
+		Expect(target2.Lock(context.Background(), time.Millisecond*100)).To(Equal(migration.ErrLockTimeout))
+
+		Expect(target1.Unlock()).To(Succeed())
+		Expect(target2.Lock(context.Background(), time.Second)).To(Succeed())
+		Expect(target2.Unlock()).To(Succeed())
+	})
 })
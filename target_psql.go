@@ -4,26 +4,41 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"hash/fnv"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
+
+	"github.com/lab259/go-migration/splitter"
 )
 
 type PostgreSQLTarget struct {
-	db        *sql.DB
-	tableName string
+	db                  *sql.DB
+	tableName           string
+	historyTableName    string
+	repeatableTableName string
+	lockConn            *sql.Conn
+	ensured             ensureOnce
 }
 
 func NewPostgreSQLTarget(db *sql.DB) *PostgreSQLTarget {
 	return &PostgreSQLTarget{
-		db:        db,
-		tableName: pq.QuoteIdentifier(DefaultMigrationTable),
+		db:                  db,
+		tableName:           pq.QuoteIdentifier(DefaultMigrationTable),
+		historyTableName:    pq.QuoteIdentifier(DefaultMigrationTable + "_history"),
+		repeatableTableName: pq.QuoteIdentifier(DefaultMigrationTable + "_repeatable"),
 	}
 }
 
 func (target *PostgreSQLTarget) Version() (time.Time, error) {
+	return target.VersionContext(context.Background())
+}
+
+// VersionContext implements the migration.ContextTarget.
+func (target *PostgreSQLTarget) VersionContext(ctx context.Context) (time.Time, error) {
 	version := NoVersion
-	err := target.withConn(func(ctx context.Context, conn *sql.Conn) error {
+	err := target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
 		rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT id FROM %s ORDER BY id DESC LIMIT 1", target.tableName))
 		if err != nil {
 			return err
@@ -46,22 +61,81 @@ func (target *PostgreSQLTarget) Version() (time.Time, error) {
 }
 
 func (target *PostgreSQLTarget) AddMigration(summary *Summary) error {
-	return target.withConn(func(ctx context.Context, conn *sql.Conn) error {
-		_, err := conn.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (id) values ($1)", target.tableName), summary.Migration.GetID())
+	return target.AddMigrationContext(context.Background(), summary)
+}
+
+// AddMigrationContext implements the migration.ContextTarget.
+func (target *PostgreSQLTarget) AddMigrationContext(ctx context.Context, summary *Summary) error {
+	if err := target.ensure(ctx); err != nil {
 		return err
+	}
+	return runInTransaction(ctx, target.db, func(tx *sql.Tx) error {
+		return target.addMigration(ctx, tx, summary)
 	})
 }
 
+// addMigration inserts the migration into the migrations table, appending
+// it to the history table.
+func (target *PostgreSQLTarget) addMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	record := NewHistoryRecord(summary)
+	if IsRepeatable(summary.Migration) {
+		_, err := executor.ExecContext(ctx, fmt.Sprintf(
+			"INSERT INTO %s (description, checksum, applied_at) values ($1, $2, $3) ON CONFLICT (description) DO UPDATE SET checksum = EXCLUDED.checksum, applied_at = EXCLUDED.applied_at",
+			target.repeatableTableName,
+		), record.Description, record.Checksum, record.AppliedAt)
+		return err
+	}
+	_, err := executor.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (id, checksum, description, applied_at, duration_ms, hostname, username, tool_version, baselined) values ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9)",
+		target.tableName,
+	), record.ID, record.Checksum, record.Description, record.AppliedAt, int64(record.Duration/time.Millisecond), record.Hostname, record.User, record.ToolVersion, record.Baselined)
+	if err != nil {
+		return err
+	}
+	return target.addHistory(ctx, executor, record)
+}
+
 func (target *PostgreSQLTarget) RemoveMigration(summary *Summary) error {
-	return target.withConn(func(ctx context.Context, conn *sql.Conn) error {
-		_, err := conn.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = $1", target.tableName), summary.Migration.GetID())
+	return target.RemoveMigrationContext(context.Background(), summary)
+}
+
+// RemoveMigrationContext implements the migration.ContextTarget.
+func (target *PostgreSQLTarget) RemoveMigrationContext(ctx context.Context, summary *Summary) error {
+	if err := target.ensure(ctx); err != nil {
 		return err
+	}
+	return runInTransaction(ctx, target.db, func(tx *sql.Tx) error {
+		return target.removeMigration(ctx, tx, summary)
 	})
 }
 
+// removeMigration deletes the migration from the migrations table, appending
+// the revert to the history table.
+func (target *PostgreSQLTarget) removeMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	_, err := executor.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = $1", target.tableName), summary.Migration.GetID())
+	if err != nil {
+		return err
+	}
+	return target.addHistory(ctx, executor, NewHistoryRecord(summary))
+}
+
+// addHistory inserts the record into the history table.
+func (target *PostgreSQLTarget) addHistory(ctx context.Context, executor SQLExecutor, record *HistoryRecord) error {
+	_, err := executor.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (migration_id, description, direction, applied_at, duration_ms, checksum, hostname, username, tool_version, failed, failure, baselined) values ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, NULLIF($11, ''), $12)",
+		target.historyTableName,
+	), record.ID, record.Description, record.Direction.String(), record.AppliedAt, int64(record.Duration/time.Millisecond), record.Checksum, record.Hostname, record.User, record.ToolVersion, record.Failed, record.Failure, record.Baselined)
+	return err
+}
+
 func (target *PostgreSQLTarget) MigrationsExecuted() ([]time.Time, error) {
+	return target.MigrationsExecutedContext(context.Background())
+}
+
+// MigrationsExecutedContext implements the migration.ContextTarget.
+func (target *PostgreSQLTarget) MigrationsExecutedContext(ctx context.Context) ([]time.Time, error) {
 	migrations := make([]time.Time, 0, 10)
-	err := target.withConn(func(ctx context.Context, conn *sql.Conn) error {
+	err := target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
 		rows, err := conn.QueryContext(ctx, fmt.Sprintf(`SELECT id FROM %s ORDER BY id`, target.tableName))
 		if err != nil {
 			return err
@@ -83,8 +157,111 @@ func (target *PostgreSQLTarget) MigrationsExecuted() ([]time.Time, error) {
 
 }
 
-func (target *PostgreSQLTarget) withConn(h func(ctx context.Context, conn *sql.Conn) error) error {
-	ctx := context.Background()
+// Checksums implements the migration.ChecksumTarget.
+func (target *PostgreSQLTarget) Checksums(ctx context.Context) (map[int64]string, error) {
+	checksums := make(map[int64]string)
+	err := target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT id, checksum FROM %s WHERE checksum IS NOT NULL", target.tableName))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				id       time.Time
+				checksum string
+			)
+			if err := rows.Scan(&id, &checksum); err != nil {
+				return err
+			}
+			checksums[id.UnixNano()] = checksum
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// RepeatableChecksums implements the migration.RepeatableTarget.
+func (target *PostgreSQLTarget) RepeatableChecksums(ctx context.Context) (map[string]string, error) {
+	checksums := make(map[string]string)
+	err := target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT description, checksum FROM %s", target.repeatableTableName))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var description, checksum string
+			if err := rows.Scan(&description, &checksum); err != nil {
+				return err
+			}
+			checksums[description] = checksum
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// AddFailure implements the migration.HistoryTarget by inserting the record
+// into the history table.
+func (target *PostgreSQLTarget) AddFailure(ctx context.Context, record *HistoryRecord) error {
+	return target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		return target.addHistory(ctx, conn, record)
+	})
+}
+
+// History implements the migration.HistoryTarget by listing the history
+// table.
+func (target *PostgreSQLTarget) History(ctx context.Context) ([]*HistoryRecord, error) {
+	records := make([]*HistoryRecord, 0)
+	err := target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		rows, err := conn.QueryContext(ctx, fmt.Sprintf(
+			"SELECT migration_id, COALESCE(description, ''), direction, applied_at, COALESCE(duration_ms, 0), COALESCE(checksum, ''), COALESCE(hostname, ''), COALESCE(username, ''), COALESCE(tool_version, ''), failed, COALESCE(failure, ''), baselined FROM %s ORDER BY applied_at, id",
+			target.historyTableName,
+		))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				record     HistoryRecord
+				direction  string
+				durationMs int64
+			)
+			if err := rows.Scan(&record.ID, &record.Description, &direction, &record.AppliedAt, &durationMs, &record.Checksum, &record.Hostname, &record.User, &record.ToolVersion, &record.Failed, &record.Failure, &record.Baselined); err != nil {
+				return err
+			}
+			if record.Direction, err = ParseDirection(direction); err != nil {
+				return err
+			}
+			record.ID = record.ID.UTC()
+			record.Duration = time.Duration(durationMs) * time.Millisecond
+			records = append(records, &record)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Dialect implements the migration.DialectTarget.
+func (target *PostgreSQLTarget) Dialect() splitter.Dialect {
+	return splitter.PostgreSQL
+}
+
+func (target *PostgreSQLTarget) withConn(ctx context.Context, h func(ctx context.Context, conn *sql.Conn) error) error {
+	if err := target.ensure(ctx); err != nil {
+		return err
+	}
 
 	conn, err := target.db.Conn(ctx)
 	if err != nil {
@@ -92,10 +269,245 @@ func (target *PostgreSQLTarget) withConn(h func(ctx context.Context, conn *sql.C
 	}
 	defer conn.Close()
 
-	_, err = conn.ExecContext(context.Background(), fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id timestamptz NOT NULL PRIMARY KEY)", target.tableName))
+	return h(ctx, conn)
+}
+
+// postgresMigrationsColumns are the columns added to the migrations table
+// after the id. They are added to tables created by older versions.
+var postgresMigrationsColumns = []tableColumn{
+	{"checksum", "text"},
+	{"description", "text"},
+	{"applied_at", "timestamptz"},
+	{"duration_ms", "bigint"},
+	{"hostname", "text"},
+	{"username", "text"},
+	{"tool_version", "text"},
+	{"baselined", "boolean NOT NULL DEFAULT false"},
+}
+
+// postgresHistoryColumns are the columns added to the history table after it
+// was introduced. They are added to tables created by older versions.
+var postgresHistoryColumns = []tableColumn{
+	{"failed", "boolean NOT NULL DEFAULT false"},
+	{"baselined", "boolean NOT NULL DEFAULT false"},
+}
+
+// ensure creates and upgrades the migrations tables once per target, so the
+// DDL (and the locks it takes) is not repeated on every call.
+func (target *PostgreSQLTarget) ensure(ctx context.Context) error {
+	return target.ensured.do(func() error {
+		return target.ensureMigrationsTable(ctx)
+	})
+}
+
+// ensureMigrationsTable creates the migrations, the history and the repeatable
+// tables. Tables created by older versions are upgraded with the columns they
+// miss.
+func (target *PostgreSQLTarget) ensureMigrationsTable(ctx context.Context) error {
+	if err := target.createTables(ctx, target.db); err != nil {
+		return err
+	}
+
+	columns, err := target.columns(ctx, DefaultMigrationTable)
 	if err != nil {
 		return err
 	}
+	if err = addMissingColumns(ctx, target.db, target.tableName, columns, postgresMigrationsColumns); err != nil {
+		return err
+	}
 
-	return h(ctx, conn)
+	columns, err = target.columns(ctx, DefaultMigrationTable+"_history")
+	if err != nil {
+		return err
+	}
+	return addMissingColumns(ctx, target.db, target.historyTableName, columns, postgresHistoryColumns)
+}
+
+// columns lists the columns of the `table` (not quoted).
+func (target *PostgreSQLTarget) columns(ctx context.Context, table string) (map[string]bool, error) {
+	rows, err := target.db.QueryContext(ctx, "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1", table)
+	if err != nil {
+		return nil, err
+	}
+	return scanColumnNames(rows, 0, 1)
+}
+
+// createTables creates the migrations table, with all its columns, along
+// with the history and the repeatable tables.
+func (target *PostgreSQLTarget) createTables(ctx context.Context, executor SQLExecutor) error {
+	columns := []string{"id timestamptz NOT NULL PRIMARY KEY"}
+	for _, column := range postgresMigrationsColumns {
+		columns = append(columns, column.name+" "+column.definition)
+	}
+	_, err := executor.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]s (%[4]s);
+		CREATE TABLE IF NOT EXISTS %[2]s (
+			id bigserial NOT NULL PRIMARY KEY,
+			migration_id timestamptz NOT NULL,
+			description text,
+			direction text NOT NULL,
+			applied_at timestamptz NOT NULL,
+			duration_ms bigint,
+			checksum text,
+			hostname text,
+			username text,
+			tool_version text,
+			failed boolean NOT NULL DEFAULT false,
+			failure text,
+			baselined boolean NOT NULL DEFAULT false
+		);
+		CREATE TABLE IF NOT EXISTS %[3]s (
+			description text NOT NULL PRIMARY KEY,
+			checksum text NOT NULL,
+			applied_at timestamptz NOT NULL
+		)`, target.tableName, target.historyTableName, target.repeatableTableName, strings.Join(columns, ", ")))
+	return err
+}
+
+// ScriptSetup implements the migration.ScriptTarget.
+func (target *PostgreSQLTarget) ScriptSetup(ctx context.Context, executor SQLExecutor) error {
+	return target.createTables(ctx, executor)
+}
+
+// ScriptAddMigration implements the migration.ScriptTarget.
+func (target *PostgreSQLTarget) ScriptAddMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	return target.addMigration(ctx, executor, summary)
+}
+
+// ScriptRemoveMigration implements the migration.ScriptTarget.
+func (target *PostgreSQLTarget) ScriptRemoveMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	return target.removeMigration(ctx, executor, summary)
+}
+
+// Begin implements the migration.TransactionalTarget by starting a new
+// transaction on the database. The migrations ran inside of it receive the
+// `*sql.Tx` as execution context.
+func (target *PostgreSQLTarget) Begin(ctx context.Context) (Transaction, error) {
+	if err := target.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := target.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &postgreSQLTransaction{
+		ctx:    ctx,
+		target: target,
+		tx:     tx,
+	}, nil
+}
+
+// postgreSQLTransaction is the migration.Transaction returned by the
+// migration.PostgreSQLTarget.
+type postgreSQLTransaction struct {
+	// ctx is the context passed to Begin, used to record the migrations.
+	ctx    context.Context
+	target *PostgreSQLTarget
+	tx     *sql.Tx
+}
+
+func (t *postgreSQLTransaction) ExecutionContext() interface{} {
+	return t.tx
+}
+
+func (t *postgreSQLTransaction) AddMigration(summary *Summary) error {
+	return t.target.addMigration(t.ctx, t.tx, summary)
+}
+
+func (t *postgreSQLTransaction) RemoveMigration(summary *Summary) error {
+	return t.target.removeMigration(t.ctx, t.tx, summary)
+}
+
+func (t *postgreSQLTransaction) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *postgreSQLTransaction) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// lockKey returns the key of the advisory lock used by this target. It is
+// derived from the table name, so targets sharing the same table share the
+// same lock.
+func (target *PostgreSQLTarget) lockKey() int64 {
+	h := fnv.New64a()
+	h.Write([]byte("go-migration:" + target.tableName))
+	return int64(h.Sum64())
+}
+
+// Lock implements the migration.Locker by acquiring a session level
+// advisory lock (`pg_advisory_lock`). The connection holding the lock is kept
+// until migration.PostgreSQLTarget.Unlock is called.
+func (target *PostgreSQLTarget) Lock(ctx context.Context, timeout time.Duration) error {
+	conn, err := target.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	if timeout < 0 {
+		timeout = 0
+	}
+	_, err = conn.ExecContext(ctx, fmt.Sprintf("SET lock_timeout = %d", int64(timeout/time.Millisecond)))
+	if err == nil {
+		_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", target.lockKey())
+	}
+	if err == nil {
+		_, err = conn.ExecContext(ctx, "RESET lock_timeout")
+	}
+	if err != nil {
+		// The lock may have been acquired right before a failure (eg. when
+		// the `ctx` is canceled), so it is released as well.
+		target.releaseConn(conn)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "55P03" { // lock_not_available
+			return ErrLockTimeout
+		}
+		return err
+	}
+
+	target.lockConn = conn
+	return nil
+}
+
+// Unlock implements the migration.Locker by releasing the advisory lock.
+func (target *PostgreSQLTarget) Unlock() error {
+	conn := target.lockConn
+	if conn == nil {
+		return nil
+	}
+	target.lockConn = nil
+
+	_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", target.lockKey())
+	if err != nil {
+		discardConn(conn)
+		return err
+	}
+	return conn.Close()
+}
+
+// releaseConn restores the session of a connection used by
+// migration.PostgreSQLTarget.Lock, releasing the advisory lock (which is a
+// no-op when it is not held), and returns the connection to the pool. If the
+// session cannot be restored, the connection is discarded, so the next user
+// of the connection does not hold the lock (or the lock_timeout) by accident.
+func (target *PostgreSQLTarget) releaseConn(conn *sql.Conn) {
+	ctx := context.Background()
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", target.lockKey())
+	if err == nil {
+		_, err = conn.ExecContext(ctx, "RESET lock_timeout")
+	}
+	if err != nil {
+		discardConn(conn)
+		return
+	}
+	conn.Close()
+}
+
+// discardConn terminates the session of the connection, which releases all
+// of its locks, before closing it. As the driver reports the terminated
+// session as a bad connection, the connection is not returned to the pool.
+func discardConn(conn *sql.Conn) {
+	conn.ExecContext(context.Background(), "SELECT pg_terminate_backend(pg_backend_pid())")
+	conn.Close()
 }
@@ -1,7 +1,9 @@
 package migration_test
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -31,8 +33,8 @@ var _ = Describe("PostgreSQLTarget", func() {
 		Expect(err).ToNot(HaveOccurred())
 		db = d
 
-		// Drops the _migrations table
-		_, err = db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s`, pq.QuoteIdentifier(migration.DefaultMigrationTable)))
+		// Drops the _migrations and _migrations_history tables
+		_, err = db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s, %s`, pq.QuoteIdentifier(migration.DefaultMigrationTable), pq.QuoteIdentifier(migration.DefaultMigrationTable+"_history")))
 		Expect(err).ToNot(HaveOccurred())
 
 		source = migration.NewCodeSource()
@@ -141,4 +143,187 @@ var _ = Describe("PostgreSQLTarget", func() {
 		Expect(migrations[0]).To(Equal(m1.GetID()))
 		Expect(migrations[1]).To(Equal(m5.GetID()))
 	})
+
+	It("should record the migration only when the transaction is committed", func() {
+		target := migration.NewPostgreSQLTarget(db)
+
+		tx, err := target.Begin(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tx.ExecutionContext()).To(BeAssignableToTypeOf(&sql.Tx{}))
+		Expect(tx.AddMigration(migration.NewSummary(m1))).To(Succeed())
+		Expect(tx.Rollback()).To(Succeed())
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(BeEmpty())
+
+		tx, err = target.Begin(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tx.AddMigration(migration.NewSummary(m1))).To(Succeed())
+		Expect(tx.Commit()).To(Succeed())
+
+		migrations, err = target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(HaveLen(1))
+		Expect(migrations[0]).To(Equal(m1.GetID()))
+	})
+
+	It("should rollback the schema changes of a failed migration", func() {
+		_, err := db.Exec(`DROP TABLE IF EXISTS "tx_test"`)
+		Expect(err).ToNot(HaveOccurred())
+
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(m1.GetID(), "Create table and fail", func(executionContext interface{}) error {
+			tx := executionContext.(*sql.Tx)
+			if _, err := tx.Exec(`CREATE TABLE "tx_test" (id int)`); err != nil {
+				return err
+			}
+			return errors.New("forced error")
+		}))
+		manager := migration.NewManager(migration.NewPostgreSQLTarget(db), source)
+
+		_, err = manager.Do(&nopReporter{}, db)
+		Expect(err).To(HaveOccurred())
+
+		var count int
+		Expect(db.QueryRow(`SELECT count(*) FROM information_schema.tables WHERE table_name = 'tx_test'`).Scan(&count)).To(Succeed())
+		Expect(count).To(Equal(0))
+
+		migrations, err := manager.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(BeEmpty())
+	})
+
+	It("should store the checksum of the migrations", func() {
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(m1.GetID(), "Migration 1", nopHandler).WithChecksum("checksum 1"))
+		source.Register(migration.NewMigration(m2.GetID(), "Migration 2", nopHandler))
+		target := migration.NewPostgreSQLTarget(db)
+		manager := migration.NewManager(target, source)
+
+		_, err := manager.Migrate(&nopReporter{}, db)
+		Expect(err).ToNot(HaveOccurred())
+
+		checksums, err := target.Checksums(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(Equal(map[int64]string{
+			m1.GetID().UnixNano(): "checksum 1",
+		}))
+	})
+
+	It("should add the checksum column to an existing migrations table", func() {
+		_, err := db.Exec(fmt.Sprintf(`CREATE TABLE %s (id timestamptz NOT NULL PRIMARY KEY)`, pq.QuoteIdentifier(migration.DefaultMigrationTable)))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Exec(fmt.Sprintf(`INSERT INTO %s (id) VALUES ($1)`, pq.QuoteIdentifier(migration.DefaultMigrationTable)), m1.GetID())
+		Expect(err).ToNot(HaveOccurred())
+
+		target := migration.NewPostgreSQLTarget(db)
+		Expect(target.AddMigration(migration.NewSummary(m2))).To(Succeed())
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(Equal([]time.Time{m1.GetID(), m2.GetID()}))
+
+		checksums, err := target.Checksums(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(BeEmpty())
+	})
+
+	It("should store the history of the migrations", func() {
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(m1.GetID(), "Migration 1", nopHandler).WithChecksum("checksum 1"))
+		source.Register(migration.NewMigration(m2.GetID(), "Migration 2", func(executionContext interface{}) error {
+			return errors.New("forced error")
+		}))
+		target := migration.NewPostgreSQLTarget(db)
+		manager := migration.NewManager(target, source)
+
+		_, err := manager.Migrate(&nopReporter{}, db)
+		Expect(err).To(HaveOccurred())
+
+		records, err := manager.History()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].ID).To(Equal(m1.GetID()))
+		Expect(records[0].Description).To(Equal("Migration 1"))
+		Expect(records[0].Checksum).To(Equal("checksum 1"))
+		Expect(records[0].ToolVersion).To(Equal(migration.ToolVersion))
+		Expect(records[0].AppliedAt).ToNot(BeZero())
+		Expect(records[0].Failed).To(BeFalse())
+		Expect(records[1].ID).To(Equal(m2.GetID()))
+		Expect(records[1].Direction).To(Equal(migration.DirectionDo))
+		Expect(records[1].Failed).To(BeTrue())
+		Expect(records[1].Failure).To(Equal("forced error"))
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(HaveLen(1))
+	})
+
+	It("should detect the residue left by the undo of a migration", func() {
+		_, err := db.Exec(`DROP TABLE IF EXISTS "reversible_test", "residue_test"`)
+		Expect(err).ToNot(HaveOccurred())
+		defer db.Exec(`DROP TABLE IF EXISTS "reversible_test", "residue_test"`)
+
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(m1.GetID(), "Reversible", func(executionContext interface{}) error {
+			_, err := executionContext.(*sql.Tx).Exec(`CREATE TABLE "reversible_test" (id int PRIMARY KEY)`)
+			return err
+		}, func(executionContext interface{}) error {
+			_, err := executionContext.(*sql.Tx).Exec(`DROP TABLE "reversible_test"`)
+			return err
+		}))
+		source.Register(migration.NewMigration(m2.GetID(), "Leaves residue", func(executionContext interface{}) error {
+			_, err := executionContext.(*sql.Tx).Exec(`CREATE TABLE IF NOT EXISTS "residue_test" (id int); CREATE INDEX "residue_test_id" ON "residue_test" (id)`)
+			return err
+		}, func(executionContext interface{}) error {
+			_, err := executionContext.(*sql.Tx).Exec(`DROP INDEX "residue_test_id"`)
+			return err
+		}))
+		manager := migration.NewManager(migration.NewPostgreSQLTarget(db), source)
+
+		residues, err := manager.VerifyReversible(migration.NewPostgreSQLSnapshotter(db), &nopReporter{}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(residues).To(HaveLen(1))
+		Expect(residues[0].Migration.GetID()).To(Equal(m2.GetID()))
+		Expect(residues[0].Added).To(ContainElement("table public residue_test BASE TABLE"))
+		Expect(residues[0].Added).To(ContainElement("column public.residue_test.id integer YES "))
+		Expect(residues[0].Removed).To(BeEmpty())
+
+		migrations, err := manager.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(HaveLen(2))
+	})
+
+	It("should fail acquiring a lock held by another target", func() {
+		target1 := migration.NewPostgreSQLTarget(db)
+		target2 := migration.NewPostgreSQLTarget(db)
+
+		Expect(target1.Lock(context.Background(), time.Second)).To(Succeed())
+		Expect(target2.Lock(context.Background(), time.Millisecond*100)).To(Equal(migration.ErrLockTimeout))
+
+		Expect(target1.Unlock()).To(Succeed())
+		Expect(target2.Lock(context.Background(), time.Second)).To(Succeed())
+		Expect(target2.Unlock()).To(Succeed())
+	})
+
+	It("should not leave the lock timeout on the connections of the pool", func() {
+		db.SetMaxOpenConns(2)
+		target1 := migration.NewPostgreSQLTarget(db)
+		target2 := migration.NewPostgreSQLTarget(db)
+
+		Expect(target1.Lock(context.Background(), time.Second)).To(Succeed())
+		Expect(target2.Lock(context.Background(), time.Millisecond*100)).To(Equal(migration.ErrLockTimeout))
+		Expect(target1.Unlock()).To(Succeed())
+
+		// Both connections of the pool are taken, so each of them is checked.
+		for i := 0; i < 2; i++ {
+			conn, err := db.Conn(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+			var lockTimeout string
+			Expect(conn.QueryRowContext(context.Background(), "SHOW lock_timeout").Scan(&lockTimeout)).To(Succeed())
+			Expect(lockTimeout).To(Equal("0"))
+		}
+	})
 })
@@ -4,14 +4,26 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"strings"
 	"time"
+
+	"github.com/lab259/go-migration/splitter"
 )
 
-// MySQLTarget implements the migration.Target of the SQL based databases, using
-// the Golang SQL package.
+// MySQLTarget implements the migration.Target of the MySQL, using the Golang
+// SQL package.
+//
+// The times are read from `DATETIME` columns, so the connection must be opened
+// with the `parseTime=true` parameter.
+//
+// MySQL commits implicitly on DDL statements, so the migrations are not ran
+// inside of transactions.
 type MySQLTarget struct {
 	connection *sql.DB
 	tableName  string
+	lockConn   *sql.Conn
+	ensured    ensureOnce
 }
 
 // NewMySQL returns a new instance of the migration.MySQLTarget
@@ -22,65 +34,273 @@ func NewMySQL(conn *sql.DB) *MySQLTarget {
 	}
 }
 
+func mysqlQuoteIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// table returns the quoted name of the migrations table.
+func (target *MySQLTarget) table() string {
+	return mysqlQuoteIdentifier(target.tableName)
+}
+
+// historyTable returns the quoted name of the history table.
+func (target *MySQLTarget) historyTable() string {
+	return mysqlQuoteIdentifier(target.tableName + "_history")
+}
+
+// repeatableTable returns the quoted name of the table of the repeatable
+// migrations.
+func (target *MySQLTarget) repeatableTable() string {
+	return mysqlQuoteIdentifier(target.tableName + "_repeatable")
+}
+
 // Version implements the migration.Target.Version by fetching the current
 // version of the database from the table defined by
-// migration.MongoDBTarget.SetCollectionName.
+// migration.MySQLTarget.SetTableName.
 //
 // It returns the current version of the database.
 //
 // Any error returned by the driver, will be passed to the caller.
 func (target *MySQLTarget) Version() (time.Time, error) {
-	ctx := context.Background()
-	conn, err := target.connection.Conn(ctx)
+	return target.VersionContext(context.Background())
+}
+
+// VersionContext implements the migration.ContextTarget.
+func (target *MySQLTarget) VersionContext(ctx context.Context) (time.Time, error) {
+	version := NoVersion
+	err := target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		err := conn.QueryRowContext(ctx, fmt.Sprintf("SELECT id FROM %s ORDER BY id DESC LIMIT 1", target.table())).Scan(&version)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	})
 	if err != nil {
 		return NoVersion, err
 	}
-	defer conn.Close()
+	return version.UTC(), nil
+}
+
+// AddMigration implements the migration.Target.AddMigration by inserting the
+// migration into the migrations table.
+func (target *MySQLTarget) AddMigration(summary *Summary) error {
+	return target.AddMigrationContext(context.Background(), summary)
+}
 
-	err = target.ensureMigrationsTable(conn)
+// AddMigrationContext implements the migration.ContextTarget.
+func (target *MySQLTarget) AddMigrationContext(ctx context.Context, summary *Summary) error {
+	return target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		return runInTransaction(ctx, conn, func(tx *sql.Tx) error {
+			return target.addMigration(ctx, tx, summary)
+		})
+	})
+}
+
+// addMigration inserts the migration into the migrations table, appending
+// it to the history table.
+func (target *MySQLTarget) addMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	record := NewHistoryRecord(summary)
+	if IsRepeatable(summary.Migration) {
+		_, err := executor.ExecContext(ctx, fmt.Sprintf(
+			"INSERT INTO %s (description, checksum, applied_at) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE checksum = VALUES(checksum), applied_at = VALUES(applied_at)",
+			target.repeatableTable(),
+		), record.Description, record.Checksum, record.AppliedAt.UTC())
+		return err
+	}
+	_, err := executor.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (id, checksum, description, applied_at, duration_ms, hostname, username, tool_version, baselined) VALUES (?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?)",
+		target.table(),
+	), record.ID.UTC(), record.Checksum, record.Description, record.AppliedAt.UTC(), int64(record.Duration/time.Millisecond), record.Hostname, record.User, record.ToolVersion, record.Baselined)
 	if err != nil {
-		return NoVersion, err
+		return err
 	}
+	return target.addHistory(ctx, executor, record)
+}
+
+// RemoveMigration implements the migration.Target.RemoveMigration by deleting
+// the migration from the migrations table.
+func (target *MySQLTarget) RemoveMigration(summary *Summary) error {
+	return target.RemoveMigrationContext(context.Background(), summary)
+}
+
+// RemoveMigrationContext implements the migration.ContextTarget.
+func (target *MySQLTarget) RemoveMigrationContext(ctx context.Context, summary *Summary) error {
+	return target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		return runInTransaction(ctx, conn, func(tx *sql.Tx) error {
+			return target.removeMigration(ctx, tx, summary)
+		})
+	})
+}
 
-	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT id FROM %s ORDER BY id DESC LIMIT 1", target.tableName))
+// removeMigration deletes the migration from the migrations table, appending
+// the revert to the history table.
+func (target *MySQLTarget) removeMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	_, err := executor.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = ?", target.table()), summary.Migration.GetID().UTC())
 	if err != nil {
-		return time.Time{}, err
+		return err
 	}
-	defer rows.Close()
+	return target.addHistory(ctx, executor, NewHistoryRecord(summary))
+}
+
+// addHistory inserts the record into the history table.
+func (target *MySQLTarget) addHistory(ctx context.Context, executor SQLExecutor, record *HistoryRecord) error {
+	_, err := executor.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (migration_id, description, direction, applied_at, duration_ms, checksum, hostname, username, tool_version, failed, failure, baselined) VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, NULLIF(?, ''), ?)",
+		target.historyTable(),
+	), record.ID.UTC(), record.Description, record.Direction.String(), record.AppliedAt.UTC(), int64(record.Duration/time.Millisecond), record.Checksum, record.Hostname, record.User, record.ToolVersion, record.Failed, record.Failure, record.Baselined)
+	return err
+}
 
-	if !rows.Next() {
-		return NoVersion, nil
+// ScriptSetup implements the migration.ScriptTarget. As the script cannot
+// inspect the database, the migrations table is written with all its
+// columns; tables created by older versions must be upgraded by running the
+// target first.
+func (target *MySQLTarget) ScriptSetup(ctx context.Context, executor SQLExecutor) error {
+	columns := []string{"id DATETIME NOT NULL PRIMARY KEY"}
+	for _, column := range mysqlMigrationsColumns {
+		columns = append(columns, column.name+" "+column.definition)
 	}
-	d := time.Time{}
-	err = rows.Scan(&d)
+	_, err := executor.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", target.table(), strings.Join(columns, ", ")))
 	if err != nil {
-		return NoVersion, err
+		return err
 	}
-	return d, nil
+	return target.ensureSideTables(ctx, executor)
 }
 
-// SetVersion implements the migration.Target.SetVersion by storing the passed
-// version on the database.
-//
-// It returns any error returned from the database driver.
-func (target *MySQLTarget) SetVersion(id time.Time) error {
-	ctx := context.Background()
-	conn, err := target.connection.Conn(ctx)
+// ScriptAddMigration implements the migration.ScriptTarget.
+func (target *MySQLTarget) ScriptAddMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	return target.addMigration(ctx, executor, summary)
+}
+
+// ScriptRemoveMigration implements the migration.ScriptTarget.
+func (target *MySQLTarget) ScriptRemoveMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	return target.removeMigration(ctx, executor, summary)
+}
+
+// MigrationsExecuted implements the migration.Target.MigrationsExecuted by
+// listing the migrations stored on the migrations table.
+func (target *MySQLTarget) MigrationsExecuted() ([]time.Time, error) {
+	return target.MigrationsExecutedContext(context.Background())
+}
+
+// MigrationsExecutedContext implements the migration.ContextTarget.
+func (target *MySQLTarget) MigrationsExecutedContext(ctx context.Context) ([]time.Time, error) {
+	migrations := make([]time.Time, 0, 10)
+	err := target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT id FROM %s ORDER BY id", target.table()))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id time.Time
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			migrations = append(migrations, id.UTC())
+		}
+		return rows.Err()
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer conn.Close()
+	return migrations, nil
+}
 
-	err = target.ensureMigrationsTable(conn)
+// Checksums implements the migration.ChecksumTarget.
+func (target *MySQLTarget) Checksums(ctx context.Context) (map[int64]string, error) {
+	checksums := make(map[int64]string)
+	err := target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT id, checksum FROM %s WHERE checksum IS NOT NULL", target.table()))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				id       time.Time
+				checksum string
+			)
+			if err := rows.Scan(&id, &checksum); err != nil {
+				return err
+			}
+			checksums[id.UnixNano()] = checksum
+		}
+		return rows.Err()
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return checksums, nil
+}
 
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("INSERT INTO %s (id) VALUES (?)", target.tableName), id)
+// RepeatableChecksums implements the migration.RepeatableTarget.
+func (target *MySQLTarget) RepeatableChecksums(ctx context.Context) (map[string]string, error) {
+	checksums := make(map[string]string)
+	err := target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		rows, err := conn.QueryContext(ctx, fmt.Sprintf("SELECT description, checksum FROM %s", target.repeatableTable()))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var description, checksum string
+			if err := rows.Scan(&description, &checksum); err != nil {
+				return err
+			}
+			checksums[description] = checksum
+		}
+		return rows.Err()
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return checksums, nil
+}
+
+// AddFailure implements the migration.HistoryTarget by inserting the record
+// into the history table.
+func (target *MySQLTarget) AddFailure(ctx context.Context, record *HistoryRecord) error {
+	return target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		return target.addHistory(ctx, conn, record)
+	})
+}
+
+// History implements the migration.HistoryTarget by listing the history
+// table.
+func (target *MySQLTarget) History(ctx context.Context) ([]*HistoryRecord, error) {
+	records := make([]*HistoryRecord, 0)
+	err := target.withConn(ctx, func(ctx context.Context, conn *sql.Conn) error {
+		rows, err := conn.QueryContext(ctx, fmt.Sprintf(
+			"SELECT migration_id, COALESCE(description, ''), direction, applied_at, COALESCE(duration_ms, 0), COALESCE(checksum, ''), COALESCE(hostname, ''), COALESCE(username, ''), COALESCE(tool_version, ''), failed, COALESCE(failure, ''), baselined FROM %s ORDER BY applied_at, id",
+			target.historyTable(),
+		))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				record     HistoryRecord
+				direction  string
+				durationMs int64
+			)
+			if err := rows.Scan(&record.ID, &record.Description, &direction, &record.AppliedAt, &durationMs, &record.Checksum, &record.Hostname, &record.User, &record.ToolVersion, &record.Failed, &record.Failure, &record.Baselined); err != nil {
+				return err
+			}
+			if record.Direction, err = ParseDirection(direction); err != nil {
+				return err
+			}
+			record.ID = record.ID.UTC()
+			record.Duration = time.Duration(durationMs) * time.Millisecond
+			records = append(records, &record)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
 }
 
 // SetTableName sets the name of the table used to store the current migrations
@@ -90,24 +310,164 @@ func (target *MySQLTarget) SetTableName(collection string) *MySQLTarget {
 	return target
 }
 
-// Connection returns the mgo.Session reference of this target.
+// Connection returns the `*sql.DB` reference of this target.
 func (target *MySQLTarget) Connection() *sql.DB {
 	return target.connection
 }
 
-func (target *MySQLTarget) ensureMigrationsTable(conn *sql.Conn) error {
-	ctx := context.Background()
-	// ADHOC ADVISED: Tried to use the params with ? but it did not worked.
-	rows, err := conn.QueryContext(ctx, fmt.Sprintf("SHOW TABLES LIKE \"%s\"", target.tableName))
+// Dialect implements the migration.DialectTarget.
+func (target *MySQLTarget) Dialect() splitter.Dialect {
+	return splitter.MySQL
+}
+
+func (target *MySQLTarget) withConn(ctx context.Context, h func(ctx context.Context, conn *sql.Conn) error) error {
+	conn, err := target.connection.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	err = target.ensured.do(func() error {
+		return target.ensureMigrationsTable(ctx, conn)
+	})
+	if err != nil {
+		return err
+	}
+
+	return h(ctx, conn)
+}
+
+// mysqlMigrationsColumns are the columns added to the migrations table after
+// the id. They are added to tables created by older versions.
+var mysqlMigrationsColumns = []tableColumn{
+	{"checksum", "VARCHAR(255) NULL"},
+	{"description", "TEXT NULL"},
+	{"applied_at", "DATETIME(6) NULL"},
+	{"duration_ms", "BIGINT NULL"},
+	{"hostname", "VARCHAR(255) NULL"},
+	{"username", "VARCHAR(255) NULL"},
+	{"tool_version", "VARCHAR(255) NULL"},
+	{"baselined", "BOOLEAN NOT NULL DEFAULT FALSE"},
+}
+
+// mysqlHistoryColumns are the columns added to the history table after it was
+// introduced. They are added to tables created by older versions.
+var mysqlHistoryColumns = []tableColumn{
+	{"failed", "BOOLEAN NOT NULL DEFAULT FALSE"},
+	{"baselined", "BOOLEAN NOT NULL DEFAULT FALSE"},
+}
+
+// ensureMigrationsTable creates the migrations, the history and the repeatable
+// tables. Tables created by older versions are upgraded with the columns they
+// miss.
+//
+// It is called once per target (see withConn).
+func (target *MySQLTarget) ensureMigrationsTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (id DATETIME NOT NULL PRIMARY KEY)", target.table()))
+	if err != nil {
+		return err
+	}
+	columns, err := target.columns(ctx, conn, target.tableName)
+	if err != nil {
+		return err
+	}
+	if err = addMissingColumns(ctx, conn, target.table(), columns, mysqlMigrationsColumns); err != nil {
+		return err
+	}
+
+	if err = target.ensureSideTables(ctx, conn); err != nil {
+		return err
+	}
+	columns, err = target.columns(ctx, conn, target.tableName+"_history")
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
+	return addMissingColumns(ctx, conn, target.historyTable(), columns, mysqlHistoryColumns)
+}
+
+// columns lists the columns of the `table` (not quoted).
+func (target *MySQLTarget) columns(ctx context.Context, conn *sql.Conn, table string) (map[string]bool, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ?", table)
+	if err != nil {
+		return nil, err
+	}
+	return scanColumnNames(rows, 0, 1)
+}
 
-	if rows.Next() {
+// ensureSideTables creates the history table and the table of the repeatable
+// migrations.
+func (target *MySQLTarget) ensureSideTables(ctx context.Context, executor SQLExecutor) error {
+	_, err := executor.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+		migration_id DATETIME NOT NULL,
+		description TEXT NULL,
+		direction VARCHAR(8) NOT NULL,
+		applied_at DATETIME(6) NOT NULL,
+		duration_ms BIGINT NULL,
+		checksum VARCHAR(255) NULL,
+		hostname VARCHAR(255) NULL,
+		username VARCHAR(255) NULL,
+		tool_version VARCHAR(255) NULL,
+		failed BOOLEAN NOT NULL DEFAULT FALSE,
+		failure TEXT NULL,
+		baselined BOOLEAN NOT NULL DEFAULT FALSE
+	)`, target.historyTable()))
+	if err != nil {
+		return err
+	}
+	_, err = executor.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		description VARCHAR(255) NOT NULL PRIMARY KEY,
+		checksum VARCHAR(255) NOT NULL,
+		applied_at DATETIME(6) NOT NULL
+	)`, target.repeatableTable()))
+	return err
+}
+
+// lockName returns the name of the lock used by this target. It is derived
+// from the table name, so targets sharing the same table share the same lock.
+func (target *MySQLTarget) lockName() string {
+	return "go-migration:" + target.tableName
+}
+
+// Lock implements the migration.Locker by acquiring a named lock
+// (`GET_LOCK`). The connection holding the lock is kept until
+// migration.MySQLTarget.Unlock is called.
+func (target *MySQLTarget) Lock(ctx context.Context, timeout time.Duration) error {
+	conn, err := target.connection.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	// GET_LOCK waits indefinitely with a negative timeout.
+	seconds := int64(-1)
+	if timeout > 0 {
+		seconds = int64(math.Ceil(timeout.Seconds()))
+	}
+
+	var acquired sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", target.lockName(), seconds).Scan(&acquired)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		conn.Close()
+		return ErrLockTimeout
+	}
+
+	target.lockConn = conn
+	return nil
+}
+
+// Unlock implements the migration.Locker by releasing the named lock.
+func (target *MySQLTarget) Unlock() error {
+	conn := target.lockConn
+	if conn == nil {
 		return nil
 	}
+	target.lockConn = nil
+	defer conn.Close()
 
-	_, err = conn.ExecContext(ctx, fmt.Sprintf("CREATE TABLE %s (id DATETIME PRIMARY KEY)", target.tableName))
+	_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", target.lockName())
 	return err
 }
@@ -0,0 +1,208 @@
+package migration_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"github.com/lab259/go-migration"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func getMySQLDB() (*sql.DB, error) {
+	db, err := sql.Open("mysql", "root:root@tcp(localhost:3306)/migrations?parseTime=true")
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+var _ = Describe("MySQLTarget", func() {
+	var (
+		db                 *sql.DB
+		m1, m2, m3, m4, m5 *migration.DefaultMigration
+		source             *migration.CodeSource
+	)
+	BeforeEach(func() {
+		d, err := getMySQLDB()
+		Expect(err).ToNot(HaveOccurred())
+		db = d
+
+		// Drops the _migrations and _migrations_history tables
+		_, err = db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`, `%s`", migration.DefaultMigrationTable, migration.DefaultMigrationTable+"_history"))
+		Expect(err).ToNot(HaveOccurred())
+
+		source = migration.NewCodeSource()
+
+		baseTime := time.Date(2000, 0, 0, 0, 0, 0, 0, time.UTC)
+
+		m1 = migration.NewMigration(baseTime, "Migration 1")
+		m2 = migration.NewMigration(baseTime.Add(time.Second), "Migration 2")
+		m3 = migration.NewMigration(baseTime.Add(time.Hour), "Migration 3")
+		m4 = migration.NewMigration(baseTime.Add(time.Hour*24), "Migration 4")
+		m5 = migration.NewMigration(baseTime.Add(time.Hour*24*10), "Migration 5")
+		source.Register(m1)
+		source.Register(m2)
+		source.Register(m3)
+		source.Register(m4)
+		source.Register(m5)
+	})
+
+	AfterEach(func() {
+		db.Close()
+		db = nil
+	})
+
+	It("should return a new instance of a MySQLTarget", func() {
+		target := migration.NewMySQL(db)
+		Expect(target).NotTo(BeNil())
+	})
+
+	It("should add migrations to the execution list", func() {
+		target := migration.NewMySQL(db)
+		Expect(target.AddMigration(migration.NewSummary(m1))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m2))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m5))).To(Succeed())
+
+		migrations := make([]*migrationFromDB, 0, 3)
+		rows, err := db.Query(fmt.Sprintf("SELECT id FROM `%s` ORDER BY id", migration.DefaultMigrationTable))
+		Expect(err).ToNot(HaveOccurred())
+		defer rows.Close()
+		for rows.Next() {
+			var id time.Time
+			Expect(rows.Scan(&id)).To(Succeed())
+			migrations = append(migrations, &migrationFromDB{id})
+		}
+		Expect(rows.Err()).ToNot(HaveOccurred())
+		Expect(migrations).To(HaveLen(3))
+		Expect(migrations[0].ID).To(Equal(m1.GetID()))
+		Expect(migrations[1].ID).To(Equal(m2.GetID()))
+		Expect(migrations[2].ID).To(Equal(m5.GetID()))
+	})
+
+	It("should return NoVersion when there is no migrations ran", func() {
+		target := migration.NewMySQL(db)
+		version, err := target.Version()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal(migration.NoVersion))
+	})
+
+	It("should return the current version with an arbitrary addition of migrations", func() {
+		target := migration.NewMySQL(db)
+		Expect(target.AddMigration(migration.NewSummary(m5))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m3))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m1))).To(Succeed())
+
+		version, err := target.Version()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal(m5.GetID()))
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(Equal([]time.Time{m1.GetID(), m3.GetID(), m5.GetID()}))
+	})
+
+	It("should remove a migration from the database", func() {
+		target := migration.NewMySQL(db)
+		Expect(target.AddMigration(migration.NewSummary(m5))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m3))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m1))).To(Succeed())
+
+		Expect(target.RemoveMigration(migration.NewSummary(m3))).To(Succeed())
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(Equal([]time.Time{m1.GetID(), m5.GetID()}))
+	})
+
+	It("should quote the name of the table", func() {
+		target := migration.NewMySQL(db).SetTableName("migrations`table")
+		defer db.Exec("DROP TABLE IF EXISTS `migrations``table`, `migrations``table_history`")
+
+		Expect(target.AddMigration(migration.NewSummary(m1))).To(Succeed())
+
+		var count int
+		Expect(db.QueryRow("SELECT count(*) FROM `migrations``table`").Scan(&count)).To(Succeed())
+		Expect(count).To(Equal(1))
+	})
+
+	It("should run the migrations through the manager", func() {
+		manager := migration.NewManager(migration.NewMySQL(db), source)
+
+		summaries, err := manager.Migrate(&nopReporter{}, db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summaries).To(HaveLen(5))
+
+		migrations, err := manager.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(Equal([]time.Time{m1.GetID(), m2.GetID(), m3.GetID(), m4.GetID(), m5.GetID()}))
+	})
+
+	It("should add the new columns to an existing migrations table", func() {
+		_, err := db.Exec(fmt.Sprintf("CREATE TABLE `%s` (id DATETIME PRIMARY KEY)", migration.DefaultMigrationTable))
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Exec(fmt.Sprintf("INSERT INTO `%s` (id) VALUES (?)", migration.DefaultMigrationTable), m1.GetID())
+		Expect(err).ToNot(HaveOccurred())
+
+		target := migration.NewMySQL(db)
+		Expect(target.AddMigration(migration.NewSummary(m2))).To(Succeed())
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(Equal([]time.Time{m1.GetID(), m2.GetID()}))
+
+		checksums, err := target.Checksums(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(BeEmpty())
+	})
+
+	It("should store the checksum and the history of the migrations", func() {
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(m1.GetID(), "Migration 1", nopHandler).WithChecksum("checksum 1"))
+		source.Register(migration.NewMigration(m2.GetID(), "Migration 2", func(executionContext interface{}) error {
+			return errors.New("forced error")
+		}))
+		target := migration.NewMySQL(db)
+		manager := migration.NewManager(target, source)
+
+		_, err := manager.Migrate(&nopReporter{}, db)
+		Expect(err).To(HaveOccurred())
+
+		checksums, err := target.Checksums(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(Equal(map[int64]string{
+			m1.GetID().UnixNano(): "checksum 1",
+		}))
+
+		records, err := manager.History()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].ID).To(Equal(m1.GetID()))
+		Expect(records[0].Description).To(Equal("Migration 1"))
+		Expect(records[0].Checksum).To(Equal("checksum 1"))
+		Expect(records[0].ToolVersion).To(Equal(migration.ToolVersion))
+		Expect(records[0].AppliedAt).ToNot(BeZero())
+		Expect(records[0].Failed).To(BeFalse())
+		Expect(records[1].ID).To(Equal(m2.GetID()))
+		Expect(records[1].Direction).To(Equal(migration.DirectionDo))
+		Expect(records[1].Failed).To(BeTrue())
+		Expect(records[1].Failure).To(Equal("forced error"))
+	})
+
+	It("should fail acquiring a lock held by another target", func() {
+		target1 := migration.NewMySQL(db)
+		target2 := migration.NewMySQL(db)
+
+		Expect(target1.Lock(context.Background(), time.Second)).To(Succeed())
+		Expect(target2.Lock(context.Background(), time.Second)).To(Equal(migration.ErrLockTimeout))
+
+		Expect(target1.Unlock()).To(Succeed())
+		Expect(target2.Lock(context.Background(), time.Second)).To(Succeed())
+		Expect(target2.Unlock()).To(Succeed())
+	})
+})
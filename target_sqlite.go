@@ -0,0 +1,434 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lab259/go-migration/splitter"
+)
+
+// SQLiteTarget implements the migration.Target of the SQLite.
+//
+// It works on top of the `database/sql`, so the SQLite driver (eg.
+// github.com/mattn/go-sqlite3) must be imported by the application.
+//
+// SQLite has transactional DDL, so each migration runs inside of a
+// transaction (see migration.TransactionalTarget). Since every connection to
+// an in-memory database (`:memory:`) opens a new database, in-memory
+// databases should be used with `db.SetMaxOpenConns(1)`.
+type SQLiteTarget struct {
+	db                  *sql.DB
+	tableName           string
+	historyTableName    string
+	repeatableTableName string
+	ensured             ensureOnce
+}
+
+// NewSQLiteTarget returns a new instance of the migration.SQLiteTarget.
+func NewSQLiteTarget(db *sql.DB) *SQLiteTarget {
+	return &SQLiteTarget{
+		db:                  db,
+		tableName:           sqliteQuoteIdentifier(DefaultMigrationTable),
+		historyTableName:    sqliteQuoteIdentifier(DefaultMigrationTable + "_history"),
+		repeatableTableName: sqliteQuoteIdentifier(DefaultMigrationTable + "_repeatable"),
+	}
+}
+
+func sqliteQuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// Version implements the migration.Target.Version by returning the most
+// recent migration stored on the migrations table.
+func (target *SQLiteTarget) Version() (time.Time, error) {
+	return target.VersionContext(context.Background())
+}
+
+// VersionContext implements the migration.ContextTarget.
+func (target *SQLiteTarget) VersionContext(ctx context.Context) (time.Time, error) {
+	version := NoVersion
+	err := target.withEnsured(ctx, func() error {
+		err := target.db.QueryRowContext(ctx, fmt.Sprintf("SELECT id FROM %s ORDER BY id DESC LIMIT 1", target.tableName)).Scan(&version)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return NoVersion, err
+	}
+	return version.UTC(), nil
+}
+
+// AddMigration implements the migration.Target.AddMigration by inserting the
+// migration into the migrations table.
+func (target *SQLiteTarget) AddMigration(summary *Summary) error {
+	return target.AddMigrationContext(context.Background(), summary)
+}
+
+// AddMigrationContext implements the migration.ContextTarget.
+func (target *SQLiteTarget) AddMigrationContext(ctx context.Context, summary *Summary) error {
+	return target.withEnsured(ctx, func() error {
+		return runInTransaction(ctx, target.db, func(tx *sql.Tx) error {
+			return target.addMigration(ctx, tx, summary)
+		})
+	})
+}
+
+// addMigration inserts the migration into the migrations table, appending
+// it to the history table.
+func (target *SQLiteTarget) addMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	record := NewHistoryRecord(summary)
+	if IsRepeatable(summary.Migration) {
+		_, err := executor.ExecContext(ctx, fmt.Sprintf(
+			"INSERT OR REPLACE INTO %s (description, checksum, applied_at) VALUES (?, ?, ?)",
+			target.repeatableTableName,
+		), record.Description, record.Checksum, record.AppliedAt.UTC())
+		return err
+	}
+	_, err := executor.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (id, checksum, description, applied_at, duration_ms, hostname, username, tool_version, baselined) VALUES (?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?, ?)",
+		target.tableName,
+	), record.ID.UTC(), record.Checksum, record.Description, record.AppliedAt.UTC(), int64(record.Duration/time.Millisecond), record.Hostname, record.User, record.ToolVersion, record.Baselined)
+	if err != nil {
+		return err
+	}
+	return target.addHistory(ctx, executor, record)
+}
+
+// RemoveMigration implements the migration.Target.RemoveMigration by deleting
+// the migration from the migrations table.
+func (target *SQLiteTarget) RemoveMigration(summary *Summary) error {
+	return target.RemoveMigrationContext(context.Background(), summary)
+}
+
+// RemoveMigrationContext implements the migration.ContextTarget.
+func (target *SQLiteTarget) RemoveMigrationContext(ctx context.Context, summary *Summary) error {
+	return target.withEnsured(ctx, func() error {
+		return runInTransaction(ctx, target.db, func(tx *sql.Tx) error {
+			return target.removeMigration(ctx, tx, summary)
+		})
+	})
+}
+
+// removeMigration deletes the migration from the migrations table, appending
+// the revert to the history table.
+func (target *SQLiteTarget) removeMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	_, err := executor.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = ?", target.tableName), summary.Migration.GetID().UTC())
+	if err != nil {
+		return err
+	}
+	return target.addHistory(ctx, executor, NewHistoryRecord(summary))
+}
+
+// addHistory inserts the record into the history table.
+func (target *SQLiteTarget) addHistory(ctx context.Context, executor SQLExecutor, record *HistoryRecord) error {
+	_, err := executor.ExecContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (migration_id, description, direction, applied_at, duration_ms, checksum, hostname, username, tool_version, failed, failure, baselined) VALUES (?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?, ?, ?, ?)",
+		target.historyTableName,
+	), record.ID.UTC(), record.Description, record.Direction.String(), record.AppliedAt.UTC(), int64(record.Duration/time.Millisecond), record.Checksum, record.Hostname, record.User, record.ToolVersion, record.Failed, record.Failure, record.Baselined)
+	return err
+}
+
+// MigrationsExecuted implements the migration.Target.MigrationsExecuted by
+// listing the migrations stored on the migrations table.
+func (target *SQLiteTarget) MigrationsExecuted() ([]time.Time, error) {
+	return target.MigrationsExecutedContext(context.Background())
+}
+
+// MigrationsExecutedContext implements the migration.ContextTarget.
+func (target *SQLiteTarget) MigrationsExecutedContext(ctx context.Context) ([]time.Time, error) {
+	migrations := make([]time.Time, 0, 10)
+	err := target.withEnsured(ctx, func() error {
+		rows, err := target.db.QueryContext(ctx, fmt.Sprintf("SELECT id FROM %s ORDER BY id", target.tableName))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id time.Time
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			migrations = append(migrations, id.UTC())
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return migrations, nil
+}
+
+// Checksums implements the migration.ChecksumTarget.
+func (target *SQLiteTarget) Checksums(ctx context.Context) (map[int64]string, error) {
+	checksums := make(map[int64]string)
+	err := target.withEnsured(ctx, func() error {
+		rows, err := target.db.QueryContext(ctx, fmt.Sprintf("SELECT id, checksum FROM %s WHERE checksum IS NOT NULL", target.tableName))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				id       time.Time
+				checksum string
+			)
+			if err := rows.Scan(&id, &checksum); err != nil {
+				return err
+			}
+			checksums[id.UnixNano()] = checksum
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// RepeatableChecksums implements the migration.RepeatableTarget.
+func (target *SQLiteTarget) RepeatableChecksums(ctx context.Context) (map[string]string, error) {
+	checksums := make(map[string]string)
+	err := target.withEnsured(ctx, func() error {
+		rows, err := target.db.QueryContext(ctx, fmt.Sprintf("SELECT description, checksum FROM %s", target.repeatableTableName))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var description, checksum string
+			if err := rows.Scan(&description, &checksum); err != nil {
+				return err
+			}
+			checksums[description] = checksum
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// AddFailure implements the migration.HistoryTarget by inserting the record
+// into the history table.
+func (target *SQLiteTarget) AddFailure(ctx context.Context, record *HistoryRecord) error {
+	return target.withEnsured(ctx, func() error {
+		return target.addHistory(ctx, target.db, record)
+	})
+}
+
+// History implements the migration.HistoryTarget by listing the history
+// table.
+func (target *SQLiteTarget) History(ctx context.Context) ([]*HistoryRecord, error) {
+	records := make([]*HistoryRecord, 0)
+	err := target.withEnsured(ctx, func() error {
+		rows, err := target.db.QueryContext(ctx, fmt.Sprintf(
+			"SELECT migration_id, description, direction, applied_at, duration_ms, COALESCE(checksum, ''), hostname, username, tool_version, failed, failure, baselined FROM %s ORDER BY applied_at, id",
+			target.historyTableName,
+		))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				record     HistoryRecord
+				direction  string
+				durationMs int64
+			)
+			if err := rows.Scan(&record.ID, &record.Description, &direction, &record.AppliedAt, &durationMs, &record.Checksum, &record.Hostname, &record.User, &record.ToolVersion, &record.Failed, &record.Failure, &record.Baselined); err != nil {
+				return err
+			}
+			if record.Direction, err = ParseDirection(direction); err != nil {
+				return err
+			}
+			record.ID = record.ID.UTC()
+			record.Duration = time.Duration(durationMs) * time.Millisecond
+			records = append(records, &record)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// withEnsured makes sure the migrations tables exist before calling `h`.
+func (target *SQLiteTarget) withEnsured(ctx context.Context, h func() error) error {
+	if err := target.ensure(ctx); err != nil {
+		return err
+	}
+	return h()
+}
+
+// sqliteMigrationsColumns are the columns added to the migrations table after
+// it was introduced. They are added to tables created by older versions.
+var sqliteMigrationsColumns = []tableColumn{
+	{"baselined", "BOOLEAN NOT NULL DEFAULT 0"},
+}
+
+// sqliteHistoryColumns are the columns added to the history table after it
+// was introduced. They are added to tables created by older versions.
+var sqliteHistoryColumns = []tableColumn{
+	{"failed", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"baselined", "BOOLEAN NOT NULL DEFAULT 0"},
+}
+
+// ensure creates and upgrades the migrations tables once per target, so the
+// DDL is not repeated on every call.
+func (target *SQLiteTarget) ensure(ctx context.Context) error {
+	return target.ensured.do(func() error {
+		return target.ensureMigrationsTable(ctx)
+	})
+}
+
+// ensureMigrationsTable creates the migrations, the history and the repeatable
+// tables. Tables created by older versions are upgraded with the columns they
+// miss.
+func (target *SQLiteTarget) ensureMigrationsTable(ctx context.Context) error {
+	if err := target.createTables(ctx, target.db); err != nil {
+		return err
+	}
+
+	columns, err := target.columns(ctx, target.tableName)
+	if err != nil {
+		return err
+	}
+	if err = addMissingColumns(ctx, target.db, target.tableName, columns, sqliteMigrationsColumns); err != nil {
+		return err
+	}
+
+	columns, err = target.columns(ctx, target.historyTableName)
+	if err != nil {
+		return err
+	}
+	return addMissingColumns(ctx, target.db, target.historyTableName, columns, sqliteHistoryColumns)
+}
+
+// columns lists the columns of the `table` (quoted).
+func (target *SQLiteTarget) columns(ctx context.Context, table string) (map[string]bool, error) {
+	rows, err := target.db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, err
+	}
+	// cid, name, type, notnull, dflt_value, pk
+	return scanColumnNames(rows, 1, 6)
+}
+
+// createTables creates the migrations table, with all its columns, along
+// with the history and the repeatable tables.
+func (target *SQLiteTarget) createTables(ctx context.Context, executor SQLExecutor) error {
+	_, err := executor.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id DATETIME NOT NULL PRIMARY KEY,
+		checksum TEXT,
+		description TEXT NOT NULL,
+		applied_at DATETIME NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		hostname TEXT NOT NULL,
+		username TEXT NOT NULL,
+		tool_version TEXT NOT NULL,
+		baselined BOOLEAN NOT NULL DEFAULT 0
+	)`, target.tableName))
+	if err != nil {
+		return err
+	}
+	_, err = executor.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		migration_id DATETIME NOT NULL,
+		description TEXT NOT NULL,
+		direction TEXT NOT NULL,
+		applied_at DATETIME NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		checksum TEXT,
+		hostname TEXT NOT NULL,
+		username TEXT NOT NULL,
+		tool_version TEXT NOT NULL,
+		failed BOOLEAN NOT NULL DEFAULT 0,
+		failure TEXT NOT NULL,
+		baselined BOOLEAN NOT NULL DEFAULT 0
+	)`, target.historyTableName))
+	if err != nil {
+		return err
+	}
+	_, err = executor.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		description TEXT NOT NULL PRIMARY KEY,
+		checksum TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`, target.repeatableTableName))
+	return err
+}
+
+// Dialect implements the migration.DialectTarget.
+func (target *SQLiteTarget) Dialect() splitter.Dialect {
+	return splitter.SQLite
+}
+
+// ScriptSetup implements the migration.ScriptTarget.
+func (target *SQLiteTarget) ScriptSetup(ctx context.Context, executor SQLExecutor) error {
+	return target.createTables(ctx, executor)
+}
+
+// ScriptAddMigration implements the migration.ScriptTarget.
+func (target *SQLiteTarget) ScriptAddMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	return target.addMigration(ctx, executor, summary)
+}
+
+// ScriptRemoveMigration implements the migration.ScriptTarget.
+func (target *SQLiteTarget) ScriptRemoveMigration(ctx context.Context, executor SQLExecutor, summary *Summary) error {
+	return target.removeMigration(ctx, executor, summary)
+}
+
+// Begin implements the migration.TransactionalTarget by starting a new
+// transaction on the database. The migrations ran inside of it receive the
+// `*sql.Tx` as execution context.
+func (target *SQLiteTarget) Begin(ctx context.Context) (Transaction, error) {
+	if err := target.ensure(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := target.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqliteTransaction{
+		ctx:    ctx,
+		target: target,
+		tx:     tx,
+	}, nil
+}
+
+// sqliteTransaction is the migration.Transaction returned by the
+// migration.SQLiteTarget.
+type sqliteTransaction struct {
+	// ctx is the context passed to Begin, used to record the migrations.
+	ctx    context.Context
+	target *SQLiteTarget
+	tx     *sql.Tx
+}
+
+func (t *sqliteTransaction) ExecutionContext() interface{} {
+	return t.tx
+}
+
+func (t *sqliteTransaction) AddMigration(summary *Summary) error {
+	return t.target.addMigration(t.ctx, t.tx, summary)
+}
+
+func (t *sqliteTransaction) RemoveMigration(summary *Summary) error {
+	return t.target.removeMigration(t.ctx, t.tx, summary)
+}
+
+func (t *sqliteTransaction) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *sqliteTransaction) Rollback() error {
+	return t.tx.Rollback()
+}
@@ -0,0 +1,358 @@
+package migration_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/lab259/go-migration"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SQLiteTarget", func() {
+	var (
+		db             *sql.DB
+		m1, m2, m3, m5 *migration.DefaultMigration
+	)
+
+	BeforeEach(func() {
+		d, err := sql.Open("sqlite3", ":memory:")
+		Expect(err).ToNot(HaveOccurred())
+		d.SetMaxOpenConns(1)
+		db = d
+
+		baseTime := time.Date(2000, 0, 0, 0, 0, 0, 0, time.UTC)
+
+		m1 = migration.NewMigration(baseTime, "Migration 1", nopHandler, nopHandler)
+		m2 = migration.NewMigration(baseTime.Add(time.Second), "Migration 2", nopHandler, nopHandler)
+		m3 = migration.NewMigration(baseTime.Add(time.Hour), "Migration 3", nopHandler, nopHandler)
+		m5 = migration.NewMigration(baseTime.Add(time.Hour*24*10), "Migration 5", nopHandler, nopHandler)
+	})
+
+	AfterEach(func() {
+		db.Close()
+		db = nil
+	})
+
+	tableExists := func(name string) bool {
+		var count int
+		Expect(db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&count)).To(Succeed())
+		return count > 0
+	}
+
+	It("should return NoVersion when there is no migrations ran", func() {
+		target := migration.NewSQLiteTarget(db)
+		version, err := target.Version()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal(migration.NoVersion))
+	})
+
+	It("should return the current version with an arbitrary addition of migrations", func() {
+		target := migration.NewSQLiteTarget(db)
+		Expect(target.AddMigration(migration.NewSummary(m5))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m3))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m1))).To(Succeed())
+
+		version, err := target.Version()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal(m5.GetID()))
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(Equal([]time.Time{m1.GetID(), m3.GetID(), m5.GetID()}))
+	})
+
+	It("should remove a migration from the database", func() {
+		target := migration.NewSQLiteTarget(db)
+		Expect(target.AddMigration(migration.NewSummary(m5))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m3))).To(Succeed())
+		Expect(target.AddMigration(migration.NewSummary(m1))).To(Succeed())
+
+		Expect(target.RemoveMigration(migration.NewSummary(m3))).To(Succeed())
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(Equal([]time.Time{m1.GetID(), m5.GetID()}))
+	})
+
+	It("should record the migration only when the transaction is committed", func() {
+		target := migration.NewSQLiteTarget(db)
+
+		tx, err := target.Begin(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tx.ExecutionContext()).To(BeAssignableToTypeOf(&sql.Tx{}))
+		Expect(tx.AddMigration(migration.NewSummary(m1))).To(Succeed())
+		Expect(tx.Rollback()).To(Succeed())
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(BeEmpty())
+
+		tx, err = target.Begin(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tx.AddMigration(migration.NewSummary(m1))).To(Succeed())
+		Expect(tx.Commit()).To(Succeed())
+
+		migrations, err = target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(Equal([]time.Time{m1.GetID()}))
+	})
+
+	It("should store the checksum and the history of the migrations", func() {
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(m1.GetID(), "Migration 1", nopHandler).WithChecksum("checksum 1"))
+		source.Register(migration.NewMigration(m2.GetID(), "Migration 2", func(executionContext interface{}) error {
+			return errors.New("forced error")
+		}))
+		target := migration.NewSQLiteTarget(db)
+		manager := migration.NewManager(target, source)
+
+		_, err := manager.Migrate(&nopReporter{}, db)
+		Expect(err).To(MatchError("forced error"))
+
+		checksums, err := target.Checksums(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(Equal(map[int64]string{
+			m1.GetID().UnixNano(): "checksum 1",
+		}))
+
+		records, err := manager.History()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].ID).To(Equal(m1.GetID()))
+		Expect(records[0].Description).To(Equal("Migration 1"))
+		Expect(records[0].Checksum).To(Equal("checksum 1"))
+		Expect(records[0].ToolVersion).To(Equal(migration.ToolVersion))
+		Expect(records[0].Failed).To(BeFalse())
+		Expect(records[1].ID).To(Equal(m2.GetID()))
+		Expect(records[1].Direction).To(Equal(migration.DirectionDo))
+		Expect(records[1].Failed).To(BeTrue())
+		Expect(records[1].Failure).To(Equal("forced error"))
+	})
+
+	It("should keep the migrations reverted on the history", func() {
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		source.Register(m2)
+		target := migration.NewSQLiteTarget(db)
+		manager := migration.NewManager(target, source)
+
+		_, err := manager.Migrate(&nopReporter{}, db)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = manager.Undo(&nopReporter{}, db)
+		Expect(err).ToNot(HaveOccurred())
+
+		records, err := manager.History()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(3))
+		Expect(records[0].ID).To(Equal(m1.GetID()))
+		Expect(records[0].Direction).To(Equal(migration.DirectionDo))
+		Expect(records[1].ID).To(Equal(m2.GetID()))
+		Expect(records[1].Direction).To(Equal(migration.DirectionDo))
+		Expect(records[2].ID).To(Equal(m2.GetID()))
+		Expect(records[2].Direction).To(Equal(migration.DirectionUndo))
+		Expect(records[2].Failed).To(BeFalse())
+	})
+
+	It("should record the migrations baselined", func() {
+		target := migration.NewSQLiteTarget(db)
+		manager := migration.NewManager(target, &migration.DirectorySource{
+			Directory: "test/sqlite",
+			Extension: "sql",
+		})
+
+		summaries, err := manager.Baseline(migration.NewMigrationID("20180101000000"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summaries).To(HaveLen(1))
+		Expect(tableExists("users")).To(BeFalse())
+
+		records, err := manager.History()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].ID).To(Equal(migration.NewMigrationID("20180101000000")))
+		Expect(records[0].Baselined).To(BeTrue())
+		Expect(records[0].Checksum).ToNot(BeEmpty())
+	})
+
+	It("should upgrade the tables created by older versions", func() {
+		_, err := db.Exec(`CREATE TABLE _migrations (
+			id DATETIME NOT NULL PRIMARY KEY,
+			checksum TEXT,
+			description TEXT NOT NULL,
+			applied_at DATETIME NOT NULL,
+			duration_ms INTEGER NOT NULL,
+			hostname TEXT NOT NULL,
+			username TEXT NOT NULL,
+			tool_version TEXT NOT NULL
+		)`)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.Exec(`CREATE TABLE _migrations_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			migration_id DATETIME NOT NULL,
+			description TEXT NOT NULL,
+			direction TEXT NOT NULL,
+			applied_at DATETIME NOT NULL,
+			duration_ms INTEGER NOT NULL,
+			checksum TEXT,
+			hostname TEXT NOT NULL,
+			username TEXT NOT NULL,
+			tool_version TEXT NOT NULL,
+			failure TEXT NOT NULL
+		)`)
+		Expect(err).ToNot(HaveOccurred())
+
+		source := migration.NewCodeSource()
+		source.Register(m1)
+		source.Register(migration.NewMigration(m2.GetID(), "Migration 2", func(executionContext interface{}) error {
+			return errors.New("forced error")
+		}))
+		manager := migration.NewManager(migration.NewSQLiteTarget(db), source)
+
+		_, err = manager.Migrate(&nopReporter{}, db)
+		Expect(err).To(MatchError("forced error"))
+
+		records, err := manager.History()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].ID).To(Equal(m1.GetID()))
+		Expect(records[0].Failed).To(BeFalse())
+		Expect(records[0].Baselined).To(BeFalse())
+		Expect(records[1].ID).To(Equal(m2.GetID()))
+		Expect(records[1].Failed).To(BeTrue())
+	})
+
+	It("should run SQL files inside of transactions", func() {
+		target := migration.NewSQLiteTarget(db)
+		manager := migration.NewManager(target, &migration.DirectorySource{
+			Directory: "test/sqlite",
+			Extension: "sql",
+		})
+
+		ms, err := manager.Migrate(&nopReporter{}, db)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("test/sqlite/20180102000000_create_posts.up.sql:2: no such table: missing_table"))
+		Expect(ms).To(HaveLen(2))
+
+		var name string
+		Expect(db.QueryRow("SELECT name FROM users").Scan(&name)).To(Succeed())
+		Expect(name).To(Equal("john"))
+		Expect(tableExists("posts")).To(BeFalse())
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(Equal([]time.Time{migration.NewMigrationID("20180101000000")}))
+
+		_, err = manager.Undo(&nopReporter{}, db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tableExists("users")).To(BeFalse())
+	})
+
+	It("should apply the repeatable migrations when they change", func() {
+		target := migration.NewSQLiteTarget(db)
+		manager := migration.NewManager(target, &migration.DirectorySource{
+			Directory: "test/repeatable",
+			Extension: "sql",
+		})
+
+		ms, err := manager.Migrate(&nopReporter{}, db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ms).To(HaveLen(2))
+
+		var name string
+		Expect(db.QueryRow("SELECT name FROM users_view").Scan(&name)).To(Succeed())
+		Expect(name).To(Equal("john"))
+
+		checksums, err := target.RepeatableChecksums(context.Background())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(checksums).To(HaveKey("users_view"))
+
+		ms, err = manager.Migrate(&nopReporter{}, db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ms).To(BeEmpty())
+
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(Equal([]time.Time{migration.NewMigrationID("20180101000000")}))
+	})
+
+	It("should run the triggers of SQL files", func() {
+		target := migration.NewSQLiteTarget(db)
+		manager := migration.NewManager(target, &migration.DirectorySource{
+			Directory: "test/sqlite_triggers",
+			Extension: "sql",
+		})
+
+		_, err := manager.Migrate(&nopReporter{}, db)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = db.Exec("INSERT INTO posts (title) VALUES ('hello')")
+		Expect(err).ToNot(HaveOccurred())
+		var total int
+		Expect(db.QueryRow("SELECT total FROM counters WHERE name = 'posts'").Scan(&total)).To(Succeed())
+		Expect(total).To(Equal(1))
+	})
+
+	It("should write the SQL script of a plan without executing it", func() {
+		target := migration.NewSQLiteTarget(db)
+		manager := migration.NewManager(target, &migration.DirectorySource{
+			Directory: "test/sqlite",
+			Extension: "sql",
+		})
+
+		plan, err := manager.PlanMigrateTo(migration.NewMigrationID("20180101000000"))
+		Expect(err).ToNot(HaveOccurred())
+
+		var script bytes.Buffer
+		Expect(manager.WriteSQL(plan, &script)).To(Succeed())
+		Expect(script.String()).To(ContainSubstring("-- do [20180101000000] create_users\nBEGIN;\nCREATE TABLE users"))
+		Expect(script.String()).To(ContainSubstring("INSERT INTO users (name) VALUES ('john');"))
+		Expect(script.String()).To(ContainSubstring(`INSERT INTO "_migrations"`))
+		Expect(script.String()).To(ContainSubstring("COMMIT;"))
+		Expect(tableExists("users")).To(BeFalse())
+		migrations, err := target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(BeEmpty())
+
+		_, err = db.Exec(script.String())
+		Expect(err).ToNot(HaveOccurred())
+
+		var name string
+		Expect(db.QueryRow("SELECT name FROM users").Scan(&name)).To(Succeed())
+		Expect(name).To(Equal("john"))
+		migrations, err = target.MigrationsExecuted()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(migrations).To(Equal([]time.Time{migration.NewMigrationID("20180101000000")}))
+
+		plan, err = manager.PlanRewind()
+		Expect(err).ToNot(HaveOccurred())
+		script.Reset()
+		Expect(manager.WriteSQL(plan, &script)).To(Succeed())
+		Expect(script.String()).To(ContainSubstring("DROP TABLE users;"))
+		Expect(script.String()).To(ContainSubstring(`DELETE FROM "_migrations" WHERE id = '2018-01-01 00:00:00+00:00';`))
+	})
+
+	It("should not write the SQL script of a plan with migrations written in Go", func() {
+		called := false
+		source := migration.NewCodeSource()
+		source.Register(migration.NewMigration(m1.GetID(), "Migration 1", func(executionContext interface{}) error {
+			called = true
+			return nil
+		}))
+		manager := migration.NewManager(migration.NewSQLiteTarget(db), source)
+
+		plan, err := manager.PlanMigrate()
+		Expect(err).ToNot(HaveOccurred())
+
+		var script bytes.Buffer
+		err = manager.WriteSQL(plan, &script)
+		Expect(err).To(BeAssignableToTypeOf(&migration.UnscriptableMigrationError{}))
+		Expect(err.(*migration.UnscriptableMigrationError).Migration).To(Equal(plan.Steps[0].Migration))
+		Expect(script.Len()).To(BeZero())
+		Expect(called).To(BeFalse())
+	})
+})
@@ -0,0 +1,10 @@
+CREATE TABLE a (id int);
+
+-- the default value has a semicolon; it must not split the statement
+CREATE TABLE b (name text DEFAULT 'x;y');
+
+CREATE FUNCTION f() RETURNS int AS $$
+BEGIN
+  RETURN 1;
+END;
+$$ LANGUAGE plpgsql;
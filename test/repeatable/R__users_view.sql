@@ -0,0 +1,2 @@
+DROP VIEW IF EXISTS users_view;
+CREATE VIEW users_view AS SELECT name FROM users;
@@ -0,0 +1,2 @@
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL DEFAULT 'a;b');
+INSERT INTO users (name) VALUES ('john');
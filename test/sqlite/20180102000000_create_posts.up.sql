@@ -0,0 +1,2 @@
+CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT NOT NULL);
+INSERT INTO missing_table VALUES (1);
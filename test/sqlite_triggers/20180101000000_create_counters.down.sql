@@ -0,0 +1,3 @@
+DROP TRIGGER count_posts;
+DROP TABLE counters;
+DROP TABLE posts;
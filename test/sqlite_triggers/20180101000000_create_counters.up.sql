@@ -0,0 +1,8 @@
+CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT);
+CREATE TABLE counters (name TEXT PRIMARY KEY, total INTEGER NOT NULL);
+INSERT INTO counters (name, total) VALUES ('posts', 0);
+
+CREATE TRIGGER count_posts AFTER INSERT ON posts
+BEGIN
+	UPDATE counters SET total = total + 1 WHERE name = 'posts';
+END;